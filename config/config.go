@@ -11,6 +11,34 @@ type Config struct {
 	DBConnectionString string
 	DBName             string
 	JWTSecret          string
+
+	// On-chain deposit verification; ChainNetwork "trc20" or "erc20", empty disables it
+	ChainNetwork          string
+	ChainRPCURL           string
+	ChainAPIKey           string
+	USDTContract          string
+	ChainMinConfirmations int64
+	AutoApproveDeposits   bool
+
+	// Outgoing mail for OTPs; required unless DevMode, which logs messages instead
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// CSV of IFSC codes (IFSC, BANK, BRANCH, ...); empty checks the format only
+	IFSCMasterFile string
+
+	// Daily and monthly limits reset at midnight and on the 1st in this zone
+	LimitsTimezone string
+
+	// Local development only: auth cookies work over plain HTTP and, without
+	// SMTPHost, reset codes are written to the log
+	DevMode bool
+
+	// How long a rate quote from POST /users/auth/rate-quote can be redeemed
+	RateQuoteTTLSeconds int
 }
 
 var Cfg Config
@@ -22,6 +50,23 @@ func LoadConfig() {
 	// Optional: read from environment variables too
 	viper.AutomaticEnv()
 
+	// Defaults also register the keys so they can come from the environment alone
+	viper.SetDefault("ChainNetwork", "")
+	viper.SetDefault("ChainRPCURL", "")
+	viper.SetDefault("ChainAPIKey", "")
+	viper.SetDefault("USDTContract", "")
+	viper.SetDefault("ChainMinConfirmations", 20)
+	viper.SetDefault("AutoApproveDeposits", false)
+	viper.SetDefault("SMTPHost", "")
+	viper.SetDefault("SMTPPort", 587)
+	viper.SetDefault("SMTPUsername", "")
+	viper.SetDefault("SMTPPassword", "")
+	viper.SetDefault("SMTPFrom", "")
+	viper.SetDefault("IFSCMasterFile", "")
+	viper.SetDefault("LimitsTimezone", "Asia/Kolkata")
+	viper.SetDefault("RateQuoteTTLSeconds", 120)
+	viper.SetDefault("DevMode", false)
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file: %v", err)
 	}
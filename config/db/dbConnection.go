@@ -59,3 +59,18 @@ func GetClient() *mongo.Client {
 	}
 	return clientInstance
 }
+
+// WithTransaction runs fn inside a multi-document transaction on a new session.
+// fn must use the context it is given so its operations join the transaction.
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := GetClient().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		return nil, fn(txCtx)
+	})
+	return err
+}
@@ -1,11 +1,16 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
+	sessionHandler "p2p/handlers/sessions"
+	"p2p/handlers/twofactor"
 	"p2p/models"
 	"p2p/services/admin"
+	"p2p/services/sessions"
 	"p2p/utils"
 	midleware "p2p/utils/midleWare"
+	"p2p/utils/money"
 	"p2p/utils/response"
 
 	"github.com/gin-gonic/gin"
@@ -14,7 +19,7 @@ import (
 type AdminHandler struct{}
 
 func (h *AdminHandler) RegisterAdmin(c *gin.Context) {
-	var req models.User
+	var req models.RegisterRequest
 
 	if err := c.BindJSON(&req); err != nil {
 		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
@@ -45,9 +50,31 @@ func (h *AdminHandler) SignInAdmin(c *gin.Context) {
 		return
 	}
 
-	midleware.GenerateJWT(user.Email, user.ID.Hex(), user.Role)
+	// Accounts with 2FA get a pre-auth token and finish at /login/2fa
+	if user.TOTPEnabled {
+		preAuth, err := midleware.GeneratePreAuthToken(user.Email, user.ID.Hex(), user.Role)
+		if err != nil {
+			response.HandleError(c, err, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+		twofactor.PreAuthResponse(c, preAuth)
+		return
+	}
+
+	ss := sessions.SessionServiceInterface(&sessions.SessionService{})
+	tokens, err := ss.IssueTokens(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		response.HandleError(c, err, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	sessionHandler.SetAuthCookies(c, tokens)
 
-	response.SuccessResponse(c, "Admin signed in successfully", gin.H{"admin": user}, http.StatusOK)
+	response.SuccessResponse(c, "Admin signed in successfully", gin.H{
+		"admin":         user,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn}, http.StatusOK)
 }
 
 func (h *AdminHandler) FetchAdminConfig(c *gin.Context) {
@@ -83,7 +110,7 @@ func (h *AdminHandler) UpsertSecureWalletAddress(c *gin.Context) {
 
 func (h *AdminHandler) UpsertUSDTRate(c *gin.Context) {
 	var req struct {
-		USDTRate string `json:"usdt_rate"`
+		USDTRate money.Amount `json:"usdt_rate"`
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -91,6 +118,11 @@ func (h *AdminHandler) UpsertUSDTRate(c *gin.Context) {
 		return
 	}
 
+	if !req.USDTRate.IsPositive() {
+		response.HandleError(c, errors.New("usdt_rate must be greater than zero"), "Invalid USDT rate", http.StatusBadRequest)
+		return
+	}
+
 	s := admin.AdminServiceInterface(&admin.AdminService{})
 	id, err := s.UpsertAdminConfig(models.AdminConfigData{USDTRate: req.USDTRate})
 	if err != nil {
@@ -101,6 +133,94 @@ func (h *AdminHandler) UpsertUSDTRate(c *gin.Context) {
 	response.SuccessResponse(c, "USDT rate updated successfully", gin.H{"config_id": id}, http.StatusOK)
 }
 
+func (h *AdminHandler) UpsertTOTPThreshold(c *gin.Context) {
+	var req struct {
+		Threshold money.Amount `json:"totp_approval_threshold"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	if req.Threshold.IsNegative() {
+		response.HandleError(c, errors.New("totp_approval_threshold can't be negative"), "Invalid TOTP threshold", http.StatusBadRequest)
+		return
+	}
+
+	// Zero is stored as-is and switches the check off
+	threshold := req.Threshold.RoundUSDT()
+	s := admin.AdminServiceInterface(&admin.AdminService{})
+	id, err := s.UpsertAdminConfig(models.AdminConfigData{TOTPApprovalThreshold: &threshold})
+	if err != nil {
+		response.HandleError(c, err, "Failed to update TOTP threshold", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "TOTP approval threshold updated successfully", gin.H{"config_id": id}, http.StatusOK)
+}
+
+func (h *AdminHandler) UpsertDualApprovalThreshold(c *gin.Context) {
+	var req struct {
+		Threshold money.Amount `json:"dual_approval_threshold"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	if req.Threshold.IsNegative() {
+		response.HandleError(c, errors.New("dual_approval_threshold can't be negative"), "Invalid dual approval threshold", http.StatusBadRequest)
+		return
+	}
+
+	// Zero is stored as-is and switches maker-checker off
+	threshold := req.Threshold.RoundUSDT()
+	s := admin.AdminServiceInterface(&admin.AdminService{})
+	id, err := s.UpsertAdminConfig(models.AdminConfigData{DualApprovalThreshold: &threshold})
+	if err != nil {
+		response.HandleError(c, err, "Failed to update dual approval threshold", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Dual approval threshold updated successfully", gin.H{"config_id": id}, http.StatusOK)
+}
+
+func (h *AdminHandler) GetRejectionReasons(c *gin.Context) {
+	s := admin.AdminServiceInterface(&admin.AdminService{})
+	reasons, err := s.RejectionReasons()
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch rejection reasons", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Rejection reasons fetched successfully", reasons, http.StatusOK)
+}
+
+func (h *AdminHandler) UpsertRejectionReasons(c *gin.Context) {
+	var req struct {
+		RejectionReasons []models.RejectionReason `json:"rejection_reasons"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := admin.AdminServiceInterface(&admin.AdminService{})
+	if err := s.UpsertRejectionReasons(req.RejectionReasons); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, admin.ErrInvalidReasons) {
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to update rejection reasons", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Rejection reasons updated successfully", nil, http.StatusOK)
+}
+
 func (h *AdminHandler) UpsertQRCode(c *gin.Context) {
 	// Expect multipart/form-data with "file"
 	fileHeader, err := c.FormFile("file")
@@ -138,3 +258,14 @@ func (h *AdminHandler) GetLedgerStats(c *gin.Context) {
 
 	response.SuccessResponse(c, "Ledger stats fetched successfully", stats, http.StatusOK)
 }
+
+func (h *AdminHandler) GetCollisions(c *gin.Context) {
+	s := admin.AdminServiceInterface(&admin.AdminService{})
+	report, err := s.GetCollisions()
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch collision report", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Collision report fetched successfully", report, http.StatusOK)
+}
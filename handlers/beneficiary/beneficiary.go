@@ -0,0 +1,173 @@
+package beneficiary
+
+import (
+	"errors"
+	"net/http"
+	"p2p/models"
+	"p2p/services/beneficiary"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BeneficiaryHandler struct{}
+
+// Save a bank account for withdrawals
+func (h *BeneficiaryHandler) CreateBeneficiary(c *gin.Context) {
+	var req models.BeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	result, err := s.Create(c.GetString("userID"), req)
+	if err != nil {
+		response.HandleError(c, err, "Failed to save beneficiary", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Beneficiary saved successfully", result, http.StatusCreated)
+}
+
+// List the caller's beneficiaries
+func (h *BeneficiaryHandler) GetMyBeneficiaries(c *gin.Context) {
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	results, err := s.ListForUser(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch beneficiaries", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Beneficiaries fetched successfully", results, http.StatusOK)
+}
+
+// List beneficiaries for admins; ?user_id= and ?verified=true|false filter
+func (h *BeneficiaryHandler) ListBeneficiaries(c *gin.Context) {
+	var verified *bool
+	if v := c.Query("verified"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			response.HandleError(c, err, "verified must be true or false", http.StatusBadRequest)
+			return
+		}
+		verified = &b
+	}
+
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	results, err := s.List(c.Query("user_id"), verified)
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch beneficiaries", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Beneficiaries fetched successfully", results, http.StatusOK)
+}
+
+// Get beneficiary by ID
+func (h *BeneficiaryHandler) GetBeneficiaryByID(c *gin.Context) {
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	result, err := s.Get(c.Param("id"))
+	if err != nil {
+		response.HandleError(c, err, "Beneficiary not found", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Beneficiary fetched successfully", result, http.StatusOK)
+}
+
+// Replace the caller's beneficiary; new bank details need verifying again
+func (h *BeneficiaryHandler) UpdateBeneficiary(c *gin.Context) {
+	var req models.BeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	result, err := s.Update(c.Param("id"), c.GetString("userID"), req)
+	if err != nil {
+		response.HandleError(c, err, "Failed to update beneficiary", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Beneficiary updated successfully", result, http.StatusOK)
+}
+
+// Remove one of the caller's beneficiaries
+func (h *BeneficiaryHandler) DeleteBeneficiary(c *gin.Context) {
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	if err := s.Delete(c.Param("id"), c.GetString("userID")); err != nil {
+		response.HandleError(c, err, "Failed to delete beneficiary", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Beneficiary deleted successfully", nil, http.StatusOK)
+}
+
+// Mark a beneficiary as checked by an admin, or undo it
+func (h *BeneficiaryHandler) VerifyBeneficiary(c *gin.Context) {
+	var req models.VerifyBeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	if err := s.Verify(c.Param("id"), actor, req.Verified); err != nil {
+		response.HandleError(c, err, "Failed to verify beneficiary", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Beneficiary verification updated", gin.H{"verified": req.Verified}, http.StatusOK)
+}
+
+// Look up bank and branch for an IFSC code
+func (h *BeneficiaryHandler) LookupIFSC(c *gin.Context) {
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	result, err := s.LookupIFSC(c.Param("code"))
+	if err != nil {
+		status := statusFor(err)
+		if errors.Is(err, beneficiary.ErrUnknownIFSC) {
+			status = http.StatusNotFound
+		}
+		response.HandleError(c, err, "IFSC lookup failed", status)
+		return
+	}
+
+	response.SuccessResponse(c, "IFSC found", result, http.StatusOK)
+}
+
+// BeneficiaryOwner resolves the owner of the beneficiary in the :id path param for OwnerOrAdmin
+func (h *BeneficiaryHandler) BeneficiaryOwner(c *gin.Context) (string, error) {
+	s := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	b, err := s.Get(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, beneficiary.ErrBeneficiaryNotFound) {
+			return "", midleware.ErrResourceNotFound
+		}
+		return "", err
+	}
+	return b.UserId.Hex(), nil
+}
+
+// statusFor maps a beneficiary error to the HTTP status to answer with
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, beneficiary.ErrBeneficiaryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, beneficiary.ErrDuplicateBeneficiary):
+		return http.StatusConflict
+	case beneficiary.IsValidationError(err):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
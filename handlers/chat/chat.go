@@ -6,7 +6,9 @@ import (
 	"p2p/models"
 	"p2p/services/chat"
 	"p2p/utils"
+	midleware "p2p/utils/midleWare"
 	"p2p/utils/response"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -41,33 +43,35 @@ func (h *ChatHandler) CreateChat(c *gin.Context) {
 		return
 	}
 
-	// Receiver & message from form-data
+	// Receiver & message from form-data; receiver_id may be "support"
 	receiverID := c.PostForm("receiver_id")
 	message := c.PostForm("message")
 
-	receiverObjID, err := primitive.ObjectIDFromHex(receiverID)
+	receiverObjID, err := chat.ParsePeer(receiverID)
 	if err != nil {
 		response.HandleError(c, err, "Invalid receiver_id", http.StatusBadRequest)
 		return
 	}
 
+	// Refuse before uploading anything
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	role := c.GetString("role")
+	if err := s.CanMessage(role, senderObjID, receiverObjID); err != nil {
+		response.HandleError(c, err, "Cannot message this user", statusFor(err))
+		return
+	}
+
 	form, err := c.MultipartForm()
 	if err != nil {
 		response.HandleError(c, err, "Invalid form data", http.StatusBadRequest)
 		return
 	}
 
-	files := form.File["files"]
-	var fileURLs []string
-
 	// Upload files to Cloudinary
-	for _, fileHeader := range files {
-		url, err := utils.UploadFormFileToCloudinary(c, fileHeader)
-		if err != nil {
-			response.HandleError(c, err, "Cloudinary upload failed", http.StatusInternalServerError)
-			return
-		}
-		fileURLs = append(fileURLs, url)
+	fileURLs, err := utils.UploadFormFilesToCloudinary(c, form.File["files"])
+	if err != nil {
+		response.HandleError(c, err, "Cloudinary upload failed", http.StatusInternalServerError)
+		return
 	}
 
 	// Build chat record
@@ -81,9 +85,8 @@ func (h *ChatHandler) CreateChat(c *gin.Context) {
 	}
 
 	// Save chat
-	s := chat.ChatServiceInterface(&chat.ChatService{})
-	if err := s.CreateChat(chatData); err != nil {
-		response.HandleError(c, err, "Failed to save chat", http.StatusInternalServerError)
+	if err := s.CreateChat(role, chatData); err != nil {
+		response.HandleError(c, err, "Failed to save chat", statusFor(err))
 		return
 	}
 
@@ -122,17 +125,40 @@ func (h *ChatHandler) FetchChats(c *gin.Context) {
 		return
 	}
 
-	receiverObjID, err := primitive.ObjectIDFromHex(receiverID)
+	receiverObjID, err := chat.ParsePeer(receiverID)
 	if err != nil {
 		response.HandleError(c, err, "Invalid receiver_id", http.StatusBadRequest)
 		return
 	}
 
+	// Admins can open any conversation with ?user_id= as the other side
+	if userID := c.Query("user_id"); userID != "" && userID != senderObjID.Hex() {
+		if c.GetString("role") != midleware.RoleAdmin {
+			response.HandleError(c, nil, "Only admins can read other conversations", http.StatusForbidden)
+			return
+		}
+		if senderObjID, err = primitive.ObjectIDFromHex(userID); err != nil {
+			response.HandleError(c, err, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Page through with ?before= or ?after= set to an earlier next_cursor
+	q := models.ChatPageQuery{Before: c.Query("before"), After: c.Query("after")}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			response.HandleError(c, err, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+
 	// Fetch from service
 	service := chat.ChatServiceInterface(&chat.ChatService{})
-	chats, err := service.GetChatsBetweenUsers(senderObjID, receiverObjID)
+	chats, err := service.GetChatsBetweenUsers(senderObjID, receiverObjID, q)
 	if err != nil {
-		response.HandleError(c, err, "Failed to fetch chats", http.StatusInternalServerError)
+		response.HandleError(c, err, "Failed to fetch chats", statusFor(err))
 		return
 	}
 
@@ -173,36 +199,59 @@ func (h *ChatHandler) GetUniqueChatUsers(c *gin.Context) {
 	response.SuccessResponse(c, "Unique chat users fetched successfully", users, http.StatusOK)
 }
 
+// UpdateChatsReadStatus marks what peer_id sent the caller as read, up to
+// up_to or everything so far
 func (h *ChatHandler) UpdateChatsReadStatus(c *gin.Context) {
-	var req struct {
-		ChatIDs []string `json:"chat_ids"`
+	readerID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Invalid user id", http.StatusUnauthorized)
+		return
 	}
 
-	if err := c.BindJSON(&req); err != nil {
+	var req models.ChatReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
 		return
 	}
-
-	if len(req.ChatIDs) == 0 {
-		response.HandleError(c, nil, "chat_ids cannot be empty", http.StatusBadRequest)
+	peerID, err := chat.ParsePeer(req.PeerID)
+	if err != nil {
+		response.HandleError(c, err, "Invalid peer_id", http.StatusBadRequest)
 		return
 	}
-
-	var chatObjIDs []primitive.ObjectID
-	for _, idStr := range req.ChatIDs {
-		objID, err := primitive.ObjectIDFromHex(idStr)
-		if err != nil {
-			response.HandleError(c, err, "Invalid chat_id: "+idStr, http.StatusBadRequest)
-			return
-		}
-		chatObjIDs = append(chatObjIDs, objID)
+	upTo := time.Now()
+	if req.UpTo != nil && req.UpTo.Before(upTo) {
+		upTo = *req.UpTo
 	}
 
 	s := chat.ChatServiceInterface(&chat.ChatService{})
-	if err := s.UpdateChatsReadStatus(chatObjIDs); err != nil {
-		response.HandleError(c, err, "Failed to update chat read status", http.StatusInternalServerError)
+	count, err := s.MarkConversationRead(readerID, peerID, upTo)
+	if err != nil {
+		response.HandleError(c, err, "Failed to update chat read status", statusFor(err))
 		return
 	}
 
-	response.SuccessResponse(c, "Chat read status updated successfully", nil, http.StatusOK)
-}
\ No newline at end of file
+	response.SuccessResponse(c, "Chat read status updated successfully", gin.H{"marked": count}, http.StatusOK)
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, chat.ErrInvalidRecipient),
+		errors.Is(err, chat.ErrInvalidCursor),
+		errors.Is(err, chat.ErrBothCursors),
+		errors.Is(err, chat.ErrInvalidAssignee),
+		errors.Is(err, chat.ErrInvalidInboxView),
+		errors.Is(err, chat.ErrEmptyMessage):
+		return http.StatusBadRequest
+	case errors.Is(err, chat.ErrRecipientNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, chat.ErrConversationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, chat.ErrAlreadyAssigned),
+		errors.Is(err, chat.ErrAssignedElsewhere),
+		errors.Is(err, chat.ErrAssignmentChanged),
+		errors.Is(err, chat.ErrNotAssigned):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,163 @@
+package chat
+
+import (
+	"net/http"
+	"p2p/models"
+	"p2p/services/chat"
+	"p2p/utils"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/response"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Support inbox; ?view=unassigned (default), mine or all
+func (h *ChatHandler) GetSupportInbox(c *gin.Context) {
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	results, err := s.SupportInbox(c.Query("view"), actor)
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch support inbox", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Support inbox fetched successfully", results, http.StatusOK)
+}
+
+// Get a user's support conversation with its assignment history. The
+// messages come from GET /chat/?user_id=<id>&receiver_id=support.
+func (h *ChatHandler) GetSupportConversation(c *gin.Context) {
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	result, err := s.SupportConversation(c.Param("id"))
+	if err != nil {
+		response.HandleError(c, err, "Support conversation not found", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Support conversation fetched successfully", result, http.StatusOK)
+}
+
+// Take an unassigned conversation
+func (h *ChatHandler) ClaimSupportConversation(c *gin.Context) {
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	result, err := s.ClaimSupport(c.Param("id"), actor)
+	if err != nil {
+		response.HandleError(c, err, "Failed to claim conversation", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Conversation claimed successfully", result, http.StatusOK)
+}
+
+// Assign a conversation to an admin, transferring it if someone holds it
+func (h *ChatHandler) AssignSupportConversation(c *gin.Context) {
+	var req models.SupportAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	result, err := s.AssignSupport(c.Param("id"), actor, req)
+	if err != nil {
+		response.HandleError(c, err, "Failed to assign conversation", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Conversation assigned successfully", result, http.StatusOK)
+}
+
+// Put a conversation back in the unassigned inbox
+func (h *ChatHandler) ReleaseSupportConversation(c *gin.Context) {
+	var req models.SupportReleaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+	}
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	result, err := s.ReleaseSupport(c.Param("id"), actor, req.Reason)
+	if err != nil {
+		response.HandleError(c, err, "Failed to release conversation", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Conversation released successfully", result, http.StatusOK)
+}
+
+// Answer a user as support; form-data with message and optional files
+func (h *ChatHandler) ReplySupportConversation(c *gin.Context) {
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		response.HandleError(c, err, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	fileURLs, err := utils.UploadFormFilesToCloudinary(c, form.File["files"])
+	if err != nil {
+		response.HandleError(c, err, "Cloudinary upload failed", http.StatusInternalServerError)
+		return
+	}
+
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	result, err := s.SupportReply(c.Param("id"), actor, c.PostForm("message"), fileURLs)
+	if err != nil {
+		response.HandleError(c, err, "Failed to send reply", statusFor(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Mark what the user sent support as read, up to ?up_to= (RFC 3339) or everything so far
+func (h *ChatHandler) MarkSupportConversationRead(c *gin.Context) {
+	upTo := time.Now()
+	if v := c.Query("up_to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			response.HandleError(c, err, "up_to must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		if t.Before(upTo) {
+			upTo = t
+		}
+	}
+
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	count, err := s.MarkSupportRead(c.Param("id"), upTo)
+	if err != nil {
+		response.HandleError(c, err, "Failed to update chat read status", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Chat read status updated successfully", gin.H{"marked": count}, http.StatusOK)
+}
@@ -0,0 +1,129 @@
+package chat
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"p2p/models"
+	"p2p/services/chat"
+	"p2p/services/sessions"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/realtime"
+	"p2p/utils/response"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// Clients must send something, e.g. {"type":"ping"}, at least this often
+	wsReadTimeout  = 90 * time.Second
+	wsWriteTimeout = 10 * time.Second
+	wsMaxFrame     = 4 << 10
+)
+
+// IssueWSTicket returns a single-use ticket for opening the socket with
+// GET /chat/ws?ticket=, good for a few seconds
+func (h *ChatHandler) IssueWSTicket(c *gin.Context) {
+	s := sessions.SessionServiceInterface(&sessions.SessionService{})
+	res, err := s.IssueWebSocketTicket(midleware.Claims{
+		Email:     c.GetString("email"),
+		UserID:    c.GetString("userID"),
+		Role:      c.GetString("role"),
+		SessionID: c.GetString("sessionID"),
+	})
+	if err != nil {
+		response.HandleError(c, err, "Failed to issue websocket ticket", http.StatusInternalServerError)
+		return
+	}
+	response.SuccessResponse(c, "WebSocket ticket issued", res, http.StatusOK)
+}
+
+// ServeWS upgrades to a WebSocket that pushes new messages, read receipts,
+// presence and typing events. Messages are still sent with POST /chat/.
+func (h *ChatHandler) ServeWS(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Invalid user id", http.StatusUnauthorized)
+		return
+	}
+	role := c.GetString("role")
+
+	server := websocket.Server{
+		// The caller was checked by WebSocketAuth, never from a cookie, so any
+		// origin may connect
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = wsMaxFrame
+			serveChatSocket(ws, userID, role)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func serveChatSocket(ws *websocket.Conn, userID primitive.ObjectID, role string) {
+	defer ws.Close()
+
+	s := chat.ChatServiceInterface(&chat.ChatService{})
+	client := realtime.NewClient(userID.Hex())
+
+	// 1️⃣ Register and start with the presence of every chat peer
+	snapshot, err := s.Connect(client)
+	if err != nil {
+		log.Printf("Chat socket of %s failed to connect: %v", userID.Hex(), err)
+		writeEvent(ws, realtime.Event{Type: models.ChatEventError, Data: "failed to connect", At: time.Now()})
+		return
+	}
+	defer s.Disconnect(client)
+	if err := writeEvent(ws, realtime.Event{Type: models.ChatEventPresenceSnapshot, Data: snapshot, At: time.Now()}); err != nil {
+		return
+	}
+
+	// 2️⃣ One writer per connection; everything else queues on the client
+	go func() {
+		for {
+			select {
+			case ev := <-client.Events():
+				if err := writeEvent(ws, ev); err != nil {
+					client.Close()
+					return
+				}
+			case <-client.Done():
+				ws.Close()
+				return
+			}
+		}
+	}()
+
+	// 3️⃣ Read client frames until the socket closes or goes quiet
+	for {
+		ws.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		var raw []byte
+		if err := websocket.Message.Receive(ws, &raw); err != nil {
+			return
+		}
+
+		var frame models.ChatClientFrame
+		if err := json.Unmarshal(raw, &frame); err != nil {
+			client.Push(realtime.Event{Type: models.ChatEventError, Data: "invalid frame"})
+			continue
+		}
+		switch frame.Type {
+		case "ping":
+			client.Push(realtime.Event{Type: models.ChatEventPong})
+		case models.ChatEventTyping:
+			if err := s.Typing(role, userID, frame.To, frame.Typing); err != nil {
+				client.Push(realtime.Event{Type: models.ChatEventError, Data: err.Error()})
+			}
+		default:
+			client.Push(realtime.Event{Type: models.ChatEventError, Data: "unknown frame type"})
+		}
+	}
+}
+
+func writeEvent(ws *websocket.Conn, ev realtime.Event) error {
+	ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return websocket.JSON.Send(ws, ev)
+}
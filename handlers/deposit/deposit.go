@@ -1,12 +1,17 @@
 package deposit
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"p2p/models"
+	adminService "p2p/services/admin"
 	"p2p/services/deposit"
+	"p2p/services/limits"
+	"p2p/services/rates"
+	"p2p/services/twofactor"
+	midleware "p2p/utils/midleWare"
 	"p2p/utils/response"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,6 +19,11 @@ import (
 
 type DepositHandler struct{}
 
+// NewDepositService builds the service the handlers call; route tests swap it
+var NewDepositService = func() deposit.DepositServiceInterface {
+	return &deposit.DepositService{}
+}
+
 // Create deposit request
 func (h *DepositHandler) CreateDeposit(c *gin.Context) {
 	var req models.DepositRequest
@@ -24,18 +34,34 @@ func (h *DepositHandler) CreateDeposit(c *gin.Context) {
 		return
 	}
 
-	oid, _ := primitive.ObjectIDFromHex(userIDVal.(string))
-	req.UserId = oid
-	req.Status = "Pending"
-
 	if err := c.BindJSON(&req); err != nil {
 		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
 		return
 	}
 
-	s := deposit.DepositServiceInterface(&deposit.DepositService{})
+	// Owner and status come from the session, never from the body
+	oid, _ := primitive.ObjectIDFromHex(userIDVal.(string))
+	req.UserId = oid
+	req.Status = models.StatusPending
+
+	s := NewDepositService()
 	if err := s.CreateDeposit(req); err != nil {
-		response.HandleError(c, err, "Failed to create deposit request", http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, deposit.ErrInvalidAmount), errors.Is(err, deposit.ErrMissingTxHash):
+			status = http.StatusBadRequest
+		case errors.Is(err, deposit.ErrDuplicateTxHash):
+			status = http.StatusConflict
+		case limits.IsLimitError(err):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, rates.ErrQuoteNotFound):
+			status = http.StatusBadRequest
+		case rates.IsQuoteError(err):
+			status = http.StatusConflict
+		case errors.Is(err, rates.ErrRateUnavailable):
+			status = http.StatusServiceUnavailable
+		}
+		response.HandleError(c, err, "Failed to create deposit request", status)
 		return
 	}
 
@@ -50,17 +76,82 @@ func (h *DepositHandler) UpdateDepositStatus(c *gin.Context) {
 		return
 	}
 
-	approve := strings.EqualFold(req.Status, "Approved")
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
 
-	s := deposit.DepositServiceInterface(&deposit.DepositService{})
-	if err := s.UpdateDepositStatus(req.ID, approve); err != nil {
-		response.HandleError(c, err, "Failed to update deposit status", http.StatusInternalServerError)
+	s := NewDepositService()
+	if err := s.UpdateDepositStatus(req, actor); err != nil {
+		response.HandleError(c, err, "Failed to update deposit status", updateStatusCode(err))
 		return
 	}
 
 	response.SuccessResponse(c, "Deposit status updated successfully", nil, http.StatusOK)
 }
 
+// Apply one status to many deposits, reporting the outcome per item
+func (h *DepositHandler) BulkUpdateDepositStatus(c *gin.Context) {
+	var req models.BulkStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := NewDepositService()
+	res, err := s.BulkUpdateDepositStatus(req, actor)
+	if err != nil {
+		status := updateStatusCode(err)
+		if errors.Is(err, deposit.ErrBulkEmpty) || errors.Is(err, deposit.ErrBulkTooLarge) {
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to update deposit status", status)
+		return
+	}
+
+	for i := range res.Results {
+		item := &res.Results[i]
+		switch {
+		case item.Err == nil:
+			item.StatusCode = http.StatusOK
+		case errors.Is(item.Err, deposit.ErrDuplicateItem):
+			item.Error = item.Err.Error()
+			item.StatusCode = http.StatusBadRequest
+		default:
+			item.Error = item.Err.Error()
+			item.StatusCode = updateStatusCode(item.Err)
+		}
+	}
+
+	response.SuccessResponse(c, "Bulk deposit status update processed", res, http.StatusOK)
+}
+
+// updateStatusCode maps a status change failure to the HTTP status to answer with
+func updateStatusCode(err error) int {
+	switch {
+	case errors.Is(err, deposit.ErrDepositNotPending):
+		return http.StatusConflict
+	case errors.Is(err, deposit.ErrDepositNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, deposit.ErrUnknownStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, deposit.ErrIllegalTransition):
+		return http.StatusUnprocessableEntity
+	case isTOTPError(err):
+		return http.StatusForbidden
+	case adminService.IsReviewInputError(err):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *DepositHandler) GetUserDeposits(c *gin.Context) {
 	// Get userID from context
 	userIDVal, exists := c.Get("userID")
@@ -70,20 +161,24 @@ func (h *DepositHandler) GetUserDeposits(c *gin.Context) {
 	}
 	userID := userIDVal.(string)
 
-	s := deposit.DepositServiceInterface(&deposit.DepositService{})
+	s := NewDepositService()
 	data, err := s.GetDepositsByUserID(userID)
 	if err != nil {
 		response.HandleError(c, err, "Failed to fetch deposits", http.StatusInternalServerError)
 		return
 	}
 
+	for i := range data {
+		data[i].AdminNotes = nil
+	}
+
 	response.SuccessResponse(c, "Deposits fetched successfully", data, http.StatusOK)
 }
 
 // List deposits with pagination
 func (h *DepositHandler) ListDeposits(c *gin.Context) {
 
-	s := deposit.DepositServiceInterface(&deposit.DepositService{})
+	s := NewDepositService()
 	results, err := s.ListDeposits()
 	if err != nil {
 		response.HandleError(c, err, "Failed to fetch deposits", http.StatusInternalServerError)
@@ -96,13 +191,21 @@ func (h *DepositHandler) ListDeposits(c *gin.Context) {
 // Get deposit by ID
 func (h *DepositHandler) GetDepositByID(c *gin.Context) {
 	id := c.Param("id")
-	s := deposit.DepositServiceInterface(&deposit.DepositService{})
+	s := NewDepositService()
 	result, err := s.GetDepositByID(id)
+	if err == nil && result == nil {
+		err = midleware.ErrResourceNotFound
+	}
 	if err != nil {
 		response.HandleError(c, err, "Deposit not found", http.StatusNotFound)
 		return
 	}
 
+	// Owners get the request without internal admin notes
+	if c.GetString("role") != midleware.RoleAdmin {
+		result.AdminNotes = nil
+	}
+
 	response.SuccessResponse(c, "Deposit fetched successfully", result, http.StatusOK)
 }
 
@@ -110,7 +213,7 @@ func (h *DepositHandler) GetDepositByID(c *gin.Context) {
 func (h *DepositHandler) SearchDepositsByUsername(c *gin.Context) {
 	username := c.Query("username")
 
-	s := deposit.DepositServiceInterface(&deposit.DepositService{})
+	s := NewDepositService()
 	results, err := s.SearchDepositsByUsername(username)
 	if err != nil {
 		response.HandleError(c, err, "Failed to search deposits", http.StatusInternalServerError)
@@ -119,3 +222,72 @@ func (h *DepositHandler) SearchDepositsByUsername(c *gin.Context) {
 
 	response.SuccessResponse(c, "Deposits fetched successfully", results, http.StatusOK)
 }
+
+// VerifyDeposit re-runs on-chain verification of the deposit's transaction hash
+func (h *DepositHandler) VerifyDeposit(c *gin.Context) {
+	s := NewDepositService()
+	result, err := s.VerifyDeposit(c.Param("id"))
+	if err != nil {
+		status := http.StatusBadGateway
+		switch {
+		case errors.Is(err, deposit.ErrDepositNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, deposit.ErrVerificationDisabled):
+			status = http.StatusServiceUnavailable
+		}
+		response.HandleError(c, err, "Failed to verify deposit", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Deposit verified", result, http.StatusOK)
+}
+
+// DepositOwner resolves the owner of the deposit in the :id path param for OwnerOrAdmin
+func (h *DepositHandler) DepositOwner(c *gin.Context) (string, error) {
+	s := NewDepositService()
+	dep, err := s.GetDepositByID(c.Param("id"))
+	if err != nil {
+		return "", err
+	}
+	if dep == nil {
+		return "", midleware.ErrResourceNotFound
+	}
+	return dep.UserId.Hex(), nil
+}
+
+// Append an internal note, visible to admins only
+func (h *DepositHandler) AddAdminNote(c *gin.Context) {
+	var req models.AdminNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := NewDepositService()
+	if err := s.AddAdminNote(c.Param("id"), actor, req.Note); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, deposit.ErrDepositNotFound):
+			status = http.StatusNotFound
+		case adminService.IsReviewInputError(err):
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to add note", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Note added successfully", nil, http.StatusCreated)
+}
+
+// isTOTPError reports whether an approval was refused for a missing or bad TOTP code
+func isTOTPError(err error) bool {
+	return errors.Is(err, twofactor.ErrCodeRequired) ||
+		errors.Is(err, twofactor.ErrInvalidCode) ||
+		errors.Is(err, twofactor.ErrEnrollmentRequired)
+}
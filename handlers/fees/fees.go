@@ -0,0 +1,75 @@
+package fees
+
+import (
+	"errors"
+	"net/http"
+	"p2p/models"
+	"p2p/services/fees"
+	"p2p/utils/money"
+	"p2p/utils/response"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FeeHandler struct{}
+
+// Price a withdrawal before it is submitted; ?amount= in USDT
+func (h *FeeHandler) QuoteWithdrawl(c *gin.Context) {
+	amount, err := money.Parse(c.Query("amount"))
+	if err != nil {
+		response.HandleError(c, err, "amount must be a decimal number", http.StatusBadRequest)
+		return
+	}
+	oid, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := fees.FeeServiceInterface(&fees.FeeService{})
+	quote, err := s.Quote(oid, amount)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, fees.ErrInvalidAmount) {
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to quote withdrawl fee", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Fee quoted successfully", quote, http.StatusOK)
+}
+
+// Get the withdrawal fee schedules
+func (h *FeeHandler) GetFeesConfig(c *gin.Context) {
+	s := fees.FeeServiceInterface(&fees.FeeService{})
+	cnf, err := s.Config()
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch withdrawl fees", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Withdrawl fees fetched successfully", cnf, http.StatusOK)
+}
+
+// Replace the withdrawal fee schedules
+func (h *FeeHandler) UpsertFeesConfig(c *gin.Context) {
+	var req models.FeesConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := fees.FeeServiceInterface(&fees.FeeService{})
+	if err := s.UpsertConfig(req); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, fees.ErrInvalidSchedule) || errors.Is(err, fees.ErrDuplicateTier) {
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to update withdrawl fees", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Withdrawl fees updated successfully", nil, http.StatusOK)
+}
@@ -0,0 +1,108 @@
+package ledger
+
+import (
+	"errors"
+	"net/http"
+	"p2p/models"
+	"p2p/services/ledger"
+	"p2p/utils/response"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LedgerHandler struct{}
+
+// GetMyLedger lists the caller's ledger entries with running balance
+func (h *LedgerHandler) GetMyLedger(c *gin.Context) {
+	oid, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	h.writeLedger(c, oid)
+}
+
+// GetUserLedger lists any user's ledger entries for admins
+func (h *LedgerHandler) GetUserLedger(c *gin.Context) {
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.HandleError(c, err, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	h.writeLedger(c, oid)
+}
+
+func (h *LedgerHandler) writeLedger(c *gin.Context, userID primitive.ObjectID) {
+	s := ledger.LedgerServiceInterface(&ledger.LedgerService{})
+	res, err := s.GetUserLedger(userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ledger.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		response.HandleError(c, err, "Failed to fetch ledger", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Ledger fetched successfully", res, http.StatusOK)
+}
+
+// AdjustBalance books a manual credit or debit for a user
+func (h *LedgerHandler) AdjustBalance(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.HandleError(c, err, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.BalanceAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	adminID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	s := ledger.LedgerServiceInterface(&ledger.LedgerService{})
+	if err := s.AdjustBalance(userID, req, adminID); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ledger.ErrInvalidAdjustment), errors.Is(err, ledger.ErrInsufficientBalance):
+			status = http.StatusBadRequest
+		case errors.Is(err, ledger.ErrUserNotFound):
+			status = http.StatusNotFound
+		}
+		response.HandleError(c, err, "Failed to adjust balance", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Balance adjusted successfully", gin.H{"user_id": userID.Hex()}, http.StatusOK)
+}
+
+// RebuildBalance recomputes the cached balance from the ledger
+func (h *LedgerHandler) RebuildBalance(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.HandleError(c, err, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	s := ledger.LedgerServiceInterface(&ledger.LedgerService{})
+	balance, err := s.RebuildUserBalance(userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ledger.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		response.HandleError(c, err, "Failed to rebuild balance", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Balance rebuilt successfully", gin.H{
+		"user_id": userID.Hex(),
+		"balance": balance,
+	}, http.StatusOK)
+}
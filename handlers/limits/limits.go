@@ -0,0 +1,94 @@
+package limits
+
+import (
+	"errors"
+	"net/http"
+	"p2p/models"
+	"p2p/services/limits"
+	"p2p/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LimitsHandler struct{}
+
+// Show the caller's limits and what is left of them
+func (h *LimitsHandler) GetMyLimits(c *gin.Context) {
+	s := limits.LimitsServiceInterface(&limits.LimitsService{})
+	res, err := s.Summary(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch limits", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Limits fetched successfully", res, http.StatusOK)
+}
+
+// Show a user's limits for admins
+func (h *LimitsHandler) GetUserLimits(c *gin.Context) {
+	s := limits.LimitsServiceInterface(&limits.LimitsService{})
+	res, err := s.Summary(c.Param("id"))
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch limits", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Limits fetched successfully", res, http.StatusOK)
+}
+
+// Put a user on a tier and/or override single rules
+func (h *LimitsHandler) SetUserLimits(c *gin.Context) {
+	var req models.UserLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := limits.LimitsServiceInterface(&limits.LimitsService{})
+	if err := s.SetUserLimits(c.Param("id"), req); err != nil {
+		response.HandleError(c, err, "Failed to update user limits", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "User limits updated successfully", nil, http.StatusOK)
+}
+
+// Get the global limits and tiers
+func (h *LimitsHandler) GetLimitsConfig(c *gin.Context) {
+	s := limits.LimitsServiceInterface(&limits.LimitsService{})
+	cnf, err := s.Config()
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch limits", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Limits fetched successfully", cnf, http.StatusOK)
+}
+
+// Replace the global limits and tiers
+func (h *LimitsHandler) UpsertLimitsConfig(c *gin.Context) {
+	var req models.LimitsConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := limits.LimitsServiceInterface(&limits.LimitsService{})
+	if err := s.UpsertConfig(req); err != nil {
+		response.HandleError(c, err, "Failed to update limits", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Limits updated successfully", nil, http.StatusOK)
+}
+
+// statusFor maps a limits error to the HTTP status to answer with
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, limits.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, limits.ErrInvalidLimits), errors.Is(err, limits.ErrUnknownTier):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
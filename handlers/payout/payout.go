@@ -0,0 +1,213 @@
+package payout
+
+import (
+	"errors"
+	"net/http"
+	"p2p/models"
+	"p2p/services/payout"
+	"p2p/services/twofactor"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/response"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayoutHandler struct{}
+
+// maxResponseFileSize bounds uploaded bank response files
+const maxResponseFileSize = 5 << 20
+
+// Select withdrawls into a new payout batch and move them to Processing
+func (h *PayoutHandler) CreateBatch(c *gin.Context) {
+	var req models.CreatePayoutBatchRequest
+	// Body is optional; without ids the oldest pending withdrawls are taken
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := payout.PayoutServiceInterface(&payout.PayoutService{})
+	res, err := s.CreateBatch(req, actor)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, payout.ErrUnknownTemplate), errors.Is(err, payout.ErrBatchTooLarge):
+			status = http.StatusBadRequest
+		case errors.Is(err, payout.ErrNothingToBatch):
+			status = http.StatusConflict
+		case isTOTPError(err):
+			status = http.StatusForbidden
+		}
+		response.HandleError(c, err, "Failed to create payout batch", status)
+		return
+	}
+
+	for i := range res.Skipped {
+		item := &res.Skipped[i]
+		item.Error = item.Err.Error()
+		item.StatusCode = skipStatusCode(item.Err)
+	}
+
+	response.SuccessResponse(c, "Payout batch created successfully", res, http.StatusCreated)
+}
+
+// List payout batches, newest first
+func (h *PayoutHandler) ListBatches(c *gin.Context) {
+	s := payout.PayoutServiceInterface(&payout.PayoutService{})
+	results, err := s.ListBatches()
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch payout batches", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Payout batches fetched successfully", results, http.StatusOK)
+}
+
+// Get payout batch by ID
+func (h *PayoutHandler) GetBatch(c *gin.Context) {
+	s := payout.PayoutServiceInterface(&payout.PayoutService{})
+	result, err := s.GetBatch(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, payout.ErrBatchNotFound) {
+			status = http.StatusNotFound
+		}
+		response.HandleError(c, err, "Payout batch not found", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Payout batch fetched successfully", result, http.StatusOK)
+}
+
+// Download the bank bulk-transfer file; ?template= overrides the batch's template
+func (h *PayoutHandler) ExportBatch(c *gin.Context) {
+	s := payout.PayoutServiceInterface(&payout.PayoutService{})
+	data, name, err := s.Export(c.Param("id"), c.Query("template"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, payout.ErrBatchNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, payout.ErrUnknownTemplate):
+			status = http.StatusBadRequest
+		case errors.Is(err, payout.ErrBatchNotReady):
+			status = http.StatusConflict
+		}
+		response.HandleError(c, err, "Failed to export payout batch", status)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// Upload the bank's response file to settle the batch's withdrawls.
+// Form fields: file, and optionally reason_code used for failed transfers.
+func (h *PayoutHandler) ImportBatch(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.HandleError(c, err, "File is required", http.StatusBadRequest)
+		return
+	}
+	if fileHeader.Size > maxResponseFileSize {
+		response.HandleError(c, nil, "File is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.HandleError(c, err, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	s := payout.PayoutServiceInterface(&payout.PayoutService{})
+	res, err := s.Import(c.Param("id"), filepath.Base(fileHeader.Filename), file, c.PostForm("reason_code"), actor)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, payout.ErrBatchNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, payout.ErrInvalidFile), errors.Is(err, payout.ErrUnknownTemplate):
+			status = http.StatusBadRequest
+		case errors.Is(err, payout.ErrBatchNotReady), errors.Is(err, payout.ErrBatchClosed):
+			status = http.StatusConflict
+		}
+		response.HandleError(c, err, "Failed to import payout response", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Payout response imported", res, http.StatusOK)
+}
+
+// List bank templates used for payout files
+func (h *PayoutHandler) GetTemplates(c *gin.Context) {
+	s := payout.PayoutServiceInterface(&payout.PayoutService{})
+	templates, err := s.Templates()
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch payout templates", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Payout templates fetched successfully", templates, http.StatusOK)
+}
+
+// Replace the bank templates used for payout files
+func (h *PayoutHandler) UpsertTemplates(c *gin.Context) {
+	var req struct {
+		Templates []models.PayoutTemplate `json:"templates" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := payout.PayoutServiceInterface(&payout.PayoutService{})
+	if err := s.UpsertTemplates(req.Templates); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, payout.ErrInvalidTemplates) {
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to update payout templates", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Payout templates updated successfully", nil, http.StatusOK)
+}
+
+// skipStatusCode maps why a withdrawl could not join a batch to an HTTP status
+func skipStatusCode(err error) int {
+	switch {
+	case errors.Is(err, payout.ErrWithdrawlNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, payout.ErrAlreadyInBatch), errors.Is(err, payout.ErrNotBatchable),
+		errors.Is(err, payout.ErrWithdrawlNotPending):
+		return http.StatusConflict
+	case errors.Is(err, payout.ErrFirstApprovalRequired), errors.Is(err, payout.ErrSecondApprovalRequired),
+		errors.Is(err, payout.ErrSameApprover):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// isTOTPError reports whether an approval was refused for a missing or bad TOTP code
+func isTOTPError(err error) bool {
+	return errors.Is(err, twofactor.ErrCodeRequired) ||
+		errors.Is(err, twofactor.ErrInvalidCode) ||
+		errors.Is(err, twofactor.ErrEnrollmentRequired)
+}
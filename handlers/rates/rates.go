@@ -0,0 +1,39 @@
+package rates
+
+import (
+	"errors"
+	"net/http"
+	"p2p/models"
+	"p2p/services/rates"
+	"p2p/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateHandler struct{}
+
+// Quote the current INR rate; send the quote ID as rate_quote_id when
+// creating the deposit or withdrawl to lock it
+func (h *RateHandler) CreateQuote(c *gin.Context) {
+	var req models.RateQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := rates.RateServiceInterface(&rates.RateService{})
+	quote, err := s.Quote(c.GetString("userID"), req.Kind)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, rates.ErrUnknownKind):
+			status = http.StatusBadRequest
+		case errors.Is(err, rates.ErrRateUnavailable):
+			status = http.StatusServiceUnavailable
+		}
+		response.HandleError(c, err, "Failed to quote rate", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Rate quoted successfully", quote, http.StatusCreated)
+}
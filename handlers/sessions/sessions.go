@@ -0,0 +1,94 @@
+package sessions
+
+import (
+	"errors"
+	"net/http"
+	"p2p/config"
+	"p2p/models"
+	"p2p/services/sessions"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/response"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionHandler struct{}
+
+const refreshCookie = "refresh_token"
+
+// SetAuthCookies writes the access and refresh tokens the same way login does
+func SetAuthCookies(c *gin.Context, tokens *models.AuthTokens) {
+	c.Header("Authorization", "Bearer "+tokens.AccessToken)
+	setAuthCookie(c, "token", tokens.AccessToken, int(midleware.AccessTokenTTL.Seconds()))
+	setAuthCookie(c, refreshCookie, tokens.RefreshToken, int(midleware.RefreshTokenTTL.Seconds()))
+}
+
+func clearAuthCookies(c *gin.Context) {
+	setAuthCookie(c, "token", "", -1)
+	setAuthCookie(c, refreshCookie, "", -1)
+}
+
+// setAuthCookie keeps tokens HTTPS-only and off cross-site requests; DevMode
+// lets them over plain HTTP for local runs
+func setAuthCookie(c *gin.Context, name, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(name, value, maxAge, "/", "", !config.Cfg.DevMode, true)
+}
+
+func (h *SessionHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	// Body is optional, browsers send the cookie instead
+	_ = c.ShouldBindJSON(&req)
+	if req.RefreshToken == "" {
+		if cookie, err := c.Cookie(refreshCookie); err == nil {
+			req.RefreshToken = cookie
+		}
+	}
+
+	s := sessions.SessionServiceInterface(&sessions.SessionService{})
+	tokens, err := s.Refresh(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, sessions.ErrInvalidRefreshToken), errors.Is(err, sessions.ErrRefreshTokenReused):
+			status = http.StatusUnauthorized
+		case errors.Is(err, sessions.ErrUserBlocked):
+			status = http.StatusForbidden
+		}
+		response.HandleError(c, err, "Failed to refresh token", status)
+		return
+	}
+
+	SetAuthCookies(c, tokens)
+	response.SuccessResponse(c, "Token refreshed successfully", tokens, http.StatusOK)
+}
+
+func (h *SessionHandler) Logout(c *gin.Context) {
+	s := sessions.SessionServiceInterface(&sessions.SessionService{})
+	if err := s.Logout(c.GetString("sessionID")); err != nil {
+		response.HandleError(c, err, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	clearAuthCookies(c)
+	response.SuccessResponse(c, "Logged out successfully", nil, http.StatusOK)
+}
+
+func (h *SessionHandler) LogoutAll(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	s := sessions.SessionServiceInterface(&sessions.SessionService{})
+	revoked, err := s.LogoutAll(userID)
+	if err != nil {
+		response.HandleError(c, err, "Failed to logout from all devices", http.StatusInternalServerError)
+		return
+	}
+
+	clearAuthCookies(c)
+	response.SuccessResponse(c, "Logged out from all devices", gin.H{"revoked_sessions": revoked}, http.StatusOK)
+}
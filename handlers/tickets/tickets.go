@@ -0,0 +1,205 @@
+package tickets
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2p/models"
+	"p2p/services/tickets"
+	"p2p/utils"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/response"
+	"p2p/utils/statemachine"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TicketHandler struct{}
+
+// Open a ticket about one of the caller's deposits or withdrawls; form-data
+// with ref_kind, ref_id, subject, message, optional priority and files
+func (h *TicketHandler) OpenTicket(c *gin.Context) {
+	var req models.TicketRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+	files, ok := uploadAttachments(c)
+	if !ok {
+		return
+	}
+
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	result, err := s.Open(actor, req, files)
+	if err != nil {
+		response.HandleError(c, err, "Failed to open ticket", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Ticket opened successfully", result, http.StatusCreated)
+}
+
+// List the caller's tickets
+func (h *TicketHandler) GetMyTickets(c *gin.Context) {
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	results, err := s.ListForUser(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch tickets", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Tickets fetched successfully", results, http.StatusOK)
+}
+
+// Admin queue with SLA timers; ?status=, ?priority= and ?assignee=me|none|<id> filter
+func (h *TicketHandler) GetTicketQueue(c *gin.Context) {
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	results, err := s.Queue(c.Query("status"), c.Query("priority"), c.Query("assignee"), actor)
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch ticket queue", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Ticket queue fetched successfully", results, http.StatusOK)
+}
+
+// Get a ticket with its messages
+func (h *TicketHandler) GetTicket(c *gin.Context) {
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	result, err := s.Get(c.Param("id"))
+	if err != nil {
+		response.HandleError(c, err, "Ticket not found", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Ticket fetched successfully", result, http.StatusOK)
+}
+
+// Reply on a ticket; form-data with message and optional files
+func (h *TicketHandler) ReplyTicket(c *gin.Context) {
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+	files, ok := uploadAttachments(c)
+	if !ok {
+		return
+	}
+
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	result, err := s.Reply(c.Param("id"), actor, c.PostForm("message"), files)
+	if err != nil {
+		response.HandleError(c, err, "Failed to add reply", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Reply added successfully", result, http.StatusCreated)
+}
+
+// Change a ticket's status, priority or assignee
+func (h *TicketHandler) UpdateTicket(c *gin.Context) {
+	var req models.TicketUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	result, err := s.Update(c.Param("id"), actor, req)
+	if err != nil {
+		response.HandleError(c, err, "Failed to update ticket", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Ticket updated successfully", result, http.StatusOK)
+}
+
+// Close one of the caller's tickets
+func (h *TicketHandler) CloseTicket(c *gin.Context) {
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	result, err := s.Close(c.Param("id"), actor)
+	if err != nil {
+		response.HandleError(c, err, "Failed to close ticket", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Ticket closed successfully", result, http.StatusOK)
+}
+
+// TicketOwner resolves the owner of the ticket in the :id path param for OwnerOrAdmin
+func (h *TicketHandler) TicketOwner(c *gin.Context) (string, error) {
+	s := tickets.TicketServiceInterface(&tickets.TicketService{})
+	t, err := s.Get(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, tickets.ErrTicketNotFound) {
+			return "", midleware.ErrResourceNotFound
+		}
+		return "", err
+	}
+	return t.Ticket.UserId.Hex(), nil
+}
+
+// uploadAttachments uploads the "files" of a form-data request. It answers
+// the request itself and returns false when that fails.
+func uploadAttachments(c *gin.Context) ([]string, bool) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		if errors.Is(err, http.ErrNotMultipart) {
+			return nil, true
+		}
+		response.HandleError(c, err, "Invalid form data", http.StatusBadRequest)
+		return nil, false
+	}
+
+	files := form.File["files"]
+	if len(files) > tickets.MaxAttachments {
+		err := fmt.Errorf("at most %d files can be attached", tickets.MaxAttachments)
+		response.HandleError(c, err, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	urls, err := utils.UploadFormFilesToCloudinary(c, files)
+	if err != nil {
+		response.HandleError(c, err, "Cloudinary upload failed", http.StatusInternalServerError)
+		return nil, false
+	}
+	return urls, true
+}
+
+// statusFor maps a ticket error to the HTTP status to answer with
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, tickets.ErrTicketNotFound), errors.Is(err, tickets.ErrRefNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tickets.ErrTicketExists),
+		errors.Is(err, tickets.ErrTicketChanged),
+		errors.Is(err, tickets.ErrTicketClosed),
+		errors.Is(err, tickets.ErrIllegalTransition):
+		return http.StatusConflict
+	case tickets.IsValidationError(err), errors.Is(err, statemachine.ErrUnknownStatus):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
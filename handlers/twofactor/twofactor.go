@@ -0,0 +1,134 @@
+package twofactor
+
+import (
+	"errors"
+	"net/http"
+	sessionHandler "p2p/handlers/sessions"
+	"p2p/models"
+	"p2p/services/sessions"
+	"p2p/services/twofactor"
+	"p2p/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct{}
+
+// statusFor maps two-factor errors to HTTP status codes
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode), errors.Is(err, twofactor.ErrInvalidPreAuth):
+		return http.StatusUnauthorized
+	case errors.Is(err, twofactor.ErrAlreadyEnabled), errors.Is(err, twofactor.ErrNotEnabled),
+		errors.Is(err, twofactor.ErrNoPendingEnrollment):
+		return http.StatusConflict
+	case errors.Is(err, twofactor.ErrUserBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, twofactor.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	s := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+	res, err := s.Enroll(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Failed to start two-factor enrollment", statusFor(err))
+		return
+	}
+	response.SuccessResponse(c, "Scan the QR code and confirm with a code", res, http.StatusOK)
+}
+
+func (h *TwoFactorHandler) ConfirmEnrollment(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+	codes, err := s.ConfirmEnrollment(c.GetString("userID"), req.Code)
+	if err != nil {
+		response.HandleError(c, err, "Failed to enable two-factor authentication", statusFor(err))
+		return
+	}
+	response.SuccessResponse(c, "Two-factor authentication enabled, store these recovery codes safely",
+		models.TOTPRecoveryCodesRes{RecoveryCodes: codes}, http.StatusOK)
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+	if err := s.Disable(c.GetString("userID"), req); err != nil {
+		response.HandleError(c, err, "Failed to disable two-factor authentication", statusFor(err))
+		return
+	}
+	response.SuccessResponse(c, "Two-factor authentication disabled", nil, http.StatusOK)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+	codes, err := s.RegenerateRecoveryCodes(c.GetString("userID"), req.Code)
+	if err != nil {
+		response.HandleError(c, err, "Failed to regenerate recovery codes", statusFor(err))
+		return
+	}
+	response.SuccessResponse(c, "Recovery codes regenerated", models.TOTPRecoveryCodesRes{RecoveryCodes: codes}, http.StatusOK)
+}
+
+func (h *TwoFactorHandler) LoginUser(c *gin.Context) {
+	h.completeLogin(c, "user", "user", "User signed in successfully")
+}
+
+func (h *TwoFactorHandler) LoginAdmin(c *gin.Context) {
+	h.completeLogin(c, "admin", "admin", "Admin signed in successfully")
+}
+
+func (h *TwoFactorHandler) completeLogin(c *gin.Context, role, key, message string) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+	user, err := s.CompleteLogin(req, role)
+	if err != nil {
+		response.HandleError(c, err, "Failed to sign in", statusFor(err))
+		return
+	}
+
+	ss := sessions.SessionServiceInterface(&sessions.SessionService{})
+	tokens, err := ss.IssueTokens(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		response.HandleError(c, err, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	sessionHandler.SetAuthCookies(c, tokens)
+
+	response.SuccessResponse(c, message, gin.H{
+		key:             user,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn}, http.StatusOK)
+}
+
+// PreAuthResponse answers the password step for accounts with 2FA enabled
+func PreAuthResponse(c *gin.Context, token string) {
+	response.SuccessResponse(c, "Two-factor code required", gin.H{
+		"two_factor_required": true,
+		"pre_auth_token":      token}, http.StatusOK)
+}
@@ -1,8 +1,12 @@
 package users
 
 import (
+	"errors"
 	"net/http"
+	sessionHandler "p2p/handlers/sessions"
+	"p2p/handlers/twofactor"
 	"p2p/models"
+	"p2p/services/sessions"
 	"p2p/services/users"
 	midleware "p2p/utils/midleWare"
 	"p2p/utils/response"
@@ -13,14 +17,19 @@ import (
 
 type UserHandler struct{}
 
+// NewUserService builds the service the handlers call; route tests swap it
+var NewUserService = func() users.UserServiceInterface {
+	return &users.UserService{}
+}
+
 func (h *UserHandler) RegisterUser(c *gin.Context) {
-	var req models.User
+	var req models.RegisterRequest
 
 	if err := c.BindJSON(&req); err != nil {
 		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
 		return
 	}
-	s := users.UserServiceInterface(&users.UserService{})
+	s := NewUserService()
 	userId, err := s.RegisterUser(req)
 	if err != nil {
 		response.HandleError(c, err, "Failed to register user", http.StatusInternalServerError)
@@ -37,26 +46,38 @@ func (h *UserHandler) SignInUser(c *gin.Context) {
 		return
 	}
 
-	s := users.UserServiceInterface(&users.UserService{})
+	s := NewUserService()
 	user, err := s.SignInUser(req)
 	if err != nil {
 		response.HandleError(c, err, "Failed to sign in", http.StatusForbidden)
 		return
 	}
 
-	token, err := midleware.GenerateJWT(user.Email, user.ID.Hex(), user.Role)
+	// Accounts with 2FA get a pre-auth token and finish at /login/2fa
+	if user.TOTPEnabled {
+		preAuth, err := midleware.GeneratePreAuthToken(user.Email, user.ID.Hex(), user.Role)
+		if err != nil {
+			response.HandleError(c, err, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+		twofactor.PreAuthResponse(c, preAuth)
+		return
+	}
+
+	ss := sessions.SessionServiceInterface(&sessions.SessionService{})
+	tokens, err := ss.IssueTokens(user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		response.HandleError(c, err, "Failed to generate token", http.StatusInternalServerError)
 		return
 	}
 
-	c.Header("Authorization", "Bearer "+token)
-
-	c.SetCookie("token", token, 3600, "/", "", false, true) // 1 hour, HttpOnly
+	sessionHandler.SetAuthCookies(c, tokens)
 
 	response.SuccessResponse(c, "User signed in successfully", gin.H{
-		"user":  user,
-		"token": token}, http.StatusOK)
+		"user":          user,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn}, http.StatusOK)
 }
 
 func (h *UserHandler) BlockUser(c *gin.Context) {
@@ -78,7 +99,7 @@ func (h *UserHandler) BlockUser(c *gin.Context) {
 	}
 
 	// Call service
-	s := users.UserServiceInterface(&users.UserService{})
+	s := NewUserService()
 	err = s.BlockUser(userID, req.IsBlocked)
 	if err != nil {
 		response.HandleError(c, err, "Failed to update block status", http.StatusInternalServerError)
@@ -94,7 +115,7 @@ func (h *UserHandler) BlockUser(c *gin.Context) {
 }
 
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	s := users.UserServiceInterface(&users.UserService{})
+	s := NewUserService()
 	userList, err := s.GetAllUsers()
 	if err != nil {
 		response.HandleError(c, err, "Failed to fetch users", http.StatusInternalServerError)
@@ -104,17 +125,40 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	response.SuccessResponse(c, "Users fetched successfully", userList, http.StatusOK)
 }
 
-func (h *UserHandler) UpdatePassword(c *gin.Context) {
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
 
-	var req models.Login
+	if err := c.BindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	s := NewUserService()
+	if err := s.RequestPasswordReset(req.Email); err != nil {
+		response.HandleError(c, err, "Failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+
+	// Same answer whether or not the email exists
+	response.SuccessResponse(c, "If the email is registered, a reset code has been sent", nil, http.StatusOK)
+}
+
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
 
 	if err := c.BindJSON(&req); err != nil {
 		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
 		return
 	}
-	s := users.UserServiceInterface(&users.UserService{})
-	if err := s.UpdatePassword(req.Email, req.Password); err != nil {
-		response.HandleError(c, err, "Failed to update password", http.StatusInternalServerError)
+	s := NewUserService()
+	if err := s.ConfirmPasswordReset(req); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, users.ErrInvalidResetCode), errors.Is(err, users.ErrWeakPassword):
+			status = http.StatusBadRequest
+		case errors.Is(err, users.ErrTooManyAttempts):
+			status = http.StatusTooManyRequests
+		}
+		response.HandleError(c, err, "Failed to reset password", status)
 		return
 	}
 
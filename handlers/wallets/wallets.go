@@ -0,0 +1,89 @@
+package wallets
+
+import (
+	"errors"
+	"net/http"
+	"p2p/models"
+	"p2p/services/wallets"
+	"p2p/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WalletHandler struct{}
+
+// Register an address the caller sends deposits from
+func (h *WalletHandler) RegisterWallet(c *gin.Context) {
+	var req models.DepositWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := wallets.WalletServiceInterface(&wallets.WalletService{})
+	result, err := s.Register(c.GetString("userID"), req)
+	if err != nil {
+		response.HandleError(c, err, "Failed to register wallet", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Wallet registered successfully", result, http.StatusCreated)
+}
+
+// List the caller's wallets
+func (h *WalletHandler) GetMyWallets(c *gin.Context) {
+	s := wallets.WalletServiceInterface(&wallets.WalletService{})
+	results, err := s.ListForUser(c.GetString("userID"))
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch wallets", http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, "Wallets fetched successfully", results, http.StatusOK)
+}
+
+// Remove one of the caller's wallets
+func (h *WalletHandler) DeleteWallet(c *gin.Context) {
+	s := wallets.WalletServiceInterface(&wallets.WalletService{})
+	if err := s.Delete(c.Param("id"), c.GetString("userID")); err != nil {
+		response.HandleError(c, err, "Failed to delete wallet", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Wallet deleted successfully", nil, http.StatusOK)
+}
+
+// Prove ownership of one of the caller's wallets with a test transfer
+func (h *WalletHandler) VerifyWallet(c *gin.Context) {
+	var req models.WalletProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	s := wallets.WalletServiceInterface(&wallets.WalletService{})
+	result, err := s.VerifyOwnership(c.Param("id"), c.GetString("userID"), req)
+	if err != nil {
+		response.HandleError(c, err, "Failed to verify wallet", statusFor(err))
+		return
+	}
+
+	response.SuccessResponse(c, "Wallet verified successfully", result, http.StatusOK)
+}
+
+// statusFor maps a wallet error to the HTTP status to answer with
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, wallets.ErrWalletNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, wallets.ErrWalletTaken):
+		return http.StatusConflict
+	case errors.Is(err, wallets.ErrInvalidAddress), errors.Is(err, wallets.ErrInvalidLabel):
+		return http.StatusBadRequest
+	case errors.Is(err, wallets.ErrProofMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, wallets.ErrVerificationDisabled), errors.Is(err, wallets.ErrNoProofAddress):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
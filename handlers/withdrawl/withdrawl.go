@@ -1,11 +1,16 @@
 package withdrawl
 
 import (
+	"errors"
 	"net/http"
 	"p2p/models"
+	adminService "p2p/services/admin"
+	"p2p/services/limits"
+	"p2p/services/rates"
+	"p2p/services/twofactor"
 	"p2p/services/withdrawl"
+	midleware "p2p/utils/midleWare"
 	"p2p/utils/response"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -22,18 +27,36 @@ func (h *WithdrawlHandler) CreateWithdrawl(c *gin.Context) {
 		response.HandleError(c, nil, "User ID not found in context", http.StatusUnauthorized)
 		return
 	}
-	oid, _ := primitive.ObjectIDFromHex(userIDVal.(string))
-	req.UserId = oid
-	req.Status = "Pending"
 
 	if err := c.BindJSON(&req); err != nil {
 		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
 		return
 	}
 
+	// Owner and status come from the session, never from the body
+	oid, _ := primitive.ObjectIDFromHex(userIDVal.(string))
+	req.UserId = oid
+	req.Status = models.StatusPending
+
 	s := withdrawl.WithdrawlServiceInterface(&withdrawl.WithdrawlService{})
 	if err := s.CreateWithdrawl(req); err != nil {
-		response.HandleError(c, err, "Failed to create withdrawl request", http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, withdrawl.ErrInsufficientBalance), errors.Is(err, withdrawl.ErrInvalidAmount),
+			errors.Is(err, withdrawl.ErrBeneficiaryRequired):
+			status = http.StatusBadRequest
+		case errors.Is(err, withdrawl.ErrBeneficiaryNotFound):
+			status = http.StatusNotFound
+		case limits.IsLimitError(err):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, rates.ErrQuoteNotFound):
+			status = http.StatusBadRequest
+		case rates.IsQuoteError(err):
+			status = http.StatusConflict
+		case errors.Is(err, rates.ErrRateUnavailable):
+			status = http.StatusServiceUnavailable
+		}
+		response.HandleError(c, err, "Failed to create withdrawl request", status)
 		return
 	}
 
@@ -47,15 +70,130 @@ func (h *WithdrawlHandler) UpdateWithdrawlStatus(c *gin.Context) {
 		return
 	}
 
-	approve := strings.EqualFold(req.Status, "Approved")
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := withdrawl.WithdrawlServiceInterface(&withdrawl.WithdrawlService{})
+	newStatus, err := s.UpdateWithdrawStatus(req, actor)
+	if err != nil {
+		response.HandleError(c, err, "Failed to update withdrawl status", updateStatusCode(err))
+		return
+	}
+
+	if newStatus == models.WithdrawlAwaitingSecondApproval {
+		response.SuccessResponse(c, "Withdrawl approved, awaiting second approval", gin.H{"status": newStatus}, http.StatusAccepted)
+		return
+	}
+	response.SuccessResponse(c, "Withdrawl status updated successfully", gin.H{"status": newStatus}, http.StatusOK)
+}
+
+// Apply one status to many withdrawls, each with its own UTR, reporting the outcome per item
+func (h *WithdrawlHandler) BulkUpdateWithdrawlStatus(c *gin.Context) {
+	var req models.BulkStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := withdrawl.WithdrawlServiceInterface(&withdrawl.WithdrawlService{})
+	res, err := s.BulkUpdateWithdrawStatus(req, actor)
+	if err != nil {
+		status := updateStatusCode(err)
+		if errors.Is(err, withdrawl.ErrBulkEmpty) || errors.Is(err, withdrawl.ErrBulkTooLarge) {
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to update withdrawl status", status)
+		return
+	}
+
+	for i := range res.Results {
+		item := &res.Results[i]
+		switch {
+		case item.Err == nil && item.Status == models.WithdrawlAwaitingSecondApproval:
+			item.StatusCode = http.StatusAccepted
+		case item.Err == nil:
+			item.StatusCode = http.StatusOK
+		case errors.Is(item.Err, withdrawl.ErrDuplicateItem):
+			item.Error = item.Err.Error()
+			item.StatusCode = http.StatusBadRequest
+		default:
+			item.Error = item.Err.Error()
+			item.StatusCode = updateStatusCode(item.Err)
+		}
+	}
+
+	response.SuccessResponse(c, "Bulk withdrawl status update processed", res, http.StatusOK)
+}
+
+// updateStatusCode maps a status change failure to the HTTP status to answer with
+func updateStatusCode(err error) int {
+	switch {
+	case errors.Is(err, withdrawl.ErrWithdrawlNotPending), errors.Is(err, withdrawl.ErrDuplicateUTR),
+		errors.Is(err, withdrawl.ErrAlreadyInBatch):
+		return http.StatusConflict
+	case errors.Is(err, withdrawl.ErrWithdrawlNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, withdrawl.ErrUnknownStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, withdrawl.ErrIllegalTransition):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, withdrawl.ErrSameApprover), errors.Is(err, withdrawl.ErrFirstApprovalRequired), isTOTPError(err):
+		return http.StatusForbidden
+	case adminService.IsReviewInputError(err):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Cancel the caller's own pending withdrawl
+func (h *WithdrawlHandler) CancelWithdrawl(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	// Body is optional
+	_ = c.ShouldBindJSON(&req)
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := withdrawl.WithdrawlServiceInterface(&withdrawl.WithdrawlService{})
+	if err := s.CancelWithdrawl(c.Param("id"), actor, req.Reason); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, withdrawl.ErrWithdrawlNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, withdrawl.ErrNotCancellable):
+			status = http.StatusConflict
+		}
+		response.HandleError(c, err, "Failed to cancel withdrawl", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Withdrawl cancelled successfully", gin.H{"status": models.StatusCancelled}, http.StatusOK)
+}
 
+// List withdrawls waiting for a second admin
+func (h *WithdrawlHandler) ListAwaitingSecondApproval(c *gin.Context) {
 	s := withdrawl.WithdrawlServiceInterface(&withdrawl.WithdrawlService{})
-	if err := s.UpdateWithdrawStatus(req.ID, req.UTR, approve); err != nil {
-		response.HandleError(c, err, "Failed to update withdrawl status", http.StatusInternalServerError)
+	results, err := s.ListAwaitingSecondApproval()
+	if err != nil {
+		response.HandleError(c, err, "Failed to fetch withdrawls", http.StatusInternalServerError)
 		return
 	}
 
-	response.SuccessResponse(c, "Withdrawl status updated successfully", nil, http.StatusOK)
+	response.SuccessResponse(c, "Withdrawls fetched successfully", results, http.StatusOK)
 }
 
 // List withdrawls with pagination
@@ -81,6 +219,11 @@ func (h *WithdrawlHandler) GetWithdrawlByID(c *gin.Context) {
 		return
 	}
 
+	// Owners get the request without internal admin notes
+	if result != nil && c.GetString("role") != midleware.RoleAdmin {
+		result.AdminNotes = nil
+	}
+
 	response.SuccessResponse(c, "Withdrawl fetched successfully", result, http.StatusOK)
 }
 
@@ -114,5 +257,59 @@ func (h *WithdrawlHandler) GetUserWithdrawls(c *gin.Context) {
 		return
 	}
 
+	for i := range data {
+		data[i].AdminNotes = nil
+	}
+
 	response.SuccessResponse(c, "Withdrawls fetched successfully", data, http.StatusOK)
 }
+
+// WithdrawlOwner resolves the owner of the withdrawl in the :id path param for OwnerOrAdmin
+func (h *WithdrawlHandler) WithdrawlOwner(c *gin.Context) (string, error) {
+	s := withdrawl.WithdrawlServiceInterface(&withdrawl.WithdrawlService{})
+	wd, err := s.GetWithdrawlByID(c.Param("id"))
+	if err != nil {
+		return "", err
+	}
+	if wd == nil {
+		return "", midleware.ErrResourceNotFound
+	}
+	return wd.UserId.Hex(), nil
+}
+
+// Append an internal note, visible to admins only
+func (h *WithdrawlHandler) AddAdminNote(c *gin.Context) {
+	var req models.AdminNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.HandleError(c, err, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	actor, err := midleware.CurrentActor(c)
+	if err != nil {
+		response.HandleError(c, err, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	s := withdrawl.WithdrawlServiceInterface(&withdrawl.WithdrawlService{})
+	if err := s.AddAdminNote(c.Param("id"), actor, req.Note); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, withdrawl.ErrWithdrawlNotFound):
+			status = http.StatusNotFound
+		case adminService.IsReviewInputError(err):
+			status = http.StatusBadRequest
+		}
+		response.HandleError(c, err, "Failed to add note", status)
+		return
+	}
+
+	response.SuccessResponse(c, "Note added successfully", nil, http.StatusCreated)
+}
+
+// isTOTPError reports whether an approval was refused for a missing or bad TOTP code
+func isTOTPError(err error) bool {
+	return errors.Is(err, twofactor.ErrCodeRequired) ||
+		errors.Is(err, twofactor.ErrInvalidCode) ||
+		errors.Is(err, twofactor.ErrEnrollmentRequired)
+}
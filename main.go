@@ -4,8 +4,10 @@ import (
 	"log"
 	"p2p/config"
 	"p2p/config/db"
+	"p2p/repo/indexes"
 	"p2p/routes"
 	midleware "p2p/utils/midleWare"
+	"p2p/utils/notify"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +17,11 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
 	config.LoadConfig()
+	if err := notify.CheckConfig(); err != nil {
+		log.Fatalf("❌ Notifications: %v", err)
+	}
 	db.InitMongoClient(config.Cfg.DBConnectionString)
+	indexes.EnsureAll()
 
 	// gin.SetMode(gin.ReleaseMode)
 
@@ -1,27 +1,73 @@
 package models
 
+import (
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 type AdminConfigData struct {
-	SecureWalletAddress string `json:"secure_wallet_address" bson:"secure_wallet_address"`
-	USDTRate            string `json:"usdt_rate" bson:"usdt_rate"`
-	QRCodeURL           string `json:"qr_code_url" bson:"qr_code_url"`
+	SecureWalletAddress string       `json:"secure_wallet_address" bson:"secure_wallet_address"`
+	USDTRate            money.Amount `json:"usdt_rate" bson:"usdt_rate"`
+	QRCodeURL           string       `json:"qr_code_url" bson:"qr_code_url"`
+	// Approvals at or above this USDT amount need a fresh TOTP code; nil or zero disables
+	TOTPApprovalThreshold *money.Amount `json:"totp_approval_threshold,omitempty" bson:"totp_approval_threshold,omitempty"`
+	// Withdrawals at or above this USDT amount need two different admins; nil or zero disables
+	DualApprovalThreshold *money.Amount     `json:"dual_approval_threshold,omitempty" bson:"dual_approval_threshold,omitempty"`
+	RejectionReasons      []RejectionReason `json:"rejection_reasons,omitempty" bson:"rejection_reasons,omitempty"`
+	PayoutTemplates       []PayoutTemplate  `json:"payout_templates,omitempty" bson:"payout_templates,omitempty"`
+
+	// Global and tier deposit/withdrawal limits; nil means no limits
+	Limits *LimitsConfig `json:"limits,omitempty" bson:"limits,omitempty"`
+	// Withdrawal fee schedules; nil charges no fee
+	WithdrawalFees *FeesConfig `json:"withdrawal_fees,omitempty" bson:"withdrawal_fees,omitempty"`
 }
 
 type LedgerRes struct {
-	TotalDeposits            float64    `json:"total_deposits"`
-	TotalWithdrawals         float64    `json:"total_withdrawals"`
-	TotalPendingWithdrawals  int64      `json:"total_pending_withdrawals"`
-	CurrentTotalBalance      float64    `json:"current_total_balance"`
-	PendingWithdrawalsTotal  float64    `json:"pending_withdrawals_total"`
-	RejectedWithdrawalsTotal float64    `json:"rejected_withdrawals_total"`
-	TodayStats               TodayStats `json:"today_stats"`
+	TotalDeposits             money.Amount `json:"total_deposits"`
+	TotalWithdrawals          money.Amount `json:"total_withdrawals"`
+	TotalPendingWithdrawals   int64        `json:"total_pending_withdrawals"`
+	CurrentTotalBalance       money.Amount `json:"current_total_balance"`
+	PendingWithdrawalsTotal   money.Amount `json:"pending_withdrawals_total"`
+	RejectedWithdrawalsTotal  money.Amount `json:"rejected_withdrawals_total"`
+	TotalCancelledWithdrawals int64        `json:"total_cancelled_withdrawals"`
+	CancelledWithdrawalsTotal money.Amount `json:"cancelled_withdrawals_total"`
+	TodayStats                TodayStats   `json:"today_stats"`
+
+	// Withdrawal fees booked to platform:revenue, and fees still held on open withdrawals
+	TotalFeesCollected money.Amount `json:"total_fees_collected"`
+	PendingFeesHeld    money.Amount `json:"pending_fees_held"`
+
+	// INR values at the rates locked when each request was created
+	TotalDepositsINR      money.Amount `json:"total_deposits_inr"`
+	TotalWithdrawalsINR   money.Amount `json:"total_withdrawals_inr"`
+	PendingWithdrawalsINR money.Amount `json:"pending_withdrawals_inr"`
 }
 
 type TodayStats struct {
-	TotalWithdrawals         float64 `json:"total_withdrawals"`
-	TotalWithdrawalsPending  float64 `json:"total_withdrawals_pending"`
-	TotalWithdrawalsApproved float64 `json:"total_withdrawals_approved"`
-	TotalDeposits            float64 `json:"total_deposits"`
-	TotalDepositsPending     float64 `json:"total_deposits_pending"`
-	TotalDepositsApproved    float64 `json:"total_deposits_approved"`
-	NewUsers                 int64   `json:"new_users"`
-}
\ No newline at end of file
+	TotalWithdrawals          money.Amount `json:"total_withdrawals"`
+	TotalWithdrawalsPending   money.Amount `json:"total_withdrawals_pending"`
+	TotalWithdrawalsApproved  money.Amount `json:"total_withdrawals_approved"`
+	TotalWithdrawalsCancelled money.Amount `json:"total_withdrawals_cancelled"`
+	TotalDeposits             money.Amount `json:"total_deposits"`
+	TotalDepositsPending      money.Amount `json:"total_deposits_pending"`
+	TotalDepositsApproved     money.Amount `json:"total_deposits_approved"`
+	NewUsers                  int64        `json:"new_users"`
+	FeesCollected             money.Amount `json:"fees_collected"`
+}
+
+// Collision is one value shared by more than one document
+type Collision struct {
+	Value     string               `bson:"_id" json:"value"`
+	Count     int64                `bson:"count" json:"count"`
+	IDs       []primitive.ObjectID `bson:"ids" json:"ids"`
+	UserIDs   []primitive.ObjectID `bson:"user_ids" json:"user_ids"`
+	FirstSeen time.Time            `bson:"first_seen" json:"first_seen"`
+	LastSeen  time.Time            `bson:"last_seen" json:"last_seen"`
+}
+
+type CollisionReport struct {
+	DepositTransactionHashes []Collision `json:"deposit_transaction_hashes"`
+	WithdrawalUTRs           []Collision `json:"withdrawal_utrs"`
+}
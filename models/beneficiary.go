@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Beneficiary is a bank account a user saved for withdrawals. Withdrawals
+// copy its details when created, so later edits never change past payouts.
+type Beneficiary struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserId        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Nickname      string             `bson:"nickname" json:"nickname"`
+	HolderName    string             `bson:"holder_name" json:"holder_name"`
+	AccountNumber string             `bson:"account_number" json:"account_number"`
+	IFSCCode      string             `bson:"ifsc_code" json:"ifsc_code"`
+	BankName      string             `bson:"bank_name" json:"bank_name"`
+	BranchName    string             `bson:"branch_name,omitempty" json:"branch_name,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// Optional admin check of the account; cleared whenever the bank details change
+	Verified   bool                `bson:"verified" json:"verified"`
+	VerifiedBy *primitive.ObjectID `bson:"verified_by,omitempty" json:"verified_by,omitempty"`
+	VerifiedAt *time.Time          `bson:"verified_at,omitempty" json:"verified_at,omitempty"`
+
+	// Deleted beneficiaries stay for the withdrawals that reference them
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"-"`
+}
+
+type BeneficiaryRequest struct {
+	Nickname      string `json:"nickname"`
+	HolderName    string `json:"holder_name" binding:"required"`
+	AccountNumber string `json:"account_number" binding:"required"`
+	IFSCCode      string `json:"ifsc_code" binding:"required"`
+	BankName      string `json:"bank_name"` // only used when no IFSC master file is loaded
+}
+
+type VerifyBeneficiaryRequest struct {
+	Verified bool `json:"verified"`
+}
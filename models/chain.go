@@ -0,0 +1,33 @@
+package models
+
+import (
+	"p2p/utils/money"
+	"time"
+)
+
+// Chain verification outcomes stored on a deposit
+const (
+	VerificationVerified      = "verified"
+	VerificationNotFound      = "not_found"
+	VerificationMismatch      = "mismatch"
+	VerificationUnconfirmed   = "unconfirmed"
+	VerificationFailedOnChain = "failed_on_chain"
+	VerificationError         = "error"
+)
+
+type ChainVerification struct {
+	Network       string       `bson:"network" json:"network"`
+	Status        string       `bson:"status" json:"status"`
+	FromAddress   string       `bson:"from_address,omitempty" json:"from_address,omitempty"`
+	ToAddress     string       `bson:"to_address,omitempty" json:"to_address,omitempty"`
+	Amount        money.Amount `bson:"amount" json:"amount"`
+	Confirmations int64        `bson:"confirmations" json:"confirmations"`
+	Reasons       []string     `bson:"reasons,omitempty" json:"reasons,omitempty"`
+	AutoApproved  bool         `bson:"auto_approved" json:"auto_approved"`
+	CheckedAt     time.Time    `bson:"checked_at" json:"checked_at"`
+
+	// Whether the sender is one of the depositor's verified wallets
+	SenderRegistered bool `bson:"sender_registered" json:"sender_registered"`
+	// Why a verified deposit was left for an admin instead of auto-approved
+	ManualReason string `bson:"manual_reason,omitempty" json:"manual_reason,omitempty"`
+}
@@ -14,6 +14,9 @@ type Chat struct {
 	Message   string             `bson:"message" json:"message"`
 	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
 	IsRead    bool               `bson:"is_read" json:"is_read"`
+
+	// The admin who answered for the support inbox
+	AgentID *primitive.ObjectID `bson:"agent_id,omitempty" json:"agent_id,omitempty"`
 }
 
 type Chatres struct {
@@ -24,15 +27,74 @@ type Chatres struct {
 	Message   string             `bson:"message" json:"message"`
 	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
 	IsRead    bool               `bson:"is_read" json:"is_read"`
+
+	AgentID *primitive.ObjectID `bson:"agent_id,omitempty" json:"agent_id,omitempty"`
+}
+
+// ChatPageQuery selects a page of a conversation. Before and After are
+// next_cursor values from an earlier page; at most one may be set.
+type ChatPageQuery struct {
+	Before string
+	After  string
+	Limit  int
+}
+
+// ChatPage is oldest first. NextCursor continues in the direction asked
+// for (older without a cursor or with before) and is empty at the end.
+type ChatPage struct {
+	Messages   []Chatres `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
-type ChatsRes struct {
-	Read   []Chatres `json:"read"`
-	Unread []Chatres `json:"unread"`
+// ChatReadRequest marks what PeerID sent the caller as read, up to UpTo
+// or everything so far when it is omitted
+type ChatReadRequest struct {
+	PeerID string     `json:"peer_id" binding:"required"`
+	UpTo   *time.Time `json:"up_to"`
 }
 
 type ChatUsers struct {
 	User        User    `json:"user"`
 	UnreadCount int     `json:"unread_count"`
 	LastMessage Chatres `json:"last_message"`
-}
\ No newline at end of file
+}
+
+// Realtime chat event types pushed over /chat/ws
+const (
+	ChatEventMessage  = "message"
+	ChatEventRead     = "read"
+	ChatEventPresence = "presence"
+	ChatEventTyping   = "typing"
+	ChatEventPong     = "pong"
+	ChatEventError    = "error"
+
+	// Sent once on connect: the presence of everyone the user chats with
+	ChatEventPresenceSnapshot = "presence_snapshot"
+
+	// Sent to admins who gain or lose a support conversation
+	ChatEventAssignment = "support_assignment"
+)
+
+// ChatReadEvent tells a sender their messages were read
+type ChatReadEvent struct {
+	ChatIDs  []primitive.ObjectID `json:"chat_ids"`
+	ReaderID primitive.ObjectID   `json:"reader_id"`
+}
+
+type ChatPresenceEvent struct {
+	UserID primitive.ObjectID `json:"user_id"`
+	Online bool               `json:"online"`
+}
+
+type ChatTypingEvent struct {
+	From   primitive.ObjectID `json:"from"`
+	Typing bool               `json:"typing"`
+}
+
+// ChatClientFrame is what a client may send over /chat/ws: "typing" with
+// To and Typing, or "ping"
+type ChatClientFrame struct {
+	Type   string `json:"type"`
+	To     string `json:"to"`
+	Typing bool   `json:"typing"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"p2p/utils/money"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -8,23 +9,37 @@ import (
 
 type DepositRequest struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Amount          float64            `bson:"amount" json:"amount"`
-	INRRate         float64            `bson:"inr_rate" json:"inr_rate"`
+	Amount          money.Amount       `bson:"amount" json:"amount"`
+	INRRate         money.Amount       `bson:"inr_rate" json:"inr_rate"`
+	INRAmount       money.Amount       `bson:"inr_amount" json:"inr_amount"`
+	RateQuoteID     primitive.ObjectID `bson:"rate_quote_id,omitempty" json:"rate_quote_id,omitempty"`
 	TransactionHash string             `bson:"transaction_hash" json:"transaction_hash"`
 	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
 	UserId          primitive.ObjectID `bson:"user_id" json:"user_id"`
 	Status          string             `bson:"status" json:"status"`
+	Verification    *ChainVerification `bson:"verification,omitempty" json:"verification,omitempty"`
+	StatusHistory   []StatusChange     `bson:"status_history,omitempty" json:"status_history,omitempty"`
+	Rejection       *Rejection         `bson:"rejection,omitempty" json:"rejection,omitempty"`
+	AdminNotes      []AdminNote        `bson:"admin_notes,omitempty" json:"admin_notes,omitempty"`
 }
 
 type DepositRes struct {
 	ID              primitive.ObjectID `bson:"_id" json:"id"`
-	Amount          float64            `bson:"amount" json:"amount"`
-	INRRate         float64            `bson:"inr_rate" json:"inr_rate"`
+	Amount          money.Amount       `bson:"amount" json:"amount"`
+	INRRate         money.Amount       `bson:"inr_rate" json:"inr_rate"`
+	INRAmount       money.Amount       `bson:"inr_amount" json:"inr_amount"`
+	RateQuoteID     primitive.ObjectID `bson:"rate_quote_id,omitempty" json:"rate_quote_id,omitempty"`
 	TransactionHash string             `bson:"transaction_hash" json:"transaction_hash"`
 	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
 	UserId          primitive.ObjectID `bson:"user_id" json:"user_id"`
 	Status          string             `bson:"status" json:"status"`
+	Verification    *ChainVerification `bson:"verification,omitempty" json:"verification,omitempty"`
+	StatusHistory   []StatusChange     `bson:"status_history,omitempty" json:"status_history,omitempty"`
+	Rejection       *Rejection         `bson:"rejection,omitempty" json:"rejection,omitempty"`
+	AdminNotes      []AdminNote        `bson:"admin_notes,omitempty" json:"admin_notes,omitempty"`
 	User            UserInfo           `bson:"user" json:"user"`
+	// Filled by the service from tickets that are still Open or Pending
+	OpenTickets []TicketSummary `bson:"-" json:"open_tickets,omitempty"`
 }
 
 type UserInfo struct {
@@ -34,6 +49,66 @@ type UserInfo struct {
 
 type UpdateStatusRequest struct {
 	ID     string `json:"id" binding:"required"`
-	Status string `json:"status" binding:"required"` // "Approved" or "Rejected"
+	Status string `json:"status" binding:"required"` // target status, e.g. "UnderReview", "Approved", "Rejected"
 	UTR    string `json:"utr"`
+	Reason string `json:"reason"`
+	// Required when the target is Rejected or Failed; "other" also needs a note
+	ReasonCode string `json:"reason_code"`
+	Note       string `json:"note"`
+	// Required when approving at or above the TOTP approval threshold
+	TOTPCode string `json:"totp_code"`
+}
+
+// BulkStatusItem is one request in a bulk status change; UTR is for withdrawals
+type BulkStatusItem struct {
+	ID  string `json:"id"`
+	UTR string `json:"utr"`
+}
+
+// BulkStatusRequest applies one target status to many requests. Items may
+// be given as plain ids or as items carrying a per-item UTR.
+type BulkStatusRequest struct {
+	Status     string           `json:"status" binding:"required"`
+	IDs        []string         `json:"ids"`
+	Items      []BulkStatusItem `json:"items"`
+	Reason     string           `json:"reason"`
+	ReasonCode string           `json:"reason_code"`
+	Note       string           `json:"note"`
+	TOTPCode   string           `json:"totp_code"`
+}
+
+// Targets merges IDs and Items in request order
+func (r BulkStatusRequest) Targets() []BulkStatusItem {
+	targets := make([]BulkStatusItem, 0, len(r.IDs)+len(r.Items))
+	for _, id := range r.IDs {
+		targets = append(targets, BulkStatusItem{ID: id})
+	}
+	return append(targets, r.Items...)
+}
+
+// ForItem builds the single-item request for one target
+func (r BulkStatusRequest) ForItem(item BulkStatusItem) UpdateStatusRequest {
+	return UpdateStatusRequest{
+		ID:         item.ID,
+		Status:     r.Status,
+		UTR:        item.UTR,
+		Reason:     r.Reason,
+		ReasonCode: r.ReasonCode,
+		Note:       r.Note,
+	}
+}
+
+type BulkItemResult struct {
+	ID         string `json:"id"`
+	Success    bool   `json:"success"`
+	Status     string `json:"status,omitempty"` // resulting status on success
+	Error      string `json:"error,omitempty"`
+	StatusCode int    `json:"status_code"` // what the single-item endpoint would have answered
+	Err        error  `json:"-"`
+}
+
+type BulkStatusRes struct {
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
 }
@@ -0,0 +1,52 @@
+package models
+
+import "p2p/utils/money"
+
+// Fee schedule types
+const (
+	FeeFlat    = "flat"
+	FeePercent = "percent"
+	FeeTiered  = "tiered"
+)
+
+// FeeBand is one amount band of a tiered schedule. UpTo is inclusive; the
+// last band leaves it empty to cover every larger amount.
+type FeeBand struct {
+	UpTo    *money.Amount `json:"up_to,omitempty" bson:"up_to,omitempty"`
+	Flat    money.Amount  `json:"flat" bson:"flat"`
+	Percent money.Amount  `json:"percent" bson:"percent"`
+}
+
+// FeeSchedule prices a withdrawal in USDT. Flat uses Flat, percent uses
+// Percent of the amount and tiered uses the first band the amount fits in.
+// Min and Max clamp the result when set.
+type FeeSchedule struct {
+	Type    string        `json:"type" bson:"type"`
+	Flat    money.Amount  `json:"flat" bson:"flat"`
+	Percent money.Amount  `json:"percent" bson:"percent"`
+	Bands   []FeeBand     `json:"bands,omitempty" bson:"bands,omitempty"`
+	Min     *money.Amount `json:"min,omitempty" bson:"min,omitempty"`
+	Max     *money.Amount `json:"max,omitempty" bson:"max,omitempty"`
+}
+
+// TierFeeSchedule replaces the default schedule for users on a limits tier
+type TierFeeSchedule struct {
+	Tier     string      `json:"tier" bson:"tier"`
+	Schedule FeeSchedule `json:"schedule" bson:"schedule"`
+}
+
+// FeesConfig is the withdrawal fee setup kept in the admin config; a nil
+// Default charges no fee
+type FeesConfig struct {
+	Default *FeeSchedule      `json:"default,omitempty" bson:"default,omitempty"`
+	Tiers   []TierFeeSchedule `json:"tiers,omitempty" bson:"tiers,omitempty"`
+}
+
+// FeeQuote is what a withdrawal of Amount would cost right now
+type FeeQuote struct {
+	Amount   money.Amount `json:"amount"`
+	Fee      money.Amount `json:"fee"`
+	Total    money.Amount `json:"total"` // debited from the balance
+	Tier     string       `json:"tier,omitempty"`
+	Schedule *FeeSchedule `json:"schedule,omitempty"`
+}
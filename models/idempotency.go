@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Idempotency record states
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+type IdempotencyRecord struct {
+	ID           string           `bson:"_id" json:"id"` // scope|user|key
+	Scope        string           `bson:"scope" json:"scope"`
+	UserID       string           `bson:"user_id" json:"user_id"`
+	Key          string           `bson:"key" json:"key"`
+	RequestHash  string           `bson:"request_hash" json:"request_hash"`
+	Status       string           `bson:"status" json:"status"`
+	ResponseCode int              `bson:"response_code,omitempty" json:"response_code,omitempty"`
+	Response     *GeneralResponse `bson:"response,omitempty" json:"response,omitempty"`
+	CreatedAt    time.Time        `bson:"created_at" json:"created_at"`
+	ExpiresAt    time.Time        `bson:"expires_at" json:"expires_at"`
+
+	// Whoever holds the in-progress key; once LeaseUntil passes a retry of the
+	// same request takes it over with a new LeaseID
+	LeaseID    string    `bson:"lease_id" json:"-"`
+	LeaseUntil time.Time `bson:"lease_until" json:"lease_until"`
+}
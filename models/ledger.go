@@ -0,0 +1,85 @@
+package models
+
+import (
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entry directions
+const (
+	LedgerDebit  = "debit"
+	LedgerCredit = "credit"
+)
+
+// Transaction kinds written to the ledger
+const (
+	LedgerKindDeposit          = "deposit"
+	LedgerKindWithdrawalHold   = "withdrawal_hold"
+	LedgerKindWithdrawalRefund = "withdrawal_refund"
+	LedgerKindWithdrawalPayout = "withdrawal_payout"
+	LedgerKindAdjustment       = "adjustment"
+	LedgerKindOpeningBalance   = "opening_balance"
+)
+
+// Platform-side ledger accounts. User accounts are "user:<hex id>".
+const (
+	AccountCustody         = "platform:custody"
+	AccountWithdrawalsHeld = "platform:withdrawals_held"
+	AccountAdjustments     = "platform:adjustments"
+	AccountRevenue         = "platform:revenue"
+)
+
+// UserAccount returns the ledger account name for a user
+func UserAccount(userID primitive.ObjectID) string {
+	return "user:" + userID.Hex()
+}
+
+type LedgerEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TxnID     primitive.ObjectID `bson:"txn_id" json:"txn_id"`
+	Account   string             `bson:"account" json:"account"`
+	UserId    primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Direction string             `bson:"direction" json:"direction"`
+	Amount    money.Amount       `bson:"amount" json:"amount"`
+	Kind      string             `bson:"kind" json:"kind"`
+	RefID     primitive.ObjectID `bson:"ref_id,omitempty" json:"ref_id,omitempty"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedBy primitive.ObjectID `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// LedgerLeg is one side of a ledger transaction before it is posted
+type LedgerLeg struct {
+	Account   string
+	UserId    primitive.ObjectID
+	Direction string
+	Amount    money.Amount
+}
+
+// LedgerTxn groups legs whose debits and credits must balance
+type LedgerTxn struct {
+	Kind      string
+	RefID     primitive.ObjectID
+	Note      string
+	CreatedBy primitive.ObjectID
+	Legs      []LedgerLeg
+}
+
+type UserLedgerEntry struct {
+	LedgerEntry    `bson:",inline"`
+	RunningBalance money.Amount `json:"running_balance"`
+}
+
+type UserLedgerRes struct {
+	UserId  primitive.ObjectID `json:"user_id"`
+	Balance money.Amount       `json:"balance"`
+	Entries []UserLedgerEntry  `json:"entries"`
+}
+
+type BalanceAdjustmentRequest struct {
+	Amount    money.Amount `json:"amount" binding:"required"`
+	Direction string       `json:"direction" binding:"required"` // "credit" or "debit"
+	Note      string       `json:"note" binding:"required"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"p2p/utils/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limit kinds
+const (
+	LimitDeposit   = "deposit"
+	LimitWithdrawl = "withdrawl"
+)
+
+// LimitRule bounds one kind of request. A nil field inherits from the level
+// below (user override → tier → global); zero means no limit. Amounts are USDT.
+type LimitRule struct {
+	MinPerRequest *money.Amount `json:"min_per_request,omitempty" bson:"min_per_request,omitempty"`
+	MaxPerRequest *money.Amount `json:"max_per_request,omitempty" bson:"max_per_request,omitempty"`
+	DailyCap      *money.Amount `json:"daily_cap,omitempty" bson:"daily_cap,omitempty"`
+	MonthlyCap    *money.Amount `json:"monthly_cap,omitempty" bson:"monthly_cap,omitempty"`
+	MaxPending    *int          `json:"max_pending,omitempty" bson:"max_pending,omitempty"`
+}
+
+// LimitSet holds the rules for both request kinds
+type LimitSet struct {
+	Deposit   LimitRule `json:"deposit" bson:"deposit"`
+	Withdrawl LimitRule `json:"withdrawl" bson:"withdrawl"`
+}
+
+// LimitTier is a named set of limits users can be put on
+type LimitTier struct {
+	Code   string   `json:"code" bson:"code"`
+	Name   string   `json:"name" bson:"name"`
+	Limits LimitSet `json:"limits" bson:"limits"`
+}
+
+// LimitsConfig is the global limits and the tiers, kept in the admin config
+type LimitsConfig struct {
+	Global LimitSet    `json:"global" bson:"global"`
+	Tiers  []LimitTier `json:"tiers,omitempty" bson:"tiers,omitempty"`
+}
+
+// UserLimitsRequest puts a user on a tier and/or overrides single rules;
+// empty values clear them
+type UserLimitsRequest struct {
+	Tier     string    `json:"tier"`
+	Override *LimitSet `json:"override"`
+}
+
+// LimitUsage is what a user has used of one rule set
+type LimitUsage struct {
+	Today     money.Amount `json:"today" bson:"today"`
+	ThisMonth money.Amount `json:"this_month" bson:"this_month"`
+	Pending   int          `json:"pending" bson:"pending"`
+}
+
+// LimitRemaining is the allowance left; nil means unlimited
+type LimitRemaining struct {
+	Today     *money.Amount `json:"today"`
+	ThisMonth *money.Amount `json:"this_month"`
+	Pending   *int          `json:"pending"`
+	// Largest single request that would pass right now
+	MaxRequest *money.Amount `json:"max_request"`
+}
+
+type LimitStatus struct {
+	Rule      LimitRule      `json:"rule"`
+	Used      LimitUsage     `json:"used"`
+	Remaining LimitRemaining `json:"remaining"`
+}
+
+type LimitsRes struct {
+	UserID    primitive.ObjectID `json:"user_id"`
+	Tier      string             `json:"tier,omitempty"`
+	Deposit   LimitStatus        `json:"deposit"`
+	Withdrawl LimitStatus        `json:"withdrawl"`
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Payout batch lifecycle: Building while withdrawals are being claimed,
+// Processing once the file can be exported, Completed when every item is final
+const (
+	PayoutBatchBuilding   = "Building"
+	PayoutBatchProcessing = "Processing"
+	PayoutBatchCompleted  = "Completed"
+)
+
+// ReasonBankPayoutFailed is the default rejection code for transfers the bank refused
+const ReasonBankPayoutFailed = "bank_payout_failed"
+
+// PayoutBatch groups withdrawals sent to the bank in one bulk-transfer file
+type PayoutBatch struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Template    string             `bson:"template" json:"template"`
+	Status      string             `bson:"status" json:"status"`
+	Items       []PayoutItem       `bson:"items" json:"items"`
+	Total       money.Amount       `bson:"total" json:"total"`
+	TotalINR    money.Amount       `bson:"total_inr" json:"total_inr"`
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	ExportedAt  *time.Time         `bson:"exported_at,omitempty" json:"exported_at,omitempty"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Imports     []PayoutImport     `bson:"imports,omitempty" json:"imports,omitempty"`
+}
+
+// PayoutItem is a snapshot of one withdrawal as it was sent to the bank
+type PayoutItem struct {
+	WithdrawlID   primitive.ObjectID `bson:"withdrawl_id" json:"withdrawl_id"`
+	Reference     string             `bson:"reference" json:"reference"`
+	Amount        money.Amount       `bson:"amount" json:"amount"`
+	INRAmount     money.Amount       `bson:"inr_amount" json:"inr_amount"`
+	BankName      string             `bson:"bank_name" json:"bank_name"`
+	HolderName    string             `bson:"holder_name" json:"holder_name"`
+	AccountNumber string             `bson:"account_number" json:"account_number"`
+	IFSCCode      string             `bson:"ifsc_code" json:"ifsc_code"`
+	Status        string             `bson:"status" json:"status"`
+	UTR           string             `bson:"utr,omitempty" json:"utr,omitempty"`
+	FailureReason string             `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	SettledAt     *time.Time         `bson:"settled_at,omitempty" json:"settled_at,omitempty"`
+}
+
+// PayoutImport records one bank response file applied to the batch
+type PayoutImport struct {
+	FileName   string             `bson:"file_name" json:"file_name"`
+	ImportedBy primitive.ObjectID `bson:"imported_by" json:"imported_by"`
+	ImportedAt time.Time          `bson:"imported_at" json:"imported_at"`
+	Approved   int                `bson:"approved" json:"approved"`
+	Failed     int                `bson:"failed" json:"failed"`
+	Pending    int                `bson:"pending" json:"pending"`
+	Unmatched  int                `bson:"unmatched" json:"unmatched"`
+	Errors     int                `bson:"errors" json:"errors"`
+}
+
+// PayoutTemplate describes one bank's bulk-transfer file and its response file
+type PayoutTemplate struct {
+	Code       string         `bson:"code" json:"code"`
+	Name       string         `bson:"name" json:"name"`
+	Delimiter  string         `bson:"delimiter,omitempty" json:"delimiter,omitempty"` // one character, "," when empty
+	NoHeader   bool           `bson:"no_header,omitempty" json:"no_header,omitempty"`
+	DateFormat string         `bson:"date_format,omitempty" json:"date_format,omitempty"` // Go layout, "02/01/2006" when empty
+	Columns    []PayoutColumn `bson:"columns" json:"columns"`
+	Response   PayoutResponse `bson:"response" json:"response"`
+}
+
+// PayoutColumn is one export column; Field names a withdrawal value, Value is a literal
+type PayoutColumn struct {
+	Header string `bson:"header" json:"header"`
+	Field  string `bson:"field,omitempty" json:"field,omitempty"`
+	Value  string `bson:"value,omitempty" json:"value,omitempty"`
+}
+
+// PayoutResponse names the columns of the bank's response or statement file.
+// Rows whose status is in neither list are left Processing.
+type PayoutResponse struct {
+	ReferenceColumn string   `bson:"reference_column" json:"reference_column"`
+	UTRColumn       string   `bson:"utr_column" json:"utr_column"`
+	StatusColumn    string   `bson:"status_column,omitempty" json:"status_column,omitempty"` // empty: a UTR means paid
+	ReasonColumn    string   `bson:"reason_column,omitempty" json:"reason_column,omitempty"`
+	SuccessValues   []string `bson:"success_values,omitempty" json:"success_values,omitempty"`
+	FailureValues   []string `bson:"failure_values,omitempty" json:"failure_values,omitempty"`
+}
+
+// Fields a PayoutColumn may reference
+const (
+	PayoutFieldReference     = "reference"
+	PayoutFieldWithdrawlID   = "withdrawl_id"
+	PayoutFieldAmount        = "amount"
+	PayoutFieldINRAmount     = "inr_amount"
+	PayoutFieldHolderName    = "holder_name"
+	PayoutFieldAccountNumber = "account_number"
+	PayoutFieldIFSCCode      = "ifsc_code"
+	PayoutFieldBankName      = "bank_name"
+	PayoutFieldBatchID       = "batch_id"
+	PayoutFieldDate          = "date"
+)
+
+// DefaultPayoutTemplate is used until admins configure their banks
+var DefaultPayoutTemplate = PayoutTemplate{
+	Code: "generic",
+	Name: "Generic NEFT bulk transfer",
+	Columns: []PayoutColumn{
+		{Header: "Reference", Field: PayoutFieldReference},
+		{Header: "Beneficiary Name", Field: PayoutFieldHolderName},
+		{Header: "Account Number", Field: PayoutFieldAccountNumber},
+		{Header: "IFSC", Field: PayoutFieldIFSCCode},
+		{Header: "Bank Name", Field: PayoutFieldBankName},
+		{Header: "Amount", Field: PayoutFieldINRAmount},
+		{Header: "Payment Mode", Value: "NEFT"},
+		{Header: "Value Date", Field: PayoutFieldDate},
+	},
+	Response: PayoutResponse{
+		ReferenceColumn: "Reference",
+		UTRColumn:       "UTR",
+		StatusColumn:    "Status",
+		ReasonColumn:    "Remarks",
+		SuccessValues:   []string{"success", "paid", "processed", "completed"},
+		FailureValues:   []string{"failed", "rejected", "returned", "cancelled"},
+	},
+}
+
+type CreatePayoutBatchRequest struct {
+	IDs      []string `json:"ids"`      // empty: oldest UnderReview withdrawals
+	Limit    int      `json:"limit"`    // used when ids is empty
+	Template string   `json:"template"` // payout template code, the first configured one when empty
+	TOTPCode string   `json:"totp_code"`
+}
+
+// PayoutBatchRes is the new batch plus the withdrawals that could not join it
+type PayoutBatchRes struct {
+	Batch   *PayoutBatch     `json:"batch"`
+	Skipped []BulkItemResult `json:"skipped"`
+}
+
+// Outcomes of one response file row
+const (
+	PayoutRowApproved  = "approved"
+	PayoutRowFailed    = "failed"
+	PayoutRowPending   = "pending"
+	PayoutRowUnmatched = "unmatched"
+	PayoutRowSettled   = "already_settled"
+	PayoutRowError     = "error"
+)
+
+type PayoutRowResult struct {
+	Row         int    `json:"row"`
+	Reference   string `json:"reference"`
+	WithdrawlID string `json:"withdrawl_id,omitempty"`
+	UTR         string `json:"utr,omitempty"`
+	Outcome     string `json:"outcome"`
+	Error       string `json:"error,omitempty"`
+}
+
+type PayoutImportRes struct {
+	Summary PayoutImport      `json:"summary"`
+	Rows    []PayoutRowResult `json:"rows"`
+	Batch   *PayoutBatch      `json:"batch"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PreAuthAttempt counts second-factor tries against one pre-auth token,
+// keyed by the token's jti
+type PreAuthAttempt struct {
+	ID          string             `bson:"_id" json:"id"`
+	UserId      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RateQuote holds the INR rate a user was shown so a deposit or withdrawal
+// created before ExpiresAt is locked at it, even if the admin changes usdt_rate
+type RateQuote struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserId    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Kind      string             `bson:"kind" json:"kind"` // LimitDeposit or LimitWithdrawl
+	Rate      money.Amount       `bson:"rate" json:"rate"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+type RateQuoteRequest struct {
+	Kind string `json:"kind" binding:"required"` // "deposit" or "withdrawl"
+}
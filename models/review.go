@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReasonOther requires a free-text note explaining the rejection
+const ReasonOther = "other"
+
+// RejectionReason is one entry of the admin-configurable reason list
+type RejectionReason struct {
+	Code  string `bson:"code" json:"code"`
+	Label string `bson:"label" json:"label"`
+}
+
+// DefaultRejectionReasons is used until admins configure their own list
+var DefaultRejectionReasons = []RejectionReason{
+	{Code: "amount_mismatch", Label: "Amount does not match the transfer"},
+	{Code: "tx_not_found", Label: "Transaction not found"},
+	{Code: "duplicate_request", Label: "Duplicate request"},
+	{Code: "invalid_bank_details", Label: "Invalid bank details"},
+	{Code: ReasonBankPayoutFailed, Label: "Bank transfer failed"},
+	{Code: "suspicious_activity", Label: "Failed compliance review"},
+	{Code: ReasonOther, Label: "Other"},
+}
+
+// Rejection explains to the owner why a request was rejected or failed
+type Rejection struct {
+	Code       string             `bson:"code" json:"code"`
+	Label      string             `bson:"label" json:"label"`
+	Note       string             `bson:"note,omitempty" json:"note,omitempty"`
+	RejectedBy primitive.ObjectID `bson:"rejected_by" json:"-"`
+	RejectedAt time.Time          `bson:"rejected_at" json:"rejected_at"`
+}
+
+// AdminNote is an internal remark on a deposit or withdrawal; never shown to the owner
+type AdminNote struct {
+	AuthorID  primitive.ObjectID `bson:"author_id" json:"author_id"`
+	Note      string             `bson:"note" json:"note"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type AdminNoteRequest struct {
+	Note string `json:"note" binding:"required"`
+}
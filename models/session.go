@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Session struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserId         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Role           string             `bson:"role" json:"role"`
+	RefreshHash    string             `bson:"refresh_hash" json:"-"`
+	PreviousHashes []string           `bson:"previous_hashes" json:"-"`
+	UserAgent      string             `bson:"user_agent" json:"user_agent"`
+	IP             string             `bson:"ip" json:"ip"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt     time.Time          `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt      time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt      *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokeReason   string             `bson:"revoke_reason,omitempty" json:"revoke_reason,omitempty"`
+}
+
+type AuthTokens struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresIn        int64     `json:"expires_in"` // seconds
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        string    `json:"session_id"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// WebSocketTicket lets a browser open the chat socket without putting its
+// access token in the URL. Only the ticket's hash is stored.
+type WebSocketTicket struct {
+	ID        string     `bson:"_id" json:"-"` // sha256 of the ticket
+	UserID    string     `bson:"user_id" json:"user_id"`
+	SessionID string     `bson:"session_id" json:"session_id"`
+	Role      string     `bson:"role" json:"role"`
+	Email     string     `bson:"email" json:"email"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+type WebSocketTicketRes struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int64  `json:"expires_in"` // seconds
+}
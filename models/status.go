@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Deposit and withdrawal statuses; see utils/statemachine for allowed moves
+const (
+	StatusPending     = "Pending"
+	StatusUnderReview = "UnderReview"
+	StatusProcessing  = "Processing"
+	StatusApproved    = "Approved"
+	StatusFailed      = "Failed"
+	StatusRejected    = "Rejected"
+	StatusCancelled   = "Cancelled"
+	StatusExpired     = "Expired"
+)
+
+// OpenStatuses are the non-final statuses; withdrawals in them still hold funds
+var OpenStatuses = []string{StatusPending, StatusUnderReview, StatusProcessing, WithdrawlAwaitingSecondApproval}
+
+// Actor roles recorded in status history besides the JWT roles
+const ActorSystem = "system"
+
+// Actor is who asked for a status change
+type Actor struct {
+	ID   primitive.ObjectID
+	Role string
+}
+
+// StatusChange is one move in a document's status history
+type StatusChange struct {
+	From      string             `bson:"from" json:"from"`
+	To        string             `bson:"to" json:"to"`
+	ActorID   primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	ActorRole string             `bson:"actor_role" json:"actor_role"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SupportInbox is what clients send as receiver_id or peer_id to talk to
+// support instead of one admin
+const SupportInbox = "support"
+
+// SupportInboxID stands in for the shared support inbox in chats. No user
+// has it: its timestamp is the epoch, so generated IDs never collide with it.
+var SupportInboxID, _ = primitive.ObjectIDFromHex("000000000000000000000001")
+
+// Assignment actions recorded on a support conversation
+const (
+	SupportClaim    = "claim"
+	SupportAssign   = "assign"
+	SupportTransfer = "transfer"
+	SupportRelease  = "release"
+)
+
+// SupportConversation tracks who handles a user's chat with the support
+// inbox. The messages stay in chats.
+type SupportConversation struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserId        primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	AssigneeID    *primitive.ObjectID `bson:"assignee_id,omitempty" json:"assignee_id,omitempty"`
+	LastMessageAt time.Time           `bson:"last_message_at" json:"last_message_at"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+
+	// The oldest user message support has not answered yet
+	WaitingSince *time.Time `bson:"waiting_since,omitempty" json:"waiting_since,omitempty"`
+
+	Assignments []SupportAssignment `bson:"assignments,omitempty" json:"assignments,omitempty"`
+}
+
+// SupportAssignment is one change of hands; From or To is nil when the
+// conversation was unassigned on that side
+type SupportAssignment struct {
+	Action  string              `bson:"action" json:"action"`
+	From    *primitive.ObjectID `bson:"from,omitempty" json:"from,omitempty"`
+	To      *primitive.ObjectID `bson:"to,omitempty" json:"to,omitempty"`
+	ActorID primitive.ObjectID  `bson:"actor_id" json:"actor_id"`
+	Reason  string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	At      time.Time           `bson:"at" json:"at"`
+}
+
+type SupportAssignRequest struct {
+	AssigneeID string `json:"assignee_id" binding:"required"`
+	Reason     string `json:"reason"`
+}
+
+type SupportReleaseRequest struct {
+	Reason string `json:"reason"`
+}
+
+// SupportInboxItem is a conversation in the inbox with how long the user
+// has been waiting, zero when support answered last
+type SupportInboxItem struct {
+	SupportConversation
+	User           UserInfo `json:"user"`
+	WaitingSeconds int64    `json:"waiting_seconds"`
+}
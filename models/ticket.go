@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ticket statuses: Open waits on support, Pending waits on the user
+const (
+	TicketOpen     = "Open"
+	TicketPending  = "Pending"
+	TicketResolved = "Resolved"
+	TicketClosed   = "Closed"
+)
+
+// TicketActiveStatuses are the ones shown on the referenced request and in the queue
+var TicketActiveStatuses = []string{TicketOpen, TicketPending}
+
+const (
+	TicketPriorityLow    = "low"
+	TicketPriorityNormal = "normal"
+	TicketPriorityHigh   = "high"
+	TicketPriorityUrgent = "urgent"
+)
+
+// What a ticket is about; matches the limit kinds
+const (
+	TicketRefDeposit   = LimitDeposit
+	TicketRefWithdrawl = LimitWithdrawl
+)
+
+// Realtime ticket events pushed over /chat/ws to the owner and assignee
+const (
+	TicketEventMessage = "ticket_message"
+	TicketEventUpdated = "ticket_updated"
+)
+
+// Ticket is a dispute about one deposit or withdrawal. Its messages live in
+// ticket_messages.
+type Ticket struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserId     primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	RefKind    string              `bson:"ref_kind" json:"ref_kind"`
+	RefID      primitive.ObjectID  `bson:"ref_id" json:"ref_id"`
+	Subject    string              `bson:"subject" json:"subject"`
+	Status     string              `bson:"status" json:"status"`
+	Priority   string              `bson:"priority" json:"priority"`
+	AssigneeID *primitive.ObjectID `bson:"assignee_id,omitempty" json:"assignee_id,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time           `bson:"updated_at" json:"updated_at"`
+
+	// SLA deadlines, set from the priority when it is created or changed
+	FirstResponseDue time.Time  `bson:"first_response_due" json:"first_response_due"`
+	ResolveDue       time.Time  `bson:"resolve_due" json:"resolve_due"`
+	FirstResponseAt  *time.Time `bson:"first_response_at,omitempty" json:"first_response_at,omitempty"`
+	ResolvedAt       *time.Time `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+
+	StatusHistory []StatusChange `bson:"status_history,omitempty" json:"status_history,omitempty"`
+}
+
+type TicketMessage struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TicketID   primitive.ObjectID `bson:"ticket_id" json:"ticket_id"`
+	AuthorID   primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorRole string             `bson:"author_role" json:"author_role"`
+	Message    string             `bson:"message" json:"message"`
+	FilesUrl   []string           `bson:"files" json:"files"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// TicketSummary is what a deposit or withdrawal shows of its open tickets
+type TicketSummary struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	RefID     primitive.ObjectID `bson:"ref_id" json:"-"`
+	Subject   string             `bson:"subject" json:"subject"`
+	Status    string             `bson:"status" json:"status"`
+	Priority  string             `bson:"priority" json:"priority"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// TicketRequest opens a ticket; sent as form-data so files can be attached
+type TicketRequest struct {
+	RefKind  string `form:"ref_kind" binding:"required"`
+	RefID    string `form:"ref_id" binding:"required"`
+	Subject  string `form:"subject" binding:"required"`
+	Message  string `form:"message"`
+	Priority string `form:"priority"`
+}
+
+// TicketUpdateRequest is an admin's change; empty fields are left alone and
+// Unassign clears the assignee
+type TicketUpdateRequest struct {
+	Status     string `json:"status"`
+	Priority   string `json:"priority"`
+	AssigneeID string `json:"assignee_id"`
+	Unassign   bool   `json:"unassign"`
+	Reason     string `json:"reason"`
+}
+
+type TicketRes struct {
+	Ticket   Ticket          `json:"ticket"`
+	Messages []TicketMessage `json:"messages"`
+}
+
+// TicketQueueItem is a ticket with its SLA clock. NextDue is the first
+// response deadline until support answers, then the resolve deadline.
+type TicketQueueItem struct {
+	Ticket
+	NextDue   time.Time `json:"next_due"`
+	DueIn     int64     `json:"due_in_seconds"` // negative once breached
+	Breached  bool      `json:"breached"`
+	WaitingOn string    `json:"waiting_on"` // "support" or "user"
+}
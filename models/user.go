@@ -1,6 +1,7 @@
 package models
 
 import (
+	"p2p/utils/money"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,10 +14,45 @@ type User struct {
 	PhoneNum   string             `bson:"phone_num" json:"phone_num"`
 	Password   string             `bson:"password" json:"password"`
 	Role       string             `bson:"role" json:"role"`
-	Balance    float64            `bson:"balance" json:"balance"`
-	INRBalance float64            `json:"inr_balance"`
+	Balance    money.Amount       `bson:"balance" json:"balance"`
+	INRBalance money.Amount       `json:"inr_balance"`
 	IsBlocked  bool               `bson:"is_blocked" json:"is_blocked"`
 	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+
+	// Two-factor state; secrets and recovery hashes never leave the server
+	TOTPEnabled       bool     `bson:"totp_enabled" json:"totp_enabled"`
+	TOTPSecret        string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string   `bson:"totp_pending_secret,omitempty" json:"-"`
+	TOTPLastStep      int64    `bson:"totp_last_step,omitempty" json:"-"`
+	TOTPRecoveryCodes []string `bson:"totp_recovery_codes,omitempty" json:"-"`
+
+	// Limits tier code and per-user rule overrides; see models.LimitsConfig
+	LimitTier     string    `bson:"limit_tier,omitempty" json:"limit_tier,omitempty"`
+	LimitOverride *LimitSet `bson:"limit_override,omitempty" json:"limit_override,omitempty"`
+}
+
+// RegisterRequest is everything a client may choose about a new account;
+// role, balance, limits and two-factor state are never taken from the body
+type RegisterRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	PhoneNum string `json:"phone_num"`
+	Password string `json:"password"`
+}
+
+// NewUser builds a fresh, unblocked account with role and a zero balance.
+// Two-factor stays off until the user enrolls and verifies a secret.
+func (r RegisterRequest) NewUser(role string) User {
+	return User{
+		Name:      r.Name,
+		Email:     r.Email,
+		PhoneNum:  r.PhoneNum,
+		Password:  r.Password,
+		Role:      role,
+		IsBlocked: false,
+		Balance:   money.Zero(),
+		CreatedAt: time.Now(),
+	}
 }
 
 type Login struct {
@@ -25,9 +61,28 @@ type Login struct {
 }
 
 type UserDash struct {
-	Balance            float64 `json:"balance"`
-	INRBalance         float64 `json:"inr_balance"`
-	PendingWithdrawals int64   `json:"pending_withdrawals"`
-	SellPrice          string  `json:"sell_price"`
-	WalletAddress      string  `json:"wallet_address"` //..
+	Balance            money.Amount `json:"balance"`
+	INRBalance         money.Amount `json:"inr_balance"`
+	PendingWithdrawals int64        `json:"pending_withdrawals"`
+	SellPrice          money.Amount `json:"sell_price"`
+	WalletAddress      string       `json:"wallet_address"` //..
+}
+
+type TOTPEnrollRes struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"` // render as QR code
+}
+
+type TOTPCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TOTPRecoveryCodesRes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorLoginRequest struct {
+	PreAuthToken string `json:"pre_auth_token" binding:"required"`
+	TOTPCodeRequest
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DepositWallet is an address a user sends deposits from. Deposits are only
+// auto-approved when the sender on chain is one of the depositor's verified
+// wallets, and an address can belong to one user only. Ownership is proven
+// by sending exactly ProofAmount from the address to ProofAddress.
+type DepositWallet struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserId       primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Network      string             `bson:"network" json:"network"`
+	Address      string             `bson:"address" json:"address"` // canonical form for the network
+	Label        string             `bson:"label,omitempty" json:"label,omitempty"`
+	ProofAddress string             `bson:"proof_address" json:"proof_address"`
+	ProofAmount  money.Amount       `bson:"proof_amount" json:"proof_amount"`
+	ProofTxHash  string             `bson:"proof_tx_hash,omitempty" json:"proof_tx_hash,omitempty"`
+	VerifiedAt   *time.Time         `bson:"verified_at,omitempty" json:"verified_at,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type DepositWalletRequest struct {
+	Address string `json:"address" binding:"required"`
+	Label   string `json:"label"`
+}
+
+// WalletProofRequest names the test transfer that proves a wallet is the user's
+type WalletProofRequest struct {
+	TxHash string `json:"tx_hash" binding:"required"`
+}
@@ -1,15 +1,23 @@
 package models
 
 import (
+	"p2p/utils/money"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// WithdrawlAwaitingSecondApproval is the status between the first and second
+// approval of a withdrawal at or above the dual approval threshold
+const WithdrawlAwaitingSecondApproval = "AwaitingSecondApproval"
+
 type WithdrawlRequest struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Amount        float64            `bson:"amount" json:"amount"`
-	INRRate       float64            `bson:"inr_rate" json:"inr_rate"`
+	Amount        money.Amount       `bson:"amount" json:"amount"`
+	INRRate       money.Amount       `bson:"inr_rate" json:"inr_rate"`
+	INRAmount     money.Amount       `bson:"inr_amount" json:"inr_amount"`
+	RateQuoteID   primitive.ObjectID `bson:"rate_quote_id,omitempty" json:"rate_quote_id,omitempty"`
+	BeneficiaryID primitive.ObjectID `bson:"beneficiary_id,omitempty" json:"beneficiary_id"`
 	BankName      string             `bson:"bank_name" json:"bank_name"`
 	HolderName    string             `bson:"holder_name" json:"holder_name"`
 	AccountNumber string             `bson:"account_number" json:"account_number"`
@@ -19,12 +27,37 @@ type WithdrawlRequest struct {
 	UTR           string             `bson:"utr" json:"utr"`
 	ApprovedAt    time.Time          `bson:"approved_at" json:"approved_at"`
 	Status        string             `bson:"status" json:"status"`
+
+	// Maker-checker trail; the second approver is only set above the dual approval threshold
+	FirstApproverID  *primitive.ObjectID `bson:"first_approver_id,omitempty" json:"first_approver_id,omitempty"`
+	FirstApprovedAt  *time.Time          `bson:"first_approved_at,omitempty" json:"first_approved_at,omitempty"`
+	SecondApproverID *primitive.ObjectID `bson:"second_approver_id,omitempty" json:"second_approver_id,omitempty"`
+	SecondApprovedAt *time.Time          `bson:"second_approved_at,omitempty" json:"second_approved_at,omitempty"`
+
+	CancelledBy *primitive.ObjectID `bson:"cancelled_by,omitempty" json:"cancelled_by,omitempty"`
+	CancelledAt *time.Time          `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+
+	// Set while the withdrawal is part of a bank payout batch
+	PayoutBatchID *primitive.ObjectID `bson:"payout_batch_id,omitempty" json:"payout_batch_id,omitempty"`
+
+	// Copied from the beneficiary at creation; false for older withdrawals with raw bank details
+	BeneficiaryVerified bool `bson:"beneficiary_verified" json:"beneficiary_verified"`
+
+	// Charged on top of Amount; held with it and booked to platform:revenue on payout
+	Fee money.Amount `bson:"fee" json:"fee"`
+
+	StatusHistory []StatusChange `bson:"status_history,omitempty" json:"status_history,omitempty"`
+	Rejection     *Rejection     `bson:"rejection,omitempty" json:"rejection,omitempty"`
+	AdminNotes    []AdminNote    `bson:"admin_notes,omitempty" json:"admin_notes,omitempty"`
 }
 
 type WithdrawlRes struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Amount        float64            `bson:"amount" json:"amount"`
-	INRRate       float64            `bson:"inr_rate" json:"inr_rate"`
+	Amount        money.Amount       `bson:"amount" json:"amount"`
+	INRRate       money.Amount       `bson:"inr_rate" json:"inr_rate"`
+	INRAmount     money.Amount       `bson:"inr_amount" json:"inr_amount"`
+	RateQuoteID   primitive.ObjectID `bson:"rate_quote_id,omitempty" json:"rate_quote_id,omitempty"`
+	BeneficiaryID primitive.ObjectID `bson:"beneficiary_id,omitempty" json:"beneficiary_id,omitempty"`
 	BankName      string             `bson:"bank_name" json:"bank_name"`
 	HolderName    string             `bson:"holder_name" json:"holder_name"`
 	UTR           string             `bson:"utr" json:"utr"`
@@ -35,4 +68,28 @@ type WithdrawlRes struct {
 	UserId        primitive.ObjectID `bson:"user_id" json:"user_id"`
 	Status        string             `bson:"status" json:"status"`
 	User          UserInfo           `bson:"user" json:"user"`
+
+	FirstApproverID  *primitive.ObjectID `bson:"first_approver_id,omitempty" json:"first_approver_id,omitempty"`
+	FirstApprovedAt  *time.Time          `bson:"first_approved_at,omitempty" json:"first_approved_at,omitempty"`
+	SecondApproverID *primitive.ObjectID `bson:"second_approver_id,omitempty" json:"second_approver_id,omitempty"`
+	SecondApprovedAt *time.Time          `bson:"second_approved_at,omitempty" json:"second_approved_at,omitempty"`
+
+	CancelledBy *primitive.ObjectID `bson:"cancelled_by,omitempty" json:"cancelled_by,omitempty"`
+	CancelledAt *time.Time          `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+
+	// Set while the withdrawal is part of a bank payout batch
+	PayoutBatchID *primitive.ObjectID `bson:"payout_batch_id,omitempty" json:"payout_batch_id,omitempty"`
+
+	// Copied from the beneficiary at creation; false for older withdrawals with raw bank details
+	BeneficiaryVerified bool `bson:"beneficiary_verified" json:"beneficiary_verified"`
+
+	// Charged on top of Amount; held with it and booked to platform:revenue on payout
+	Fee money.Amount `bson:"fee" json:"fee"`
+
+	StatusHistory []StatusChange `bson:"status_history,omitempty" json:"status_history,omitempty"`
+	Rejection     *Rejection     `bson:"rejection,omitempty" json:"rejection,omitempty"`
+	AdminNotes    []AdminNote    `bson:"admin_notes,omitempty" json:"admin_notes,omitempty"`
+
+	// Filled by the service from tickets that are still Open or Pending
+	OpenTickets []TicketSummary `bson:"-" json:"open_tickets,omitempty"`
 }
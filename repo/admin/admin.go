@@ -6,6 +6,7 @@ import (
 	"p2p/config"
 	"p2p/config/db"
 	"p2p/models"
+	"p2p/utils/money"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,9 +19,9 @@ type AdminRepository interface {
 	Upsert(admin models.AdminConfigData) (primitive.ObjectID, error)
 	Fetch() (*models.AdminConfigData, error)
 	GetLedgerStats() (*models.LedgerRes, error)
+	GetCollisions() (*models.CollisionReport, error)
 }
 
-
 type AdminRepo struct{}
 
 func (r *AdminRepo) Upsert(admin models.AdminConfigData) (primitive.ObjectID, error) {
@@ -34,12 +35,30 @@ func (r *AdminRepo) Upsert(admin models.AdminConfigData) (primitive.ObjectID, er
 	if admin.SecureWalletAddress != "" {
 		updateFields["secure_wallet_address"] = admin.SecureWalletAddress
 	}
-	if admin.USDTRate != "" {
+	if admin.USDTRate.IsPositive() {
 		updateFields["usdt_rate"] = admin.USDTRate
 	}
 	if admin.QRCodeURL != "" {
 		updateFields["qr_code_url"] = admin.QRCodeURL
 	}
+	if admin.TOTPApprovalThreshold != nil {
+		updateFields["totp_approval_threshold"] = *admin.TOTPApprovalThreshold
+	}
+	if admin.DualApprovalThreshold != nil {
+		updateFields["dual_approval_threshold"] = *admin.DualApprovalThreshold
+	}
+	if len(admin.RejectionReasons) > 0 {
+		updateFields["rejection_reasons"] = admin.RejectionReasons
+	}
+	if len(admin.PayoutTemplates) > 0 {
+		updateFields["payout_templates"] = admin.PayoutTemplates
+	}
+	if admin.Limits != nil {
+		updateFields["limits"] = admin.Limits
+	}
+	if admin.WithdrawalFees != nil {
+		updateFields["withdrawal_fees"] = admin.WithdrawalFees
+	}
 
 	// If no fields to update, return early
 	if len(updateFields) == 0 {
@@ -101,6 +120,14 @@ func (r *AdminRepo) Fetch() (*models.AdminConfigData, error) {
 
 	return &admin, nil
 }
+
+// lockedINR is a document's INR value at the rate locked when it was created;
+// older documents without inr_amount use their stored inr_rate
+var lockedINR = bson.M{"$ifNull": bson.A{
+	"$inr_amount",
+	bson.M{"$multiply": bson.A{"$amount", bson.M{"$ifNull": bson.A{"$inr_rate", 0}}}},
+}}
+
 func (r *AdminRepo) GetLedgerStats() (*models.LedgerRes, error) {
 	depositCollection := db.GetCollection(config.Cfg.DBName, "deposit")
 	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
@@ -113,14 +140,16 @@ func (r *AdminRepo) GetLedgerStats() (*models.LedgerRes, error) {
 
 	// 1️⃣ Total deposits
 	depCursor, err := depositCollection.Aggregate(ctx, mongo.Pipeline{
-		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}, "inr": bson.M{"$sum": lockedINR}}}},
 	})
 	if err == nil && depCursor.Next(ctx) {
 		var res struct {
-			Total float64 `bson:"total"`
+			Total money.Amount `bson:"total"`
+			INR   money.Amount `bson:"inr"`
 		}
 		if err := depCursor.Decode(&res); err == nil {
 			ledger.TotalDeposits = res.Total
+			ledger.TotalDepositsINR = res.INR
 		}
 	}
 	depCursor.Close(ctx)
@@ -131,24 +160,34 @@ func (r *AdminRepo) GetLedgerStats() (*models.LedgerRes, error) {
 			"_id":   "$status",
 			"total": bson.M{"$sum": "$amount"},
 			"count": bson.M{"$sum": 1},
+			"fees":  bson.M{"$sum": "$fee"},
+			"inr":   bson.M{"$sum": lockedINR},
 		}}},
 	})
 	if err == nil {
 		for withCursor.Next(ctx) {
 			var res struct {
-				Status string  `bson:"_id"`
-				Total  float64 `bson:"total"`
-				Count  int64   `bson:"count"`
+				Status string       `bson:"_id"`
+				Total  money.Amount `bson:"total"`
+				Count  int64        `bson:"count"`
+				Fees   money.Amount `bson:"fees"`
+				INR    money.Amount `bson:"inr"`
 			}
 			if err := withCursor.Decode(&res); err == nil {
 				switch res.Status {
-				case "Approved":
-					ledger.TotalWithdrawals += res.Total
-				case "Pending":
-					ledger.TotalPendingWithdrawals = res.Count
-					ledger.PendingWithdrawalsTotal = res.Total
-				case "Rejected":
+				case models.StatusApproved:
+					ledger.TotalWithdrawals = ledger.TotalWithdrawals.Add(res.Total)
+					ledger.TotalWithdrawalsINR = ledger.TotalWithdrawalsINR.Add(res.INR)
+				case models.StatusPending, models.StatusUnderReview, models.StatusProcessing, models.WithdrawlAwaitingSecondApproval:
+					ledger.TotalPendingWithdrawals += res.Count
+					ledger.PendingWithdrawalsTotal = ledger.PendingWithdrawalsTotal.Add(res.Total)
+					ledger.PendingFeesHeld = ledger.PendingFeesHeld.Add(res.Fees)
+					ledger.PendingWithdrawalsINR = ledger.PendingWithdrawalsINR.Add(res.INR)
+				case models.StatusRejected:
 					ledger.RejectedWithdrawalsTotal = res.Total
+				case models.StatusCancelled:
+					ledger.TotalCancelledWithdrawals = res.Count
+					ledger.CancelledWithdrawalsTotal = res.Total
 				}
 			}
 		}
@@ -161,7 +200,7 @@ func (r *AdminRepo) GetLedgerStats() (*models.LedgerRes, error) {
 	})
 	if err == nil && userCursor.Next(ctx) {
 		var res struct {
-			Total float64 `bson:"total"`
+			Total money.Amount `bson:"total"`
 		}
 		if err := userCursor.Decode(&res); err == nil {
 			ledger.CurrentTotalBalance = res.Total
@@ -182,17 +221,17 @@ func (r *AdminRepo) GetLedgerStats() (*models.LedgerRes, error) {
 	})
 	for depTodayCursor.Next(ctx) {
 		var res struct {
-			Status string  `bson:"_id"`
-			Total  float64 `bson:"total"`
+			Status string       `bson:"_id"`
+			Total  money.Amount `bson:"total"`
 		}
 		_ = depTodayCursor.Decode(&res)
 		switch res.Status {
-		case "Approved":
+		case models.StatusApproved:
 			ledger.TodayStats.TotalDepositsApproved = res.Total
-			ledger.TodayStats.TotalDeposits += res.Total
-		case "Pending":
-			ledger.TodayStats.TotalDepositsPending = res.Total
-			ledger.TodayStats.TotalDeposits += res.Total
+			ledger.TodayStats.TotalDeposits = ledger.TodayStats.TotalDeposits.Add(res.Total)
+		case models.StatusPending, models.StatusUnderReview, models.StatusProcessing:
+			ledger.TodayStats.TotalDepositsPending = ledger.TodayStats.TotalDepositsPending.Add(res.Total)
+			ledger.TodayStats.TotalDeposits = ledger.TodayStats.TotalDeposits.Add(res.Total)
 		}
 	}
 	depTodayCursor.Close(ctx)
@@ -207,17 +246,19 @@ func (r *AdminRepo) GetLedgerStats() (*models.LedgerRes, error) {
 	})
 	for withTodayCursor.Next(ctx) {
 		var res struct {
-			Status string  `bson:"_id"`
-			Total  float64 `bson:"total"`
+			Status string       `bson:"_id"`
+			Total  money.Amount `bson:"total"`
 		}
 		_ = withTodayCursor.Decode(&res)
 		switch res.Status {
-		case "Approved":
+		case models.StatusApproved:
 			ledger.TodayStats.TotalWithdrawalsApproved = res.Total
-			ledger.TodayStats.TotalWithdrawals += res.Total
-		case "Pending":
-			ledger.TodayStats.TotalWithdrawalsPending = res.Total
-			ledger.TodayStats.TotalWithdrawals += res.Total
+			ledger.TodayStats.TotalWithdrawals = ledger.TodayStats.TotalWithdrawals.Add(res.Total)
+		case models.StatusPending, models.StatusUnderReview, models.StatusProcessing, models.WithdrawlAwaitingSecondApproval:
+			ledger.TodayStats.TotalWithdrawalsPending = ledger.TodayStats.TotalWithdrawalsPending.Add(res.Total)
+			ledger.TodayStats.TotalWithdrawals = ledger.TodayStats.TotalWithdrawals.Add(res.Total)
+		case models.StatusCancelled:
+			ledger.TodayStats.TotalWithdrawalsCancelled = res.Total
 		}
 	}
 	withTodayCursor.Close(ctx)
@@ -226,5 +267,98 @@ func (r *AdminRepo) GetLedgerStats() (*models.LedgerRes, error) {
 	newUsersCount, _ := userCollection.CountDocuments(ctx, bson.M{"created_at": bson.M{"$gte": startOfDay}})
 	ledger.TodayStats.NewUsers = newUsersCount
 
+	// 5️⃣ Fees booked to the revenue account, overall and today
+	if total, err := revenueSince(ctx, time.Time{}); err == nil {
+		ledger.TotalFeesCollected = total
+	}
+	if today, err := revenueSince(ctx, startOfDay); err == nil {
+		ledger.TodayStats.FeesCollected = today
+	}
+
 	return ledger, nil
-}
\ No newline at end of file
+}
+
+// revenueSince nets the platform:revenue entries posted at or after since
+func revenueSince(ctx context.Context, since time.Time) (money.Amount, error) {
+	ledgerCollection := db.GetCollection(config.Cfg.DBName, "ledger_entries")
+
+	match := bson.M{"account": models.AccountRevenue}
+	if !since.IsZero() {
+		match["created_at"] = bson.M{"$gte": since}
+	}
+	cursor, err := ledgerCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"total": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$direction", models.LedgerCredit}}, "$amount", bson.M{"$multiply": bson.A{"$amount", -1}},
+			}}},
+		}}},
+	})
+	if err != nil {
+		return money.Zero(), err
+	}
+	defer cursor.Close(ctx)
+
+	var res struct {
+		Total money.Amount `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&res); err != nil {
+			return money.Zero(), err
+		}
+	}
+	return res.Total, cursor.Err()
+}
+
+// GetCollisions lists transaction hashes and UTRs shared by several documents,
+// normalized the same way new submissions are
+func (r *AdminRepo) GetCollisions() (*models.CollisionReport, error) {
+	depositCollection := db.GetCollection(config.Cfg.DBName, "deposit")
+	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	report := &models.CollisionReport{}
+
+	hashes, err := findCollisions(ctx, depositCollection, "transaction_hash", "$toLower")
+	if err != nil {
+		return nil, err
+	}
+	report.DepositTransactionHashes = hashes
+
+	utrs, err := findCollisions(ctx, withdrawCollection, "utr", "$toUpper")
+	if err != nil {
+		return nil, err
+	}
+	report.WithdrawalUTRs = utrs
+
+	return report, nil
+}
+
+func findCollisions(ctx context.Context, collection *mongo.Collection, field, caseOp string) ([]models.Collision, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{field: bson.M{"$type": "string", "$gt": ""}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        bson.M{caseOp: bson.M{"$trim": bson.M{"input": "$" + field}}},
+			"count":      bson.M{"$sum": 1},
+			"ids":        bson.M{"$push": "$_id"},
+			"user_ids":   bson.M{"$addToSet": "$user_id"},
+			"first_seen": bson.M{"$min": "$created_at"},
+			"last_seen":  bson.M{"$max": "$created_at"},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "last_seen", Value: -1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	collisions := []models.Collision{}
+	if err := cursor.All(ctx, &collisions); err != nil {
+		return nil, err
+	}
+	return collisions, nil
+}
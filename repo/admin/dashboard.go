@@ -4,6 +4,7 @@ import (
 	"context"
 	"p2p/config"
 	"p2p/config/db"
+	"p2p/models"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -28,8 +29,8 @@ func (r *DashboardRepo) GetCounts() (map[string]int64, error) {
 		"is_blocked": false,
 		"role":       "user",
 	}
-	depositFilter := bson.M{"status": "Pending"}
-	withdrawlFilter := bson.M{"status": "Pending"}
+	depositFilter := bson.M{"status": bson.M{"$in": models.OpenStatuses}}
+	withdrawlFilter := bson.M{"status": bson.M{"$in": models.OpenStatuses}}
 
 	// counts (return 0 if empty)
 	userCount, err := userCollection.CountDocuments(ctx, userFilter)
@@ -0,0 +1,213 @@
+package beneficiary
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type BeneficiaryRepository interface {
+	Create(b models.Beneficiary) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*models.Beneficiary, error)
+	List(userID *primitive.ObjectID, verified *bool) ([]models.Beneficiary, error)
+	Update(b models.Beneficiary, resetVerification bool) error
+	Delete(id, userID primitive.ObjectID) error
+	SetVerified(id, adminID primitive.ObjectID, verified bool) error
+}
+
+type BeneficiaryRepo struct{}
+
+var (
+	// ErrBeneficiaryNotFound is returned when no live beneficiary matches
+	ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+	// ErrDuplicateBeneficiary is returned when the user already saved the same account
+	ErrDuplicateBeneficiary = errors.New("this bank account is already saved")
+)
+
+// notDeleted matches beneficiaries their owner has not deleted
+var notDeleted = bson.M{"$exists": false}
+
+func (r *BeneficiaryRepo) Create(b models.Beneficiary) (primitive.ObjectID, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.checkDuplicate(ctx, b); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	b.ID = primitive.NewObjectID()
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = b.CreatedAt
+	if _, err := collection.InsertOne(ctx, b); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return b.ID, nil
+}
+
+// checkDuplicate rejects a second live entry for the same account and IFSC
+func (r *BeneficiaryRepo) checkDuplicate(ctx context.Context, b models.Beneficiary) error {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"user_id":        b.UserId,
+		"account_number": b.AccountNumber,
+		"ifsc_code":      b.IFSCCode,
+		"_id":            bson.M{"$ne": b.ID},
+		"deleted_at":     notDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateBeneficiary
+	}
+	return nil
+}
+
+func (r *BeneficiaryRepo) GetByID(id primitive.ObjectID) (*models.Beneficiary, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var b models.Beneficiary
+	if err := collection.FindOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted}).Decode(&b); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrBeneficiaryNotFound
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+// List returns live beneficiaries, newest first, optionally for one user
+// and by verification state
+func (r *BeneficiaryRepo) List(userID *primitive.ObjectID, verified *bool) ([]models.Beneficiary, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"deleted_at": notDeleted}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+	if verified != nil {
+		filter["verified"] = *verified
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.Beneficiary{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Update saves the owner's edits; resetVerification clears an admin
+// verification that no longer covers the new bank details
+func (r *BeneficiaryRepo) Update(b models.Beneficiary, resetVerification bool) error {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.checkDuplicate(ctx, b); err != nil {
+		return err
+	}
+
+	set := bson.M{
+		"nickname":       b.Nickname,
+		"holder_name":    b.HolderName,
+		"account_number": b.AccountNumber,
+		"ifsc_code":      b.IFSCCode,
+		"bank_name":      b.BankName,
+		"branch_name":    b.BranchName,
+		"updated_at":     time.Now(),
+	}
+	update := bson.M{"$set": set}
+	if resetVerification {
+		set["verified"] = false
+		update["$unset"] = bson.M{"verified_by": "", "verified_at": ""}
+	}
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": b.ID, "user_id": b.UserId, "deleted_at": notDeleted},
+		update,
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrBeneficiaryNotFound
+	}
+	return nil
+}
+
+// Delete hides the beneficiary from its owner; withdrawals keep their copy
+func (r *BeneficiaryRepo) Delete(id, userID primitive.ObjectID) error {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "deleted_at": notDeleted},
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrBeneficiaryNotFound
+	}
+	return nil
+}
+
+// SetVerified records or withdraws an admin's verification
+func (r *BeneficiaryRepo) SetVerified(id, adminID primitive.ObjectID, verified bool) error {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"verified": false}, "$unset": bson.M{"verified_by": "", "verified_at": ""}}
+	if verified {
+		update = bson.M{"$set": bson.M{"verified": true, "verified_by": adminID, "verified_at": time.Now()}}
+	}
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrBeneficiaryNotFound
+	}
+	return nil
+}
+
+// EnsureIndexes supports per-user listing and the verification queue
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "beneficiaries")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("user_created")},
+		{Keys: bson.D{{Key: "verified", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("verified_created")},
+	})
+	return err
+}
@@ -2,26 +2,33 @@ package chats
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"p2p/config"
 	"p2p/config/db"
 	"p2p/models"
+	"strconv"
+	"strings"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type ChatRepoInterface interface {
 	CreateChat(chat *models.Chat) error
-	GetChatsBetweenUsers(userA, userB primitive.ObjectID) (models.ChatsRes, error)
+	GetChatsBetweenUsers(userA, userB primitive.ObjectID, q models.ChatPageQuery) (models.ChatPage, error)
 	GetUniqueChatUsers(userID primitive.ObjectID) ([]models.ChatUsers, error)
-	UpdateChatsReadStatus(chatIDs []primitive.ObjectID) error
+	MarkConversationRead(readerID, peerID primitive.ObjectID, upTo time.Time) ([]models.Chat, error)
+	GetPeerIDs(userID primitive.ObjectID) ([]primitive.ObjectID, error)
 }
 
 type ChatRepo struct{}
 
+var ErrInvalidCursor = errors.New("invalid chat cursor")
+
 // CreateChat inserts a new chat into the "chats" collection
 func (r *ChatRepo) CreateChat(chat *models.Chat) error {
 	collection := db.GetCollection(config.Cfg.DBName, "chats")
@@ -39,66 +46,124 @@ func (r *ChatRepo) CreateChat(chat *models.Chat) error {
 	return nil
 }
 
-func (r *ChatRepo) GetChatsBetweenUsers(userA, userB primitive.ObjectID) (models.ChatsRes, error) {
+// GetChatsBetweenUsers returns one page of the conversation, oldest first.
+// Without a cursor it is the latest q.Limit messages.
+func (r *ChatRepo) GetChatsBetweenUsers(userA, userB primitive.ObjectID, q models.ChatPageQuery) (models.ChatPage, error) {
 	chatCollection := db.GetCollection(config.Cfg.DBName, "chats")
 	userCollection := db.GetCollection(config.Cfg.DBName, "users")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// 1️⃣ Fetch chats
+	// 1️⃣ The conversation, narrowed to one side of the cursor
 	filter := bson.M{
 		"$or": []bson.M{
 			{"sender_id": userA, "receiver_id": userB},
 			{"sender_id": userB, "receiver_id": userA},
 		},
 	}
-	opts := options.Find().SetSort(bson.M{"timestamp": 1})
+	op, dir, cur := "$lt", -1, q.Before
+	if q.After != "" {
+		op, dir, cur = "$gt", 1, q.After
+	}
+	if cur != "" {
+		ts, id, err := decodeCursor(cur)
+		if err != nil {
+			return models.ChatPage{}, err
+		}
+		filter = bson.M{"$and": []bson.M{filter, {
+			"$or": []bson.M{
+				{"timestamp": bson.M{op: ts}},
+				{"timestamp": ts, "_id": bson.M{op: id}},
+			},
+		}}}
+	}
+
+	// One extra tells whether another page follows
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: dir}, {Key: "_id", Value: dir}}).
+		SetLimit(int64(q.Limit + 1))
 
 	cursor, err := chatCollection.Find(ctx, filter, opts)
 	if err != nil {
-		return models.ChatsRes{}, err
+		return models.ChatPage{}, err
 	}
 	defer cursor.Close(ctx)
 
 	var chats []models.Chat
 	if err := cursor.All(ctx, &chats); err != nil {
-		return models.ChatsRes{}, err
+		return models.ChatPage{}, err
 	}
 
-	// 2️⃣ Build response with user info
-	var results models.ChatsRes
-	for _, chat := range chats {
-		var sender, receiver models.User
-
-		_ = userCollection.FindOne(ctx, bson.M{"_id": chat.Sender}).Decode(&sender)
-		_ = userCollection.FindOne(ctx, bson.M{"_id": chat.Receiver}).Decode(&receiver)
-		if chat.IsRead {
-			results.Read = append(results.Read, models.Chatres{
-				ID:        chat.ID,
-				Sender:    models.UserInfo{Username: sender.Name, Email: sender.Email},
-				Receiver:  models.UserInfo{Username: receiver.Name, Email: receiver.Email},
-				FilesUrl:  chat.FilesUrl,
-				Message:   chat.Message,
-				Timestamp: chat.Timestamp,
-				IsRead:    chat.IsRead,
-			})
-		} else {
-			results.Unread = append(results.Unread, models.Chatres{
-				ID:        chat.ID,
-				Sender:    models.UserInfo{Username: sender.Name, Email: sender.Email},
-				Receiver:  models.UserInfo{Username: receiver.Name, Email: receiver.Email},
-				FilesUrl:  chat.FilesUrl,
-				Message:   chat.Message,
-				Timestamp: chat.Timestamp,
-				IsRead:    chat.IsRead,
-			})
+	page := models.ChatPage{Messages: []models.Chatres{}}
+	if len(chats) > q.Limit {
+		chats = chats[:q.Limit]
+		last := chats[len(chats)-1]
+		page.NextCursor = encodeCursor(last.Timestamp, last.ID)
+	}
+	if dir < 0 {
+		for i, j := 0, len(chats)-1; i < j; i, j = i+1, j-1 {
+			chats[i], chats[j] = chats[j], chats[i]
+		}
+	}
 
+	// 2️⃣ Both participants in one lookup; the support inbox is not a user
+	infos := map[primitive.ObjectID]models.UserInfo{models.SupportInboxID: {Username: "Support"}}
+	if len(chats) > 0 {
+		userCursor, err := userCollection.Find(ctx, bson.M{"_id": bson.M{"$in": []primitive.ObjectID{userA, userB}}})
+		if err != nil {
+			return models.ChatPage{}, err
+		}
+		var users []models.User
+		if err := userCursor.All(ctx, &users); err != nil {
+			return models.ChatPage{}, err
+		}
+		for _, u := range users {
+			infos[u.ID] = models.UserInfo{Username: u.Name, Email: u.Email}
 		}
+	}
 
+	for _, chat := range chats {
+		page.Messages = append(page.Messages, models.Chatres{
+			ID:        chat.ID,
+			Sender:    infos[chat.Sender],
+			Receiver:  infos[chat.Receiver],
+			FilesUrl:  chat.FilesUrl,
+			Message:   chat.Message,
+			Timestamp: chat.Timestamp,
+			IsRead:    chat.IsRead,
+			AgentID:   chat.AgentID,
+		})
 	}
 
-	return results, nil
+	return page, nil
+}
+
+// Cursors are the timestamp and ID of the last message on a page, so
+// messages sharing a millisecond are neither skipped nor repeated
+func encodeCursor(ts time.Time, id primitive.ObjectID) string {
+	raw := strconv.FormatInt(ts.UnixMilli(), 10) + "." + id.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cur string) (time.Time, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cur)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	ms, hex, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	return time.UnixMilli(n).UTC(), id, nil
 }
 
 func (r *ChatRepo) GetUniqueChatUsers(userID primitive.ObjectID) ([]models.ChatUsers, error) {
@@ -217,23 +282,90 @@ func (r *ChatRepo) GetUniqueChatUsers(userID primitive.ObjectID) ([]models.ChatU
 	return result, nil
 }
 
-// UpdateChatsReadStatus updates the read status of multiple chat messages
-func (r *ChatRepo) UpdateChatsReadStatus(chatIDs []primitive.ObjectID) error {
+// MarkConversationRead marks what peerID sent readerID up to upTo as read
+// and returns the messages that changed
+func (r *ChatRepo) MarkConversationRead(readerID, peerID primitive.ObjectID, upTo time.Time) ([]models.Chat, error) {
 	collection := db.GetCollection(config.Cfg.DBName, "chats")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"_id": bson.M{"$in": chatIDs}}
-	update := bson.M{
-		"$set": bson.M{
-			"is_read":   true,
-		},
+	// 1️⃣ Only messages addressed to the reader
+	filter := bson.M{
+		"sender_id":   peerID,
+		"receiver_id": readerID,
+		"timestamp":   bson.M{"$lte": upTo},
+		"is_read":     false,
+	}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	_, err := collection.UpdateMany(ctx, filter, update)
+	unread := []models.Chat{}
+	if err := cursor.All(ctx, &unread); err != nil {
+		return nil, err
+	}
+	if len(unread) == 0 {
+		return unread, nil
+	}
+
+	// 2️⃣ Flip exactly those, so receipts match what changed
+	ids := make([]primitive.ObjectID, len(unread))
+	for i, c := range unread {
+		ids[i] = c.ID
+	}
+	_, err = collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "is_read": false},
+		bson.M{"$set": bson.M{"is_read": true}},
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
\ No newline at end of file
+	return unread, nil
+}
+
+// GetPeerIDs returns everyone the user has exchanged a message with, except
+// the support inbox which has no presence of its own
+func (r *ChatRepo) GetPeerIDs(userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "chats")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received, sent []primitive.ObjectID
+	if err := collection.Distinct(ctx, "sender_id", bson.M{"receiver_id": userID}).Decode(&received); err != nil {
+		return nil, err
+	}
+	if err := collection.Distinct(ctx, "receiver_id", bson.M{"sender_id": userID}).Decode(&sent); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[primitive.ObjectID]bool, len(received)+len(sent))
+	peers := make([]primitive.ObjectID, 0, len(received)+len(sent))
+	for _, id := range append(received, sent...) {
+		if id != userID && id != models.SupportInboxID && !seen[id] {
+			seen[id] = true
+			peers = append(peers, id)
+		}
+	}
+	return peers, nil
+}
+
+// EnsureIndexes backs conversation pages: each direction of a conversation
+// is a range scan in timestamp order
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "chats")
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "sender_id", Value: 1},
+			{Key: "receiver_id", Value: 1},
+			{Key: "timestamp", Value: -1},
+			{Key: "_id", Value: -1},
+		},
+		Options: options.Index().SetName("sender_receiver_timestamp"),
+	})
+	return err
+}
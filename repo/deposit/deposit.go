@@ -8,18 +8,23 @@ import (
 	"p2p/config"
 	"p2p/config/db"
 	"p2p/models"
+	"p2p/repo/ledger"
+	"p2p/utils/statemachine"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	mongov2 "go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type DepositRepository interface {
-	UpdateDepositStatus(depositID string, approve bool) error
-	DepositRequest(req models.DepositRequest) error
+	UpdateDepositStatus(depositID, status string, actor models.Actor, reason string, rejection *models.Rejection) error
+	AddAdminNote(depositID string, note models.AdminNote) error
+	DepositRequest(req models.DepositRequest, check func(ctx context.Context) error) (primitive.ObjectID, error)
+	SetVerification(depositID primitive.ObjectID, v models.ChainVerification) error
 	GetAll() ([]models.DepositRes, error)
 	GetAllByUserID(userID string) ([]models.DepositRes, error)
 	GetByID(id string) (*models.DepositRes, error)
@@ -28,11 +33,22 @@ type DepositRepository interface {
 
 type DepositRepo struct{}
 
-func (r *DepositRepo) UpdateDepositStatus(depositID string, approve bool) error {
+var (
+	// ErrDepositNotPending is returned when another request changed the deposit status first
+	ErrDepositNotPending = errors.New("deposit status changed, reload and retry")
+	// ErrDepositNotFound is returned when no deposit has the given ID
+	ErrDepositNotFound = errors.New("deposit not found")
+	// ErrDuplicateTransactionHash is returned when the transaction hash was already submitted
+	ErrDuplicateTransactionHash = errors.New("transaction hash already submitted")
+)
+
+// UpdateDepositStatus moves a deposit one step along the deposit state
+// machine, recording it in status_history. Reaching Approved credits the user
+// through the ledger in the same transaction.
+func (r *DepositRepo) UpdateDepositStatus(depositID, status string, actor models.Actor, reason string, rejection *models.Rejection) error {
 	depositCollection := db.GetCollection(config.Cfg.DBName, "deposit")
-	userCollection := db.GetCollection(config.Cfg.DBName, "users")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	id := strings.TrimSpace(depositID)
@@ -42,72 +58,139 @@ func (r *DepositRepo) UpdateDepositStatus(depositID string, approve bool) error
 		return fmt.Errorf("invalid deposit ID: %w", err)
 	}
 
-	// 1️⃣ Find deposit
-	var dep models.DepositRequest
-	err = depositCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&dep)
+	// 1️⃣ Check the move from the current status
+	var cur models.DepositRequest
+	if err := depositCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&cur); err != nil {
+		if errors.Is(err, mongov2.ErrNoDocuments) {
+			return ErrDepositNotFound
+		}
+		return err
+	}
+	change, err := statemachine.Deposit.Step(cur.Status, status, actor, reason, time.Now())
 	if err != nil {
-		log.Printf("Deposit not found: %v \n object id : %v \n deposit id : %s", err, oid, depositID)
-		return fmt.Errorf("deposit not found: %w \n object id : %v \n deposit id : %s", err, oid, depositID)
+		return err
 	}
 
-	// 2️⃣ If already processed, block duplicate updates
-	if dep.Status == "Approved" || dep.Status == "Rejected" {
-		return fmt.Errorf("deposit already %s", dep.Status)
+	set := bson.M{"status": status}
+	if rejection != nil {
+		set["rejection"] = rejection
 	}
 
-	if approve {
-		// 3️⃣ Approve → update deposit status
-		_, err = depositCollection.UpdateOne(
+	return db.WithTransaction(ctx, func(ctx context.Context) error {
+		// 2️⃣ Apply it only if nobody moved the deposit meanwhile
+		var dep models.DepositRequest
+		err := depositCollection.FindOneAndUpdate(
 			ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{"status": "Approved"}},
-		)
+			bson.M{"_id": oid, "status": cur.Status},
+			bson.M{
+				"$set":  set,
+				"$push": bson.M{"status_history": change},
+			},
+		).Decode(&dep)
 		if err != nil {
-			return fmt.Errorf("failed to approve deposit: %w", err)
+			if errors.Is(err, mongov2.ErrNoDocuments) {
+				log.Printf("Deposit status changed concurrently: object id : %v \n deposit id : %s", oid, depositID)
+				return ErrDepositNotPending
+			}
+			return fmt.Errorf("failed to update deposit status: %w", err)
 		}
 
-		// 4️⃣ Increment user balance
-		_, err = userCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": dep.UserId},
-			bson.M{"$inc": bson.M{"balance": dep.Amount}},
-		)
-		if err != nil {
-			return fmt.Errorf("failed to update user balance: %w", err)
+		if status != models.StatusApproved {
+			return nil
 		}
 
-	} else {
-		// 5️⃣ Reject → only update deposit status
-		_, err = depositCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{"status": "Rejected"}},
-		)
-		if err != nil {
-			return fmt.Errorf("failed to reject deposit: %w", err)
+		// 3️⃣ Credit the user through the ledger in the same transaction
+		ledgerRepo := ledger.LedgerRepository(&ledger.LedgerRepo{})
+		if err := ledgerRepo.Post(ctx, ledger.DepositApproval(dep.UserId, dep.ID, dep.Amount, actor.ID)); err != nil {
+			return fmt.Errorf("failed to credit deposit: %w", err)
 		}
+
+		return nil
+	})
+}
+
+// AddAdminNote appends an internal note to the deposit
+func (r *DepositRepo) AddAdminNote(depositID string, note models.AdminNote) error {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit")
+
+	oid, err := primitive.ObjectIDFromHex(strings.TrimSpace(depositID))
+	if err != nil {
+		return fmt.Errorf("invalid deposit ID: %w", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$push": bson.M{"admin_notes": note}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrDepositNotFound
+	}
 	return nil
 }
 
-func (r *DepositRepo) DepositRequest(req models.DepositRequest) error {
+// DepositRequest inserts a new deposit after check passes in the same
+// transaction, so a limit check cannot race the insert it guards
+func (r *DepositRepo) DepositRequest(req models.DepositRequest, check func(ctx context.Context) error) (primitive.ObjectID, error) {
 	collection := db.GetCollection(config.Cfg.DBName, "deposit")
 
 	// Set auto-generated fields
 	req.ID = primitive.NewObjectID()
 	req.CreatedAt = time.Now()
 
-	// Insert into MongoDB
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		// 1️⃣ Limits and anything else the caller must see atomically
+		if err := check(ctx); err != nil {
+			return err
+		}
+
+		// 2️⃣ The unique index is authoritative; this also covers databases
+		// where it could not be built because of historical duplicates
+		if req.TransactionHash != "" {
+			count, err := collection.CountDocuments(ctx, bson.M{"transaction_hash": req.TransactionHash})
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrDuplicateTransactionHash
+			}
+		}
+
+		// 3️⃣ Insert into MongoDB
+		if _, err := collection.InsertOne(ctx, req); err != nil {
+			if mongov2.IsDuplicateKeyError(err) {
+				return ErrDuplicateTransactionHash
+			}
+			log.Println(err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return req.ID, nil
+}
+
+// SetVerification stores the latest chain verification result on the deposit
+func (r *DepositRepo) SetVerification(depositID primitive.ObjectID, v models.ChainVerification) error {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit")
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := collection.InsertOne(ctx, req)
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": depositID}, bson.M{"$set": bson.M{"verification": v}})
 	if err != nil {
-		log.Println(err)
 		return err
 	}
-
+	if res.MatchedCount == 0 {
+		return ErrDepositNotPending
+	}
 	return nil
 }
 
@@ -155,24 +238,18 @@ func (r *DepositRepo) GetAllByUserID(userID string) ([]models.DepositRes, error)
 	for _, dep := range deposits {
 		var user models.User
 		err := userCollection.FindOne(ctx, bson.M{"_id": dep.UserId}).Decode(&user)
-		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		if err != nil && !errors.Is(err, mongov2.ErrNoDocuments) {
 			return nil, err
 		}
 
-		results = append(results, models.DepositRes{
-			ID:              dep.ID,
-			Amount:          dep.Amount,
-			Status:          dep.Status,
-			TransactionHash: dep.TransactionHash,
-			CreatedAt:       dep.CreatedAt,
-			User: models.UserInfo{
-				Username: user.Name,
-				Email:    user.Email,
-			},
-		})
+		// Keep the whole decoded deposit; handlers strip what users may not see
+		dep.User = models.UserInfo{
+			Username: user.Name,
+			Email:    user.Email,
+		}
+		results = append(results, dep)
 	}
 
-	log.Printf("Found %d deposit results", len(results))
 	return results, nil
 }
 
@@ -191,7 +268,7 @@ func (r *DepositRepo) GetByID(id string) (*models.DepositRes, error) {
 	// 1️⃣ Fetch the deposit
 	var dep models.DepositRes
 	if err := depositCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&dep); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+		if errors.Is(err, mongov2.ErrNoDocuments) {
 			return nil, nil
 		}
 		return nil, err
@@ -199,7 +276,7 @@ func (r *DepositRepo) GetByID(id string) (*models.DepositRes, error) {
 
 	// 2️⃣ Fetch the user info
 	var user models.User
-	if err := userCollection.FindOne(ctx, bson.M{"_id": dep.UserId}).Decode(&user); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+	if err := userCollection.FindOne(ctx, bson.M{"_id": dep.UserId}).Decode(&user); err != nil && !errors.Is(err, mongov2.ErrNoDocuments) {
 		return nil, err
 	}
 
@@ -340,6 +417,20 @@ func (r *DepositRepo) GetAll() ([]models.DepositRes, error) {
 	return results, nil
 }
 
+// EnsureIndexes makes non-empty transaction hashes unique across deposits
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit")
+
+	_, err := collection.Indexes().CreateOne(ctx, mongov2.IndexModel{
+		Keys: bson.M{"transaction_hash": 1},
+		Options: options.Index().
+			SetName("transaction_hash_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"transaction_hash": bson.M{"$type": "string", "$gt": ""}}),
+	})
+	return err
+}
+
 // -------------------- PIPELINE HELPERS --------------------
 // Use consistent BSON type (bson.D) throughout
 func lookupStage() bson.D {
@@ -0,0 +1,109 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type IdempotencyRepository interface {
+	Reserve(rec models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Complete(id, leaseID string, code int, resp models.GeneralResponse) error
+	Release(id, leaseID string) error
+}
+
+type IdempotencyRepo struct{}
+
+const collectionName = "idempotency_keys"
+
+// Reserve inserts rec as in progress. If the key was already used the stored
+// record is returned instead and nothing is written, unless it is the same
+// request still in progress past its lease, which rec then takes over.
+func (r *IdempotencyRepo) Reserve(rec models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	collection := db.GetCollection(config.Cfg.DBName, collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.InsertOne(ctx, rec)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	// 1️⃣ The holder crashed or timed out; only one retry wins the takeover
+	res, err := collection.UpdateOne(ctx, bson.M{
+		"_id":          rec.ID,
+		"request_hash": rec.RequestHash,
+		"status":       models.IdempotencyInProgress,
+		"$or": bson.A{
+			bson.M{"lease_until": bson.M{"$lt": rec.CreatedAt}},
+			bson.M{"lease_until": bson.M{"$exists": false}},
+		},
+	}, bson.M{"$set": bson.M{"lease_id": rec.LeaseID, "lease_until": rec.LeaseUntil}})
+	if err != nil {
+		return nil, err
+	}
+	if res.ModifiedCount == 1 {
+		return nil, nil
+	}
+
+	// 2️⃣ Otherwise hand back what is stored
+	var existing models.IdempotencyRecord
+	if err := collection.FindOne(ctx, bson.M{"_id": rec.ID}).Decode(&existing); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Expired between the insert and the read; let the caller retry
+			return nil, errors.New("idempotency key expired, retry the request")
+		}
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Complete stores the response so retries can replay it. It does nothing
+// once the lease was taken over, since the new holder answers instead.
+func (r *IdempotencyRepo) Complete(id, leaseID string, code int, resp models.GeneralResponse) error {
+	collection := db.GetCollection(config.Cfg.DBName, collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id, "lease_id": leaseID}, bson.M{"$set": bson.M{
+		"status":        models.IdempotencyCompleted,
+		"response_code": code,
+		"response":      resp,
+	}})
+	return err
+}
+
+// Release drops a reservation so the key can be retried, e.g. after a server
+// error. A reservation taken over by a retry is left alone.
+func (r *IdempotencyRepo) Release(id, leaseID string) error {
+	collection := db.GetCollection(config.Cfg.DBName, collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id, "lease_id": leaseID, "status": models.IdempotencyInProgress})
+	return err
+}
+
+// EnsureIndexes creates the TTL index that expires old keys
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, collectionName)
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+	})
+	return err
+}
@@ -0,0 +1,60 @@
+package indexes
+
+import (
+	"context"
+	"log"
+	"p2p/repo/beneficiary"
+	"p2p/repo/chats"
+	"p2p/repo/deposit"
+	"p2p/repo/idempotency"
+	"p2p/repo/limits"
+	"p2p/repo/passwordreset"
+	"p2p/repo/payout"
+	"p2p/repo/preauth"
+	"p2p/repo/rates"
+	"p2p/repo/sessions"
+	"p2p/repo/support"
+	"p2p/repo/tickets"
+	"p2p/repo/wallets"
+	"p2p/repo/withdrawl"
+	"p2p/repo/wstickets"
+	"time"
+)
+
+// EnsureAll creates the indexes every repo relies on. Failures are logged and
+// do not stop startup so the API stays available on a degraded database.
+func EnsureAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	steps := []struct {
+		name string
+		fn   func(ctx context.Context) error
+	}{
+		{"idempotency_keys", idempotency.EnsureIndexes},
+		{"sessions", sessions.EnsureIndexes},
+		{"ws_tickets", wstickets.EnsureIndexes},
+		{"password_resets", passwordreset.EnsureIndexes},
+		{"preauth_attempts", preauth.EnsureIndexes},
+		// Unique hash/UTR indexes fail while duplicates exist; see /admin/reports/collisions
+		{"deposit", deposit.EnsureIndexes},
+		{"withdrawl", withdrawl.EnsureIndexes},
+		{"payout_batches", payout.EnsureIndexes},
+		{"beneficiaries", beneficiary.EnsureIndexes},
+		{"limits", limits.EnsureIndexes},
+		{"rate_quotes", rates.EnsureIndexes},
+		{"chats", chats.EnsureIndexes},
+		{"tickets", tickets.EnsureIndexes},
+		{"support_conversations", support.EnsureIndexes},
+		{"deposit_wallets", wallets.EnsureIndexes},
+	}
+
+	for _, step := range steps {
+		if err := step.fn(ctx); err != nil {
+			log.Printf("❌ Failed to ensure %s indexes: %v", step.name, err)
+			continue
+		}
+	}
+
+	log.Println("✅ Indexes ensured")
+}
@@ -0,0 +1,338 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type LedgerRepository interface {
+	Post(ctx context.Context, txn models.LedgerTxn) error
+	PostTxn(txn models.LedgerTxn) error
+	GetUserEntries(userID primitive.ObjectID) ([]models.LedgerEntry, error)
+	RebuildUserBalance(userID primitive.ObjectID) (money.Amount, error)
+}
+
+type LedgerRepo struct{}
+
+var (
+	// ErrUnbalanced is returned when the debits and credits of a transaction differ
+	ErrUnbalanced = errors.New("ledger transaction is not balanced")
+	// ErrInsufficientBalance is returned when a debit would take a user balance below zero
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrUserNotFound is returned when a user leg targets a missing user
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// -------------------- TRANSACTION BUILDERS --------------------
+
+// DepositApproval credits the user and debits custody for an approved deposit
+func DepositApproval(userID, depositID primitive.ObjectID, amount money.Amount, actor primitive.ObjectID) models.LedgerTxn {
+	return models.LedgerTxn{
+		Kind:      models.LedgerKindDeposit,
+		RefID:     depositID,
+		CreatedBy: actor,
+		Legs: []models.LedgerLeg{
+			{Account: models.AccountCustody, Direction: models.LedgerDebit, Amount: amount},
+			{Account: models.UserAccount(userID), UserId: userID, Direction: models.LedgerCredit, Amount: amount},
+		},
+	}
+}
+
+// WithdrawalHold moves the withdrawal amount and its fee from the user into the held account
+func WithdrawalHold(userID, withdrawID primitive.ObjectID, amount, fee money.Amount) models.LedgerTxn {
+	total := amount.Add(fee)
+	return models.LedgerTxn{
+		Kind:      models.LedgerKindWithdrawalHold,
+		RefID:     withdrawID,
+		CreatedBy: userID,
+		Legs: []models.LedgerLeg{
+			{Account: models.UserAccount(userID), UserId: userID, Direction: models.LedgerDebit, Amount: total},
+			{Account: models.AccountWithdrawalsHeld, Direction: models.LedgerCredit, Amount: total},
+		},
+	}
+}
+
+// WithdrawalRefund returns a held withdrawal amount and its fee to the user;
+// no fee is kept for withdrawals that are not paid out
+func WithdrawalRefund(userID, withdrawID primitive.ObjectID, amount, fee money.Amount, actor primitive.ObjectID) models.LedgerTxn {
+	total := amount.Add(fee)
+	return models.LedgerTxn{
+		Kind:      models.LedgerKindWithdrawalRefund,
+		RefID:     withdrawID,
+		CreatedBy: actor,
+		Legs: []models.LedgerLeg{
+			{Account: models.AccountWithdrawalsHeld, Direction: models.LedgerDebit, Amount: total},
+			{Account: models.UserAccount(userID), UserId: userID, Direction: models.LedgerCredit, Amount: total},
+		},
+	}
+}
+
+// WithdrawalPayout releases a held amount out of custody once the payout is
+// made and books the fee as platform revenue
+func WithdrawalPayout(withdrawID primitive.ObjectID, amount, fee money.Amount, actor primitive.ObjectID) models.LedgerTxn {
+	txn := models.LedgerTxn{
+		Kind:      models.LedgerKindWithdrawalPayout,
+		RefID:     withdrawID,
+		CreatedBy: actor,
+		Legs: []models.LedgerLeg{
+			{Account: models.AccountWithdrawalsHeld, Direction: models.LedgerDebit, Amount: amount.Add(fee)},
+			{Account: models.AccountCustody, Direction: models.LedgerCredit, Amount: amount},
+		},
+	}
+	if fee.IsPositive() {
+		txn.Legs = append(txn.Legs, models.LedgerLeg{Account: models.AccountRevenue, Direction: models.LedgerCredit, Amount: fee})
+	}
+	return txn
+}
+
+// Adjustment books a manual admin correction against the user balance
+func Adjustment(userID primitive.ObjectID, amount money.Amount, direction, note string, actor primitive.ObjectID) models.LedgerTxn {
+	platformSide := models.LedgerDebit
+	if direction == models.LedgerDebit {
+		platformSide = models.LedgerCredit
+	}
+	return models.LedgerTxn{
+		Kind:      models.LedgerKindAdjustment,
+		Note:      note,
+		CreatedBy: actor,
+		Legs: []models.LedgerLeg{
+			{Account: models.AccountAdjustments, Direction: platformSide, Amount: amount},
+			{Account: models.UserAccount(userID), UserId: userID, Direction: direction, Amount: amount},
+		},
+	}
+}
+
+// -------------------- POSTING --------------------
+
+// Post writes the entries of txn and applies the user legs to the cached
+// users.balance. It must be called with a context from db.WithTransaction.
+func (r *LedgerRepo) Post(ctx context.Context, txn models.LedgerTxn) error {
+	ledgerCollection := db.GetCollection(config.Cfg.DBName, "ledger_entries")
+	userCollection := db.GetCollection(config.Cfg.DBName, "users")
+
+	if err := validate(txn); err != nil {
+		return err
+	}
+
+	// Legacy users get their pre-ledger balance booked before the first posting
+	for _, leg := range txn.Legs {
+		if leg.UserId.IsZero() {
+			continue
+		}
+		if err := bookOpeningBalance(ctx, leg.UserId); err != nil {
+			return err
+		}
+	}
+
+	txnID := primitive.NewObjectID()
+	now := time.Now()
+
+	entries := make([]interface{}, 0, len(txn.Legs))
+	for _, leg := range txn.Legs {
+		entries = append(entries, models.LedgerEntry{
+			ID:        primitive.NewObjectID(),
+			TxnID:     txnID,
+			Account:   leg.Account,
+			UserId:    leg.UserId,
+			Direction: leg.Direction,
+			Amount:    leg.Amount,
+			Kind:      txn.Kind,
+			RefID:     txn.RefID,
+			Note:      txn.Note,
+			CreatedBy: txn.CreatedBy,
+			CreatedAt: now,
+		})
+	}
+
+	if _, err := ledgerCollection.InsertMany(ctx, entries); err != nil {
+		return fmt.Errorf("failed to write ledger entries: %w", err)
+	}
+
+	// Keep the cached balance on the user document in step with the ledger
+	for _, leg := range txn.Legs {
+		if leg.UserId.IsZero() {
+			continue
+		}
+
+		filter := bson.M{"_id": leg.UserId}
+		delta := leg.Amount
+		if leg.Direction == models.LedgerDebit {
+			filter["balance"] = bson.M{"$gte": leg.Amount}
+			delta = leg.Amount.Neg()
+		}
+
+		res, err := userCollection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"balance": delta}})
+		if err != nil {
+			return fmt.Errorf("failed to update user balance: %w", err)
+		}
+		if res.MatchedCount == 0 {
+			if leg.Direction == models.LedgerDebit {
+				return ErrInsufficientBalance
+			}
+			return ErrUserNotFound
+		}
+	}
+
+	return nil
+}
+
+// PostTxn posts txn in its own transaction
+func (r *LedgerRepo) PostTxn(txn models.LedgerTxn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return db.WithTransaction(ctx, func(ctx context.Context) error {
+		return r.Post(ctx, txn)
+	})
+}
+
+func validate(txn models.LedgerTxn) error {
+	if len(txn.Legs) < 2 {
+		return fmt.Errorf("%w: needs at least two legs", ErrUnbalanced)
+	}
+
+	var debits, credits money.Amount
+	for _, leg := range txn.Legs {
+		if !leg.Amount.IsPositive() {
+			return fmt.Errorf("ledger amount must be positive, got %v", leg.Amount)
+		}
+		switch leg.Direction {
+		case models.LedgerDebit:
+			debits = debits.Add(leg.Amount)
+		case models.LedgerCredit:
+			credits = credits.Add(leg.Amount)
+		default:
+			return fmt.Errorf("invalid ledger direction %q", leg.Direction)
+		}
+	}
+
+	if debits.Cmp(credits) != 0 {
+		return fmt.Errorf("%w: debits %v, credits %v", ErrUnbalanced, debits, credits)
+	}
+	return nil
+}
+
+// -------------------- READS --------------------
+
+// GetUserEntries returns the user's ledger entries oldest first. Users whose
+// balance predates the ledger get an opening balance entry on first read.
+func (r *LedgerRepo) GetUserEntries(userID primitive.ObjectID) ([]models.LedgerEntry, error) {
+	if err := r.ensureOpeningBalance(userID); err != nil {
+		return nil, err
+	}
+
+	ledgerCollection := db.GetCollection(config.Cfg.DBName, "ledger_entries")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOpts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := ledgerCollection.Find(ctx, bson.M{"account": models.UserAccount(userID)}, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.LedgerEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return []models.LedgerEntry{}, nil
+	}
+	return entries, nil
+}
+
+// RebuildUserBalance recomputes the cached users.balance from the ledger
+func (r *LedgerRepo) RebuildUserBalance(userID primitive.ObjectID) (money.Amount, error) {
+	entries, err := r.GetUserEntries(userID)
+	if err != nil {
+		return money.Zero(), err
+	}
+
+	var balance money.Amount
+	for _, e := range entries {
+		if e.Direction == models.LedgerCredit {
+			balance = balance.Add(e.Amount)
+		} else {
+			balance = balance.Sub(e.Amount)
+		}
+	}
+
+	userCollection := db.GetCollection(config.Cfg.DBName, "users")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := userCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"balance": balance}})
+	if err != nil {
+		return money.Zero(), err
+	}
+	if res.MatchedCount == 0 {
+		return money.Zero(), ErrUserNotFound
+	}
+
+	return balance, nil
+}
+
+// ensureOpeningBalance books the pre-ledger cached balance in its own transaction
+func (r *LedgerRepo) ensureOpeningBalance(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return db.WithTransaction(ctx, func(ctx context.Context) error {
+		return bookOpeningBalance(ctx, userID)
+	})
+}
+
+// bookOpeningBalance writes an opening entry for a user that has a cached
+// balance but no ledger entries yet. The cached balance is left untouched.
+func bookOpeningBalance(ctx context.Context, userID primitive.ObjectID) error {
+	ledgerCollection := db.GetCollection(config.Cfg.DBName, "ledger_entries")
+	userCollection := db.GetCollection(config.Cfg.DBName, "users")
+
+	count, err := ledgerCollection.CountDocuments(ctx, bson.M{"account": models.UserAccount(userID)})
+	if err != nil || count > 0 {
+		return err
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if !user.Balance.IsPositive() {
+		return nil
+	}
+
+	log.Printf("Booking opening ledger balance %v for user %s", user.Balance, userID.Hex())
+
+	txnID := primitive.NewObjectID()
+	now := time.Now()
+	_, err = ledgerCollection.InsertMany(ctx, []interface{}{
+		models.LedgerEntry{
+			ID: primitive.NewObjectID(), TxnID: txnID, Account: models.AccountAdjustments,
+			Direction: models.LedgerDebit, Amount: user.Balance, Kind: models.LedgerKindOpeningBalance, CreatedAt: now,
+		},
+		models.LedgerEntry{
+			ID: primitive.NewObjectID(), TxnID: txnID, Account: models.UserAccount(userID), UserId: userID,
+			Direction: models.LedgerCredit, Amount: user.Balance, Kind: models.LedgerKindOpeningBalance, CreatedAt: now,
+		},
+	})
+	return err
+}
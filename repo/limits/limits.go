@@ -0,0 +1,127 @@
+package limits
+
+import (
+	"context"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type LimitsRepository interface {
+	Usage(kind string, userID primitive.ObjectID, dayStart, monthStart time.Time) (models.LimitUsage, error)
+	LockedUsage(ctx context.Context, kind string, userID primitive.ObjectID, dayStart, monthStart time.Time) (models.LimitUsage, error)
+}
+
+type LimitsRepo struct{}
+
+// collections maps a limit kind to the collection its requests live in
+var collections = map[string]string{
+	models.LimitDeposit:   "deposit",
+	models.LimitWithdrawl: "withdrawl",
+}
+
+// countedStatuses use up allowance; rejected, failed, cancelled and expired
+// requests give it back
+func countedStatuses() []string {
+	return append([]string{models.StatusApproved}, models.OpenStatuses...)
+}
+
+// Usage sums the user's requests since dayStart and monthStart and counts
+// the ones still open
+func (r *LimitsRepo) Usage(kind string, userID primitive.ObjectID, dayStart, monthStart time.Time) (models.LimitUsage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return usage(ctx, kind, userID, dayStart, monthStart)
+}
+
+// LockedUsage is Usage for a check followed by an insert in the transaction
+// in ctx. It first bumps the user's lock document for kind, so two such
+// transactions for the same user conflict and the driver retries the loser
+// against the winner's insert instead of both passing on the same usage.
+func (r *LimitsRepo) LockedUsage(ctx context.Context, kind string, userID primitive.ObjectID, dayStart, monthStart time.Time) (models.LimitUsage, error) {
+	locks := db.GetCollection(config.Cfg.DBName, "limit_locks")
+
+	_, err := locks.UpdateOne(ctx,
+		bson.M{"_id": kind + "|" + userID.Hex()},
+		bson.M{"$inc": bson.M{"seq": 1}, "$set": bson.M{"updated_at": time.Now()}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err != nil {
+		return models.LimitUsage{}, err
+	}
+	return usage(ctx, kind, userID, dayStart, monthStart)
+}
+
+func usage(ctx context.Context, kind string, userID primitive.ObjectID, dayStart, monthStart time.Time) (models.LimitUsage, error) {
+	collection := db.GetCollection(config.Cfg.DBName, collections[kind])
+
+	usage := models.LimitUsage{Today: money.Zero(), ThisMonth: money.Zero()}
+
+	// 1️⃣ Amounts requested this month, and the part of it from today
+	since := monthStart
+	if dayStart.Before(since) {
+		since = dayStart
+	}
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":    userID,
+			"status":     bson.M{"$in": countedStatuses()},
+			"created_at": bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"this_month": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$created_at", monthStart}}, "$amount", 0,
+			}}},
+			"today": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$created_at", dayStart}}, "$amount", 0,
+			}}},
+		}}},
+	})
+	if err != nil {
+		return usage, err
+	}
+	defer cursor.Close(ctx)
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&usage); err != nil {
+			return usage, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return usage, err
+	}
+
+	// 2️⃣ Requests still waiting on an admin
+	pending, err := collection.CountDocuments(ctx, bson.M{
+		"user_id": userID,
+		"status":  bson.M{"$in": models.OpenStatuses},
+	})
+	if err != nil {
+		return usage, err
+	}
+	usage.Pending = int(pending)
+	return usage, nil
+}
+
+// EnsureIndexes backs the per-user usage queries on deposits and withdrawals
+func EnsureIndexes(ctx context.Context) error {
+	for _, name := range []string{"deposit", "withdrawl"} {
+		collection := db.GetCollection(config.Cfg.DBName, name)
+		_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("user_created"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
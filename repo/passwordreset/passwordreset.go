@@ -0,0 +1,163 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type PasswordResetRepository interface {
+	Create(reset models.PasswordReset) (primitive.ObjectID, error)
+	GetActive(email string) (*models.PasswordReset, error)
+	RecordAttempt(resetID primitive.ObjectID) (int, error)
+	AttemptsSince(email string, since time.Time) (int, error)
+	MarkUsed(resetID primitive.ObjectID) error
+}
+
+type PasswordResetRepo struct{}
+
+var (
+	// ErrResetNotFound is returned when the email has no unused, unexpired reset
+	ErrResetNotFound = errors.New("no active password reset")
+	// ErrResetAlreadyUsed is returned when a concurrent confirm consumed the reset
+	ErrResetAlreadyUsed = errors.New("password reset already used")
+)
+
+// Create stores a new reset and retires any earlier unused one for the user,
+// so only the latest code works
+func (r *PasswordResetRepo) Create(reset models.PasswordReset) (primitive.ObjectID, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "password_resets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"user_id": reset.UserId, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": now}},
+	); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	reset.ID = primitive.NewObjectID()
+	if _, err := collection.InsertOne(ctx, reset); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return reset.ID, nil
+}
+
+func (r *PasswordResetRepo) GetActive(email string) (*models.PasswordReset, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "password_resets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"email":      email,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+
+	var reset models.PasswordReset
+	if err := collection.FindOne(ctx, filter, opts).Decode(&reset); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrResetNotFound
+		}
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// RecordAttempt counts one guess before it is checked, so concurrent guesses
+// cannot all slip in under the limit, and returns the new attempt count
+func (r *PasswordResetRepo) RecordAttempt(resetID primitive.ObjectID) (int, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "password_resets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var reset models.PasswordReset
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": resetID},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reset)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, ErrResetNotFound
+		}
+		return 0, err
+	}
+	return reset.Attempts, nil
+}
+
+// AttemptsSince totals the guesses made against every code issued to email
+// since the given time, so requesting new codes doesn't reset the count
+func (r *PasswordResetRepo) AttemptsSince(email string, since time.Time) (int, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "password_resets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"email": email, "created_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "attempts": bson.M{"$sum": "$attempts"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var total struct {
+		Attempts int `bson:"attempts"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&total); err != nil {
+			return 0, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+	return total.Attempts, nil
+}
+
+// MarkUsed consumes the reset; only one caller can win
+func (r *PasswordResetRepo) MarkUsed(resetID primitive.ObjectID) error {
+	collection := db.GetCollection(config.Cfg.DBName, "password_resets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": resetID, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrResetAlreadyUsed
+	}
+	return nil
+}
+
+// EnsureIndexes indexes lookups by email and drops resets a day after expiry
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "password_resets")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("email_created_at")},
+		{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(int32((24 * time.Hour).Seconds()))},
+	})
+	return err
+}
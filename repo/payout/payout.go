@@ -0,0 +1,204 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type PayoutRepository interface {
+	Create(batch models.PayoutBatch) error
+	Finish(batchID primitive.ObjectID, items []models.PayoutItem, total, totalINR money.Amount) error
+	Delete(batchID primitive.ObjectID) error
+	GetByID(batchID primitive.ObjectID) (*models.PayoutBatch, error)
+	List() ([]models.PayoutBatch, error)
+	MarkExported(batchID primitive.ObjectID) error
+	SettleItem(batchID, withdrawlID primitive.ObjectID, status, utr, failureReason string) error
+	RecordImport(batchID primitive.ObjectID, imp models.PayoutImport) error
+}
+
+type PayoutRepo struct{}
+
+// ErrBatchNotFound is returned when no payout batch has the given ID
+var ErrBatchNotFound = errors.New("payout batch not found")
+
+// Create stores a batch in Building while its withdrawals are claimed
+func (r *PayoutRepo) Create(batch models.PayoutBatch) error {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	batch.Status = models.PayoutBatchBuilding
+	if batch.Items == nil {
+		batch.Items = []models.PayoutItem{}
+	}
+	_, err := collection.InsertOne(ctx, batch)
+	return err
+}
+
+// Finish stores the claimed items and opens the batch for export
+func (r *PayoutRepo) Finish(batchID primitive.ObjectID, items []models.PayoutItem, total, totalINR money.Amount) error {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": batchID, "status": models.PayoutBatchBuilding},
+		bson.M{"$set": bson.M{
+			"items":     items,
+			"total":     total,
+			"total_inr": totalINR,
+			"status":    models.PayoutBatchProcessing,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrBatchNotFound
+	}
+	return nil
+}
+
+// Delete removes a batch that ended up with no withdrawals
+func (r *PayoutRepo) Delete(batchID primitive.ObjectID) error {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": batchID, "status": models.PayoutBatchBuilding})
+	return err
+}
+
+func (r *PayoutRepo) GetByID(batchID primitive.ObjectID) (*models.PayoutBatch, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var batch models.PayoutBatch
+	if err := collection.FindOne(ctx, bson.M{"_id": batchID}).Decode(&batch); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// List returns all batches, newest first
+func (r *PayoutRepo) List() ([]models.PayoutBatch, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	batches := []models.PayoutBatch{}
+	if err := cursor.All(ctx, &batches); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// MarkExported records the first time the bank file was downloaded
+func (r *PayoutRepo) MarkExported(batchID primitive.ObjectID) error {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": batchID, "exported_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"exported_at": time.Now()}},
+	)
+	return err
+}
+
+// SettleItem mirrors a withdrawal's final status onto its batch item
+func (r *PayoutRepo) SettleItem(batchID, withdrawlID primitive.ObjectID, status, utr, failureReason string) error {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"items.$.status":     status,
+		"items.$.settled_at": time.Now(),
+	}
+	if utr != "" {
+		set["items.$.utr"] = utr
+	}
+	if failureReason != "" {
+		set["items.$.failure_reason"] = failureReason
+	}
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": batchID, "items.withdrawl_id": withdrawlID},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrBatchNotFound
+	}
+	return nil
+}
+
+// RecordImport logs a response file and completes the batch once no item
+// is left in Processing
+func (r *PayoutRepo) RecordImport(batchID primitive.ObjectID, imp models.PayoutImport) error {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 1️⃣ Keep the import in the batch's audit trail
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": batchID}, bson.M{"$push": bson.M{"imports": imp}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrBatchNotFound
+	}
+
+	// 2️⃣ $ne on an array matches only when no item is still Processing
+	_, err = collection.UpdateOne(ctx,
+		bson.M{
+			"_id":          batchID,
+			"status":       models.PayoutBatchProcessing,
+			"items.status": bson.M{"$ne": models.StatusProcessing},
+		},
+		bson.M{"$set": bson.M{"status": models.PayoutBatchCompleted, "completed_at": time.Now()}},
+	)
+	return err
+}
+
+// EnsureIndexes supports the batch list and lookups by withdrawal
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "payout_batches")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}, Options: options.Index().SetName("created_at")},
+		{Keys: bson.D{{Key: "items.withdrawl_id", Value: 1}}, Options: options.Index().SetName("items_withdrawl_id")},
+	})
+	return err
+}
@@ -0,0 +1,78 @@
+package preauth
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type PreAuthRepository interface {
+	RecordAttempt(tokenID string, userID primitive.ObjectID, expiresAt time.Time) (*models.PreAuthAttempt, error)
+	Complete(tokenID string) error
+}
+
+type PreAuthRepo struct{}
+
+// ErrPreAuthUsed is returned when the token already completed a login
+var ErrPreAuthUsed = errors.New("pre-auth token already used")
+
+// RecordAttempt counts one second-factor try before it is checked, so
+// concurrent guesses cannot all slip in under the limit
+func (r *PreAuthRepo) RecordAttempt(tokenID string, userID primitive.ObjectID, expiresAt time.Time) (*models.PreAuthAttempt, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "preauth_attempts")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attempt models.PreAuthAttempt
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": tokenID},
+		bson.M{
+			"$inc":         bson.M{"attempts": 1},
+			"$setOnInsert": bson.M{"user_id": userID, "expires_at": expiresAt},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Complete consumes the token; only one login can win
+func (r *PreAuthRepo) Complete(tokenID string) error {
+	collection := db.GetCollection(config.Cfg.DBName, "preauth_attempts")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": tokenID, "completed_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"completed_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrPreAuthUsed
+	}
+	return nil
+}
+
+// EnsureIndexes drops attempt records a day after their token expires
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "preauth_attempts")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(int32((24 * time.Hour).Seconds()))},
+	})
+	return err
+}
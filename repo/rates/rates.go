@@ -0,0 +1,103 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type RateQuoteRepository interface {
+	Create(q models.RateQuote) error
+	Redeem(id, userID primitive.ObjectID, kind string) (*models.RateQuote, error)
+	Release(id primitive.ObjectID) error
+}
+
+type RateQuoteRepo struct{}
+
+var (
+	// ErrQuoteNotFound is returned for unknown quotes and quotes of another user or kind
+	ErrQuoteNotFound = errors.New("rate quote not found")
+	// ErrQuoteExpired is returned once the quote's lifetime has passed
+	ErrQuoteExpired = errors.New("rate quote expired, request a new one")
+	// ErrQuoteUsed is returned when the quote already locked another request
+	ErrQuoteUsed = errors.New("rate quote already used")
+)
+
+func (r *RateQuoteRepo) Create(q models.RateQuote) error {
+	collection := db.GetCollection(config.Cfg.DBName, "rate_quotes")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.InsertOne(ctx, q)
+	return err
+}
+
+// Redeem marks the user's live quote used and returns it. Each quote locks
+// at most one request.
+func (r *RateQuoteRepo) Redeem(id, userID primitive.ObjectID, kind string) (*models.RateQuote, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "rate_quotes")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"_id": id, "user_id": userID, "kind": kind}
+
+	var q models.RateQuote
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "user_id": userID, "kind": kind, "used_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&q)
+	if err == nil {
+		return &q, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// Tell the client why so it knows whether a new quote helps
+	if err := collection.FindOne(ctx, filter).Decode(&q); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+	if q.UsedAt != nil {
+		return nil, ErrQuoteUsed
+	}
+	return nil, ErrQuoteExpired
+}
+
+// Release makes a redeemed quote usable again when the request it was
+// redeemed for could not be created
+func (r *RateQuoteRepo) Release(id primitive.ObjectID) error {
+	collection := db.GetCollection(config.Cfg.DBName, "rate_quotes")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"used_at": ""}})
+	return err
+}
+
+// EnsureIndexes expires quotes a day after they lapse; requests keep the
+// rate they locked, so the quote itself is only needed while redeemable
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "rate_quotes")
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(int32((24 * time.Hour).Seconds())).SetName("expires_at_ttl"),
+	})
+	return err
+}
@@ -0,0 +1,159 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SessionRepository interface {
+	Create(session models.Session) (primitive.ObjectID, error)
+	GetByID(sessionID primitive.ObjectID) (*models.Session, error)
+	FindByRefreshHash(hash string) (*models.Session, bool, error)
+	Rotate(sessionID primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) error
+	Revoke(sessionID primitive.ObjectID, reason string) error
+	RevokeAllForUser(userID primitive.ObjectID, reason string) (int64, error)
+}
+
+type SessionRepo struct{}
+
+var (
+	// ErrSessionNotFound is returned when no session matches
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionRotated is returned when another request rotated the token first
+	ErrSessionRotated = errors.New("refresh token already rotated")
+)
+
+func (r *SessionRepo) Create(session models.Session) (primitive.ObjectID, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "sessions")
+
+	session.ID = primitive.NewObjectID()
+	if session.PreviousHashes == nil {
+		session.PreviousHashes = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := collection.InsertOne(ctx, session); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return session.ID, nil
+}
+
+func (r *SessionRepo) GetByID(sessionID primitive.ObjectID) (*models.Session, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "sessions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var session models.Session
+	if err := collection.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindByRefreshHash looks up the session owning a refresh token hash. The
+// bool is true when the hash belongs to an already rotated token, which means
+// the token was replayed.
+func (r *SessionRepo) FindByRefreshHash(hash string) (*models.Session, bool, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "sessions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var session models.Session
+	err := collection.FindOne(ctx, bson.M{"$or": []bson.M{
+		{"refresh_hash": hash},
+		{"previous_hashes": hash},
+	}}).Decode(&session)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, false, ErrSessionNotFound
+		}
+		return nil, false, err
+	}
+
+	return &session, session.RefreshHash != hash, nil
+}
+
+// Rotate swaps the refresh hash only if oldHash is still current
+func (r *SessionRepo) Rotate(sessionID primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) error {
+	collection := db.GetCollection(config.Cfg.DBName, "sessions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": sessionID, "refresh_hash": oldHash, "revoked_at": bson.M{"$exists": false}},
+		bson.M{
+			"$set": bson.M{
+				"refresh_hash": newHash,
+				"last_used_at": time.Now(),
+				"expires_at":   expiresAt,
+			},
+			"$push": bson.M{"previous_hashes": oldHash},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrSessionRotated
+	}
+	return nil
+}
+
+func (r *SessionRepo) Revoke(sessionID primitive.ObjectID, reason string) error {
+	collection := db.GetCollection(config.Cfg.DBName, "sessions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": sessionID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoke_reason": reason}},
+	)
+	return err
+}
+
+func (r *SessionRepo) RevokeAllForUser(userID primitive.ObjectID, reason string) (int64, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "sessions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoke_reason": reason}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// EnsureIndexes creates lookup indexes and expires sessions past their refresh lifetime
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "sessions")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"refresh_hash": 1}, Options: options.Index().SetName("refresh_hash")},
+		{Keys: bson.M{"previous_hashes": 1}, Options: options.Index().SetName("previous_hashes")},
+		{Keys: bson.M{"user_id": 1}, Options: options.Index().SetName("user_id")},
+		{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0)},
+	})
+	return err
+}
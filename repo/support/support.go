@@ -0,0 +1,166 @@
+package support
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SupportRepository interface {
+	Touch(userID primitive.ObjectID, at time.Time, waiting bool) (*models.SupportConversation, error)
+	Get(userID primitive.ObjectID) (*models.SupportConversation, error)
+	Inbox(assigneeID *primitive.ObjectID, unassigned bool) ([]models.SupportConversation, error)
+	Assign(userID primitive.ObjectID, expect, to *primitive.ObjectID, entry models.SupportAssignment) (*models.SupportConversation, error)
+}
+
+type SupportRepo struct{}
+
+var (
+	ErrConversationNotFound = errors.New("support conversation not found")
+	// ErrAssignmentChanged is returned when someone else claimed or moved the conversation first
+	ErrAssignmentChanged = errors.New("conversation was assigned by someone else, reload and retry")
+)
+
+// Touch records a message in the user's support conversation, creating it
+// on the first one. waiting starts the wait clock if it is not running;
+// otherwise support just answered and the clock stops.
+func (r *SupportRepo) Touch(userID primitive.ObjectID, at time.Time, waiting bool) (*models.SupportConversation, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "support_conversations")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 1️⃣ Upsert, stopping the clock when support answered
+	update := bson.M{
+		"$set":         bson.M{"last_message_at": at},
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": at},
+	}
+	if !waiting {
+		update["$unset"] = bson.M{"waiting_since": ""}
+	}
+	var conv models.SupportConversation
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"user_id": userID},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&conv)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2️⃣ Only the first unanswered message starts the clock
+	if waiting && conv.WaitingSince == nil {
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"user_id": userID, "waiting_since": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"waiting_since": at}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		conv.WaitingSince = &at
+	}
+	return &conv, nil
+}
+
+func (r *SupportRepo) Get(userID primitive.ObjectID) (*models.SupportConversation, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "support_conversations")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var conv models.SupportConversation
+	if err := collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&conv); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrConversationNotFound
+		}
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// Inbox lists conversations for one assignee, most recent first, or the
+// unassigned ones still waiting, longest wait first
+func (r *SupportRepo) Inbox(assigneeID *primitive.ObjectID, unassigned bool) ([]models.SupportConversation, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "support_conversations")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	opts := options.Find().SetSort(bson.M{"last_message_at": -1})
+	switch {
+	case unassigned:
+		filter = bson.M{"assignee_id": bson.M{"$exists": false}, "waiting_since": bson.M{"$exists": true}}
+		opts = options.Find().SetSort(bson.M{"waiting_since": 1})
+	case assigneeID != nil:
+		filter = bson.M{"assignee_id": *assigneeID}
+	}
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.SupportConversation{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Assign moves the conversation from expect to to (nil meaning unassigned)
+// and records entry. It fails with ErrAssignmentChanged when the assignee is
+// no longer expect. Claiming a conversation that does not exist yet creates it.
+func (r *SupportRepo) Assign(userID primitive.ObjectID, expect, to *primitive.ObjectID, entry models.SupportAssignment) (*models.SupportConversation, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "support_conversations")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "assignee_id": bson.M{"$exists": false}}
+	if expect != nil {
+		filter["assignee_id"] = *expect
+	}
+	update := bson.M{"$push": bson.M{"assignments": entry}}
+	if to != nil {
+		update["$set"] = bson.M{"assignee_id": *to}
+	} else {
+		update["$unset"] = bson.M{"assignee_id": ""}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if expect == nil {
+		update["$setOnInsert"] = bson.M{"_id": primitive.NewObjectID(), "created_at": entry.At, "last_message_at": entry.At}
+		opts.SetUpsert(true)
+	}
+
+	var conv models.SupportConversation
+	if err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&conv); err != nil {
+		// The upsert collides with the unique user_id when someone holds it
+		if errors.Is(err, mongo.ErrNoDocuments) || mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAssignmentChanged
+		}
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// EnsureIndexes keeps one conversation per user and backs both inbox views
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "support_conversations")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"user_id": 1}, Options: options.Index().SetUnique(true).SetName("user_id_unique")},
+		{Keys: bson.D{{Key: "assignee_id", Value: 1}, {Key: "waiting_since", Value: 1}}, Options: options.Index().SetName("assignee_waiting")},
+		{Keys: bson.D{{Key: "assignee_id", Value: 1}, {Key: "last_message_at", Value: -1}}, Options: options.Index().SetName("assignee_last_message")},
+	})
+	return err
+}
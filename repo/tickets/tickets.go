@@ -0,0 +1,312 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type TicketRepository interface {
+	Create(t models.Ticket, first models.TicketMessage) (*models.Ticket, error)
+	GetByID(id primitive.ObjectID) (*models.Ticket, error)
+	ListByUser(userID primitive.ObjectID) ([]models.Ticket, error)
+	Queue(q QueueFilter) ([]models.Ticket, error)
+	Messages(ticketID primitive.ObjectID) ([]models.TicketMessage, error)
+	AddMessage(msg models.TicketMessage) (*models.TicketMessage, error)
+	Update(id primitive.ObjectID, expectStatus string, p Patch) (*models.Ticket, error)
+	ActiveForRefs(kind string, refIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.TicketSummary, error)
+}
+
+type TicketRepo struct{}
+
+var (
+	ErrTicketNotFound = errors.New("ticket not found")
+	// ErrTicketExists is returned when the request already has an active ticket
+	ErrTicketExists = errors.New("this request already has an open ticket")
+	// ErrTicketChanged is returned when the ticket moved on since it was read
+	ErrTicketChanged = errors.New("ticket was changed by someone else, reload and retry")
+)
+
+// QueueFilter narrows the admin queue; empty fields match everything
+type QueueFilter struct {
+	Statuses   []string
+	Priority   string
+	AssigneeID *primitive.ObjectID
+	Unassigned bool
+}
+
+// Patch lists what an update changes; nil fields are left alone
+type Patch struct {
+	Status           *string
+	Priority         *string
+	AssigneeID       *primitive.ObjectID
+	Unassign         bool
+	FirstResponseDue *time.Time
+	ResolveDue       *time.Time
+	FirstResponseAt  *time.Time
+	ResolvedAt       *time.Time
+	ClearResolvedAt  bool
+	History          []models.StatusChange
+}
+
+// Create stores a ticket and its first message together
+func (r *TicketRepo) Create(t models.Ticket, first models.TicketMessage) (*models.Ticket, error) {
+	ticketCollection := db.GetCollection(config.Cfg.DBName, "tickets")
+	messageCollection := db.GetCollection(config.Cfg.DBName, "ticket_messages")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.ID = primitive.NewObjectID()
+	first.ID = primitive.NewObjectID()
+	first.TicketID = t.ID
+	first.CreatedAt = t.CreatedAt
+
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		// 1️⃣ One active ticket per request
+		count, err := ticketCollection.CountDocuments(ctx, bson.M{
+			"ref_kind": t.RefKind,
+			"ref_id":   t.RefID,
+			"status":   bson.M{"$in": models.TicketActiveStatuses},
+		})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTicketExists
+		}
+
+		// 2️⃣ Ticket and opening message
+		if _, err := ticketCollection.InsertOne(ctx, t); err != nil {
+			return err
+		}
+		_, err = messageCollection.InsertOne(ctx, first)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TicketRepo) GetByID(id primitive.ObjectID) (*models.Ticket, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "tickets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var t models.Ticket
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&t); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListByUser returns the user's tickets, newest first
+func (r *TicketRepo) ListByUser(userID primitive.ObjectID) ([]models.Ticket, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "tickets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.Ticket{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Queue returns tickets for the admin queue by resolve deadline, earliest first
+func (r *TicketRepo) Queue(q QueueFilter) ([]models.Ticket, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "tickets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if len(q.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": q.Statuses}
+	}
+	if q.Priority != "" {
+		filter["priority"] = q.Priority
+	}
+	if q.AssigneeID != nil {
+		filter["assignee_id"] = *q.AssigneeID
+	} else if q.Unassigned {
+		filter["assignee_id"] = bson.M{"$exists": false}
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"resolve_due": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.Ticket{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Messages returns a ticket's thread, oldest first
+func (r *TicketRepo) Messages(ticketID primitive.ObjectID) ([]models.TicketMessage, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "ticket_messages")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{"ticket_id": ticketID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.TicketMessage{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *TicketRepo) AddMessage(msg models.TicketMessage) (*models.TicketMessage, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "ticket_messages")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg.ID = primitive.NewObjectID()
+	msg.CreatedAt = time.Now()
+	if _, err := collection.InsertOne(ctx, msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Update applies p if the ticket is still in expectStatus and returns the
+// ticket as it is afterwards
+func (r *TicketRepo) Update(id primitive.ObjectID, expectStatus string, p Patch) (*models.Ticket, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "tickets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"updated_at": time.Now()}
+	unset := bson.M{}
+	if p.Status != nil {
+		set["status"] = *p.Status
+	}
+	if p.Priority != nil {
+		set["priority"] = *p.Priority
+	}
+	if p.AssigneeID != nil {
+		set["assignee_id"] = *p.AssigneeID
+	} else if p.Unassign {
+		unset["assignee_id"] = ""
+	}
+	if p.FirstResponseDue != nil {
+		set["first_response_due"] = *p.FirstResponseDue
+	}
+	if p.ResolveDue != nil {
+		set["resolve_due"] = *p.ResolveDue
+	}
+	if p.FirstResponseAt != nil {
+		set["first_response_at"] = *p.FirstResponseAt
+	}
+	if p.ResolvedAt != nil {
+		set["resolved_at"] = *p.ResolvedAt
+	} else if p.ClearResolvedAt {
+		unset["resolved_at"] = ""
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(p.History) > 0 {
+		update["$push"] = bson.M{"status_history": bson.M{"$each": p.History}}
+	}
+
+	var t models.Ticket
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": expectStatus},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&t)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTicketChanged
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ActiveForRefs returns the Open and Pending tickets of each referenced request
+func (r *TicketRepo) ActiveForRefs(kind string, refIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.TicketSummary, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "tickets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	byRef := make(map[primitive.ObjectID][]models.TicketSummary)
+	if len(refIDs) == 0 {
+		return byRef, nil
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"ref_kind": kind,
+		"ref_id":   bson.M{"$in": refIDs},
+		"status":   bson.M{"$in": models.TicketActiveStatuses},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []models.TicketSummary
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	for _, t := range list {
+		byRef[t.RefID] = append(byRef[t.RefID], t)
+	}
+	return byRef, nil
+}
+
+// EnsureIndexes backs the user list, the per-request lookup, the queue and threads
+func EnsureIndexes(ctx context.Context) error {
+	tickets := db.GetCollection(config.Cfg.DBName, "tickets")
+	_, err := tickets.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("user_created")},
+		{Keys: bson.D{{Key: "ref_kind", Value: 1}, {Key: "ref_id", Value: 1}, {Key: "status", Value: 1}}, Options: options.Index().SetName("ref_status")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "resolve_due", Value: 1}}, Options: options.Index().SetName("status_resolve_due")},
+	})
+	if err != nil {
+		return err
+	}
+
+	messages := db.GetCollection(config.Cfg.DBName, "ticket_messages")
+	_, err = messages.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "ticket_id", Value: 1}, {Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("ticket_created"),
+	})
+	return err
+}
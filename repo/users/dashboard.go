@@ -6,11 +6,12 @@ import (
 	"p2p/config"
 	"p2p/config/db"
 	"p2p/models"
+	"p2p/utils/money"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 type DashboardRepository interface {
@@ -33,7 +34,7 @@ func (r *DashboardRepo) GetUserDashboard(userID primitive.ObjectID) (*models.Use
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			// ✅ No user found → return empty dashboard
 			return &models.UserDash{
-				Balance:            0,
+				Balance:            money.Zero(),
 				PendingWithdrawals: 0,
 			}, nil
 		}
@@ -43,7 +44,7 @@ func (r *DashboardRepo) GetUserDashboard(userID primitive.ObjectID) (*models.Use
 	// 2️⃣ Count pending withdrawals (safe even if no docs)
 	withdrawlCount, err := withdrawlCollection.CountDocuments(ctx, bson.M{
 		"user_id": userID,
-		"status":  "Pending",
+		"status":  bson.M{"$in": models.OpenStatuses},
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,53 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUserNotFound is returned when no user has the given ID
+var ErrUserNotFound = errors.New("user not found")
+
+// SetLimits puts the user on tier and stores override; empty values are
+// removed so the user falls back to the tier or global limits
+func (r *UserRepo) SetLimits(userID primitive.ObjectID, tier string, override *models.LimitSet) error {
+	collection := db.GetCollection(config.Cfg.DBName, "users")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set, unset := bson.M{}, bson.M{}
+	if tier != "" {
+		set["limit_tier"] = tier
+	} else {
+		unset["limit_tier"] = ""
+	}
+	if override != nil {
+		set["limit_override"] = override
+	} else {
+		unset["limit_override"] = ""
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": userID, "role": "user"}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
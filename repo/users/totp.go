@@ -0,0 +1,111 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrTOTPReplay is returned when a code for this or an earlier step was already used
+	ErrTOTPReplay = errors.New("totp code already used")
+	// ErrRecoveryCodeInvalid is returned when the recovery code is unknown or spent
+	ErrRecoveryCodeInvalid = errors.New("invalid recovery code")
+)
+
+func (r *UserRepo) SetPendingTOTP(userID primitive.ObjectID, secret string) error {
+	return r.updateTOTP(userID, bson.M{"$set": bson.M{"totp_pending_secret": secret}})
+}
+
+// EnableTOTP promotes the pending secret and stores fresh recovery code hashes
+func (r *UserRepo) EnableTOTP(userID primitive.ObjectID, secret string, recoveryHashes []string) error {
+	return r.updateTOTP(userID, bson.M{
+		"$set": bson.M{
+			"totp_enabled":        true,
+			"totp_secret":         secret,
+			"totp_recovery_codes": recoveryHashes,
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	})
+}
+
+func (r *UserRepo) DisableTOTP(userID primitive.ObjectID) error {
+	return r.updateTOTP(userID, bson.M{
+		"$set": bson.M{"totp_enabled": false},
+		"$unset": bson.M{
+			"totp_secret":         "",
+			"totp_pending_secret": "",
+			"totp_recovery_codes": "",
+			"totp_last_step":      "",
+		},
+	})
+}
+
+func (r *UserRepo) SetRecoveryCodes(userID primitive.ObjectID, recoveryHashes []string) error {
+	return r.updateTOTP(userID, bson.M{"$set": bson.M{"totp_recovery_codes": recoveryHashes}})
+}
+
+// ClaimTOTPStep records step as used, failing if it or a later one already was
+func (r *UserRepo) ClaimTOTPStep(userID primitive.ObjectID, step int64) error {
+	collection := db.GetCollection(config.Cfg.DBName, "users")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id": userID,
+		"$or": []bson.M{
+			{"totp_last_step": bson.M{"$exists": false}},
+			{"totp_last_step": bson.M{"$lt": step}},
+		},
+	}
+	res, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrTOTPReplay
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode removes the code so it works only once
+func (r *UserRepo) ConsumeRecoveryCode(userID primitive.ObjectID, codeHash string) error {
+	collection := db.GetCollection(config.Cfg.DBName, "users")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": userID, "totp_recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"totp_recovery_codes": codeHash}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+func (r *UserRepo) updateTOTP(userID primitive.ObjectID, update bson.M) error {
+	collection := db.GetCollection(config.Cfg.DBName, "users")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 type UserRepository interface {
@@ -21,8 +21,17 @@ type UserRepository interface {
 	CheckPhoneExists(phone string) (bool, error)
 	CheckEmailExists(email string) (bool, error)
 	GetUserByEmail(email string) (models.User, error)
+	GetUserByID(userID primitive.ObjectID) (models.User, error)
+	GetUsersByIDs(userIDs []primitive.ObjectID) (map[primitive.ObjectID]models.User, error)
+	SetPendingTOTP(userID primitive.ObjectID, secret string) error
+	EnableTOTP(userID primitive.ObjectID, secret string, recoveryHashes []string) error
+	DisableTOTP(userID primitive.ObjectID) error
+	SetRecoveryCodes(userID primitive.ObjectID, recoveryHashes []string) error
+	ClaimTOTPStep(userID primitive.ObjectID, step int64) error
+	ConsumeRecoveryCode(userID primitive.ObjectID, codeHash string) error
 	GetAllUsers() ([]models.User, error)
 	UpdatePassword(email, newPassword string) error
+	SetLimits(userID primitive.ObjectID, tier string, override *models.LimitSet) error
 }
 
 type UserRepo struct{}
@@ -126,6 +135,52 @@ func (r *UserRepo) GetUserByEmail(email string) (models.User, error) {
 	return user, nil
 }
 
+func (r *UserRepo) GetUserByID(userID primitive.ObjectID) (models.User, error) {
+	var user models.User
+	collection := db.GetCollection(config.Cfg.DBName, "users")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.User{}, errors.New("user not found")
+		}
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// GetUsersByIDs loads many users in one query; missing IDs are left out
+func (r *UserRepo) GetUsersByIDs(userIDs []primitive.ObjectID) (map[primitive.ObjectID]models.User, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "users")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	byID := make(map[primitive.ObjectID]models.User, len(userIDs))
+	if len(userIDs) == 0 {
+		return byID, nil
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	return byID, nil
+}
+
 func (r *UserRepo) GetAllUsers() ([]models.User, error) {
 	collection := db.GetCollection(config.Cfg.DBName, "users")
 
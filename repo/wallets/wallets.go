@@ -0,0 +1,141 @@
+package wallets
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type WalletRepository interface {
+	Create(w models.DepositWallet) (*models.DepositWallet, error)
+	ListByUser(userID primitive.ObjectID, network string) ([]models.DepositWallet, error)
+	GetByID(id, userID primitive.ObjectID) (*models.DepositWallet, error)
+	MarkVerified(id, userID primitive.ObjectID, txHash string) (*models.DepositWallet, error)
+	Delete(id, userID primitive.ObjectID) error
+}
+
+type WalletRepo struct{}
+
+var (
+	ErrWalletNotFound = errors.New("wallet not found")
+	// ErrWalletTaken is returned when the address is registered already, to this or another user
+	ErrWalletTaken = errors.New("this wallet address is already registered")
+)
+
+func (r *WalletRepo) Create(w models.DepositWallet) (*models.DepositWallet, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit_wallets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w.ID = primitive.NewObjectID()
+	w.CreatedAt = time.Now()
+	if _, err := collection.InsertOne(ctx, w); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrWalletTaken
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListByUser returns the user's wallets, newest first; an empty network means all
+func (r *WalletRepo) ListByUser(userID primitive.ObjectID, network string) ([]models.DepositWallet, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit_wallets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	if network != "" {
+		filter["network"] = network
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.DepositWallet{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetByID returns one of the user's wallets
+func (r *WalletRepo) GetByID(id, userID primitive.ObjectID) (*models.DepositWallet, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit_wallets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var w models.DepositWallet
+	if err := collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&w); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+// MarkVerified records the proof transfer; a wallet is verified once and
+// keeps its first proof
+func (r *WalletRepo) MarkVerified(id, userID primitive.ObjectID, txHash string) (*models.DepositWallet, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit_wallets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var w models.DepositWallet
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "user_id": userID, "verified_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"verified_at": time.Now(), "proof_tx_hash": txHash}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&w)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Verified concurrently, or deleted
+		return r.GetByID(id, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Delete removes one of the user's wallets
+func (r *WalletRepo) Delete(id, userID primitive.ObjectID) error {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit_wallets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrWalletNotFound
+	}
+	return nil
+}
+
+// EnsureIndexes gives every address a single owner and backs per-user listing
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "deposit_wallets")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "network", Value: 1}, {Key: "address", Value: 1}}, Options: options.Index().SetUnique(true).SetName("network_address_unique")},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("user_created")},
+	})
+	return err
+}
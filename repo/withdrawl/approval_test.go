@@ -0,0 +1,115 @@
+package withdrawl
+
+import (
+	"errors"
+	"p2p/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFirstApprovalKeepsMaker(t *testing.T) {
+	maker := primitive.NewObjectID()
+	other := models.Actor{ID: primitive.NewObjectID(), Role: "admin"}
+
+	filter, set := bson.M{}, bson.M{}
+	firstApproval(models.WithdrawlRequest{FirstApproverID: &maker}, other, filter, set, time.Now())
+	if _, ok := set["first_approver_id"]; ok {
+		t.Errorf("first approver overwritten: %v", set)
+	}
+
+	filter, set = bson.M{}, bson.M{}
+	firstApproval(models.WithdrawlRequest{}, other, filter, set, time.Now())
+	if set["first_approver_id"] != other.ID {
+		t.Errorf("first approver not recorded: %v", set)
+	}
+}
+
+func TestSecondApprovalNeedsAnotherAdmin(t *testing.T) {
+	maker := primitive.NewObjectID()
+	parked := models.WithdrawlRequest{Status: models.WithdrawlAwaitingSecondApproval, FirstApproverID: &maker}
+
+	cases := []struct {
+		name  string
+		cur   models.WithdrawlRequest
+		actor primitive.ObjectID
+		want  error
+	}{
+		{"checker", parked, primitive.NewObjectID(), nil},
+		{"maker again", parked, maker, ErrSameApprover},
+		{"no maker", models.WithdrawlRequest{Status: models.WithdrawlAwaitingSecondApproval}, primitive.NewObjectID(), ErrFirstApprovalRequired},
+	}
+	for _, tc := range cases {
+		filter, set := bson.M{}, bson.M{}
+		err := secondApproval(tc.cur, models.Actor{ID: tc.actor, Role: "admin"}, filter, set, time.Now())
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+			continue
+		}
+		if err == nil && (set["second_approver_id"] != tc.actor || filter["first_approver_id"] != maker) {
+			t.Errorf("%s: set %v filter %v", tc.name, set, filter)
+		}
+	}
+}
+
+func TestOnlyCheckerLeavesAwaitingSecondApproval(t *testing.T) {
+	maker := primitive.NewObjectID()
+	parked := models.WithdrawlRequest{Status: models.WithdrawlAwaitingSecondApproval, FirstApproverID: &maker}
+	checker := models.Actor{ID: primitive.NewObjectID(), Role: "admin"}
+
+	cases := []struct {
+		name   string
+		status string
+		actor  models.Actor
+		want   error
+	}{
+		{"maker to processing", models.StatusProcessing, models.Actor{ID: maker, Role: "admin"}, ErrSameApprover},
+		{"maker approves", models.StatusApproved, models.Actor{ID: maker, Role: "admin"}, ErrSameApprover},
+		{"checker to processing", models.StatusProcessing, checker, nil},
+		{"maker rejects", models.StatusRejected, models.Actor{ID: maker, Role: "admin"}, nil},
+	}
+	for _, tc := range cases {
+		set := bson.M{}
+		got, err := manualApproval(parked, tc.status, tc.actor, true, bson.M{}, set, time.Now())
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+			continue
+		}
+		if err == nil && got != tc.status {
+			t.Errorf("%s: status %q", tc.name, got)
+		}
+		if tc.status == models.StatusProcessing && err == nil && set["second_approver_id"] != checker.ID {
+			t.Errorf("%s: checker not recorded: %v", tc.name, set)
+		}
+	}
+
+	got, err := manualApproval(models.WithdrawlRequest{Status: models.StatusUnderReview}, models.StatusApproved, checker, true, bson.M{}, bson.M{}, time.Now())
+	if err != nil || got != models.WithdrawlAwaitingSecondApproval {
+		t.Errorf("large approval: got %q, %v", got, err)
+	}
+}
+
+func TestBatchApprovalErrors(t *testing.T) {
+	maker := primitive.NewObjectID()
+	admin := models.Actor{ID: primitive.NewObjectID(), Role: "admin"}
+
+	cases := []struct {
+		name string
+		cur  models.WithdrawlRequest
+		dual bool
+		want error
+	}{
+		{"small", models.WithdrawlRequest{Status: models.StatusUnderReview}, false, nil},
+		{"large without maker", models.WithdrawlRequest{Status: models.StatusUnderReview}, true, ErrFirstApprovalRequired},
+		{"large with maker not parked", models.WithdrawlRequest{Status: models.StatusProcessing, FirstApproverID: &maker}, true, ErrSecondApprovalRequired},
+		{"parked", models.WithdrawlRequest{Status: models.WithdrawlAwaitingSecondApproval, FirstApproverID: &maker}, true, nil},
+	}
+	for _, tc := range cases {
+		err := batchApproval(tc.cur, admin, tc.dual, bson.M{}, bson.M{}, time.Now())
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package withdrawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"p2p/utils/money"
+	"p2p/utils/statemachine"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	// ErrAlreadyInBatch is returned when the withdrawal is already part of a payout batch
+	ErrAlreadyInBatch = errors.New("withdrawal is already in a payout batch")
+	// ErrNotBatchable is returned for withdrawals that are not one step from Processing
+	ErrNotBatchable = errors.New("withdrawal can't be added to a payout batch in its current status")
+	// ErrFirstApprovalRequired is returned when a large withdrawal is batched before its first approval
+	ErrFirstApprovalRequired = errors.New("withdrawal needs a first approval before it can be batched")
+	// ErrSecondApprovalRequired is returned when a large withdrawal is batched after its first
+	// approval but before it was parked for a second admin
+	ErrSecondApprovalRequired = errors.New("withdrawal needs a second approval before it can be batched")
+)
+
+// ClaimForPayout moves a withdrawal to Processing as part of batchID and
+// returns it. Adding a withdrawal to a batch approves it for payment, so the
+// maker-checker rule applies here: at or above dualThreshold the batching
+// admin is the checker and must differ from the first approver.
+func (r *WithdrawlRepo) ClaimForPayout(withdrawID string, batchID primitive.ObjectID, actor models.Actor, dualThreshold money.Amount) (*models.WithdrawlRequest, error) {
+	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(withdrawID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid withdraw ID: %w", err)
+	}
+
+	// 1️⃣ Read the current state; the conditional update re-checks it
+	var cur models.WithdrawlRequest
+	if err := withdrawCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&cur); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWithdrawlNotFound
+		}
+		return nil, err
+	}
+	if cur.PayoutBatchID != nil {
+		return nil, ErrAlreadyInBatch
+	}
+	if cur.Status != models.StatusProcessing && !statemachine.Withdrawl.Can(cur.Status, models.StatusProcessing) {
+		return nil, ErrNotBatchable
+	}
+
+	now := time.Now()
+	filter := bson.M{"_id": oid, "status": cur.Status, "payout_batch_id": bson.M{"$exists": false}}
+	set := bson.M{"status": models.StatusProcessing, "payout_batch_id": batchID}
+
+	// 2️⃣ Record the approval the batch stands for
+	dual := dualThreshold.IsPositive() && !cur.Amount.LessThan(dualThreshold)
+	if err := batchApproval(cur, actor, dual, filter, set, now); err != nil {
+		return nil, err
+	}
+
+	// A withdrawal already in Processing only joins the batch
+	var change *models.StatusChange
+	if cur.Status != models.StatusProcessing {
+		step, err := statemachine.Withdrawl.Step(cur.Status, models.StatusProcessing, actor, "payout batch "+batchID.Hex(), now)
+		if err != nil {
+			return nil, err
+		}
+		change = &step
+	}
+
+	// 3️⃣ Claim it; a concurrent batch or status change makes this miss
+	return r.commitTransition(ctx, filter, set, change, models.StatusProcessing, actor)
+}
+
+// batchApproval records the approval that batching cur stands for. A parked
+// withdrawal takes its second approval here; a large one not yet parked has
+// to go through AwaitingSecondApproval first.
+func batchApproval(cur models.WithdrawlRequest, actor models.Actor, dual bool, filter, set bson.M, now time.Time) error {
+	switch {
+	case cur.Status == models.WithdrawlAwaitingSecondApproval:
+		return secondApproval(cur, actor, filter, set, now)
+	case dual && cur.FirstApproverID != nil:
+		return ErrSecondApprovalRequired
+	case dual:
+		return ErrFirstApprovalRequired
+	}
+	firstApproval(cur, actor, filter, set, now)
+	return nil
+}
+
+// SettlePayout finalizes a batched withdrawal as Approved (with the bank's
+// UTR) or Failed, releasing the ledger hold in the same transaction
+func (r *WithdrawlRepo) SettlePayout(withdrawID, batchID primitive.ObjectID, status, utr string, actor models.Actor, reason string, rejection *models.Rejection) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	change, err := statemachine.Withdrawl.Step(models.StatusProcessing, status, actor, reason, now)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": withdrawID, "status": models.StatusProcessing, "payout_batch_id": batchID}
+	set := bson.M{"status": status}
+	if rejection != nil {
+		set["rejection"] = rejection
+	}
+	if status == models.StatusApproved {
+		if err := r.checkUTR(ctx, utr, withdrawID); err != nil {
+			return err
+		}
+		set["utr"] = utr
+		set["approved_at"] = now
+	}
+
+	_, err = r.commitTransition(ctx, filter, set, &change, status, actor)
+	return err
+}
@@ -8,6 +8,9 @@ import (
 	"p2p/config"
 	"p2p/config/db"
 	"p2p/models"
+	"p2p/repo/ledger"
+	"p2p/utils/money"
+	"p2p/utils/statemachine"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,124 +20,373 @@ import (
 )
 
 type WithdrawlRepository interface {
-	WithdrawlRequest(req models.WithdrawlRequest) error
+	WithdrawlRequest(req models.WithdrawlRequest, check func(ctx context.Context) error) error
 	GetAll() ([]models.WithdrawlRes, error)
 	GetByID(id string) (*models.WithdrawlRes, error)
 	SearchByUsername(username string) ([]models.WithdrawlRes, error)
 	GetAllByUserID(userID string) ([]models.WithdrawlRes, error)
-	UpdateWithdrawStatus(withdrawID string, approve bool, utr string) error
+	UpdateWithdrawStatus(withdrawID, status, utr string, actor models.Actor, reason string, rejection *models.Rejection, dualThreshold money.Amount) (string, error)
+	AddAdminNote(withdrawID string, note models.AdminNote) error
+	GetAllByStatus(status string) ([]models.WithdrawlRes, error)
+	CancelWithdrawl(withdrawID string, actor models.Actor, reason string) error
+	ClaimForPayout(withdrawID string, batchID primitive.ObjectID, actor models.Actor, dualThreshold money.Amount) (*models.WithdrawlRequest, error)
+	SettlePayout(withdrawID, batchID primitive.ObjectID, status, utr string, actor models.Actor, reason string, rejection *models.Rejection) error
 }
 
 type WithdrawlRepo struct{}
 
+var (
+	// ErrInsufficientBalance is returned when the user balance does not cover a withdrawal
+	ErrInsufficientBalance = errors.New("insufficient balance for withdrawal")
+	// ErrInvalidAmount is returned for zero or negative withdrawal amounts
+	ErrInvalidAmount = errors.New("withdrawal amount must be greater than zero")
+	// ErrWithdrawlNotPending is returned when another request changed the withdrawal status first
+	ErrWithdrawlNotPending = errors.New("withdraw status changed, reload and retry")
+	// ErrWithdrawlNotFound is returned when no withdrawal has the given ID
+	ErrWithdrawlNotFound = errors.New("withdraw not found")
+	// ErrWithdrawlNotCancellable is returned when the withdrawal has left Pending
+	ErrWithdrawlNotCancellable = errors.New("only pending withdrawals can be cancelled")
+	// ErrDuplicateUTR is returned when the UTR is already attached to another withdrawal
+	ErrDuplicateUTR = errors.New("UTR already used by another withdrawal")
+	// ErrSameApprover is returned when the first approver tries to give the second approval too
+	ErrSameApprover = errors.New("second approval must come from a different admin")
+)
+
 // -------------------- CREATE WITHDRAWL --------------------
-func (r *WithdrawlRepo) WithdrawlRequest(req models.WithdrawlRequest) error {
+
+// WithdrawlRequest holds the funds and inserts the withdrawal after check
+// passes, all in one transaction
+func (r *WithdrawlRepo) WithdrawlRequest(req models.WithdrawlRequest, check func(ctx context.Context) error) error {
 	collection := db.GetCollection(config.Cfg.DBName, "withdrawl")
 
 	req.ID = primitive.NewObjectID()
 	req.CreatedAt = time.Now()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if !req.Amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	userCollection := db.GetCollection(config.Cfg.DBName, "users")
+	return db.WithTransaction(ctx, func(ctx context.Context) error {
+		// 1️⃣ Limits and anything else the caller must see atomically
+		if err := check(ctx); err != nil {
+			return err
+		}
 
-	// 3️⃣ Get user balance
-	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"_id": req.UserId}).Decode(&user)
+		// 2️⃣ Hold the amount and fee through the ledger; fails if the balance does not cover them
+		ledgerRepo := ledger.LedgerRepository(&ledger.LedgerRepo{})
+		if err := ledgerRepo.Post(ctx, ledger.WithdrawalHold(req.UserId, req.ID, req.Amount, req.Fee)); err != nil {
+			if errors.Is(err, ledger.ErrInsufficientBalance) {
+				return ErrInsufficientBalance
+			}
+			return fmt.Errorf("failed to hold withdrawal amount: %w", err)
+		}
+
+		// 3️⃣ Record the withdrawal in the same transaction
+		if _, err := collection.InsertOne(ctx, req); err != nil {
+			log.Println(err)
+			return err
+		}
+		return nil
+	})
+}
+
+// UpdateWithdrawStatus moves a withdrawal one step along the withdrawl
+// state machine and returns the status it ended in. At or above
+// dualThreshold the first request for Approved only parks the withdrawal in
+// AwaitingSecondApproval; a different admin has to approve it again before
+// it is paid out. Final statuses release the ledger hold: Approved pays it
+// out, every other one refunds the user.
+func (r *WithdrawlRepo) UpdateWithdrawStatus(withdrawID, status, utr string, actor models.Actor, reason string, rejection *models.Rejection, dualThreshold money.Amount) (string, error) {
+	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Convert withdrawal ID
+	oid, err := primitive.ObjectIDFromHex(withdrawID)
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return "", fmt.Errorf("invalid withdraw ID: %w", err)
 	}
 
-	// 4️⃣ Check balance
-	if user.Balance < req.Amount {
-		return fmt.Errorf("insufficient balance for withdrawal")
+	// 1️⃣ Read the current state to plan the transition; the conditional
+	// update below re-checks it so concurrent callers can't both win
+	var cur models.WithdrawlRequest
+	if err := withdrawCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&cur); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", ErrWithdrawlNotFound
+		}
+		return "", err
+	}
+	// Batched withdrawals are settled by the bank's response file only
+	if cur.PayoutBatchID != nil {
+		return "", ErrAlreadyInBatch
+	}
+
+	now := time.Now()
+	filter := bson.M{"_id": oid, "status": cur.Status, "payout_batch_id": bson.M{"$exists": false}}
+	set := bson.M{}
+
+	// 2️⃣ Maker-checker: large approvals take two different admins
+	dual := dualThreshold.IsPositive() && !cur.Amount.LessThan(dualThreshold)
+	status, err = manualApproval(cur, status, actor, dual, filter, set, now)
+	if err != nil {
+		return "", err
 	}
 
-	// 6️⃣ Deduct user balance
-	_, err = userCollection.UpdateOne(
-		ctx,
-		bson.M{"_id": req.UserId},
-		bson.M{"$inc": bson.M{"balance": -req.Amount}})
+	change, err := statemachine.Withdrawl.Step(cur.Status, status, actor, reason, now)
 	if err != nil {
-		return fmt.Errorf("failed to update user balance: %w", err)
+		return "", err
 	}
-	_, err = collection.InsertOne(ctx, req)
+	set["status"] = status
+	if rejection != nil {
+		set["rejection"] = rejection
+	}
+
+	if status == models.StatusApproved {
+		// UTR is recorded once the money actually moves
+		set["utr"] = utr
+		set["approved_at"] = now
+
+		if err := r.checkUTR(ctx, utr, oid); err != nil {
+			return "", err
+		}
+	}
+
+	// 3️⃣ Apply the transition and release the hold if it is final
+	if _, err := r.commitTransition(ctx, filter, set, &change, status, actor); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// manualApproval records the approval behind a manual move of cur to status
+// and returns the status to apply. A large approval stops at
+// AwaitingSecondApproval. A withdrawal parked there always needs the checker,
+// even if the threshold has moved since, and only the checker can move it
+// on to Approved or Processing.
+func manualApproval(cur models.WithdrawlRequest, status string, actor models.Actor, dual bool, filter, set bson.M, now time.Time) (string, error) {
+	parked := cur.Status == models.WithdrawlAwaitingSecondApproval
+	switch {
+	case parked && status != models.StatusRejected:
+		return status, secondApproval(cur, actor, filter, set, now)
+	case status != models.StatusApproved:
+		return status, nil
+	case dual:
+		firstApproval(cur, actor, filter, set, now)
+		return models.WithdrawlAwaitingSecondApproval, nil
+	}
+	firstApproval(cur, actor, filter, set, now)
+	return status, nil
+}
+
+// firstApproval records actor as the maker unless the withdrawal already has
+// one; the first approver is never overwritten
+func firstApproval(cur models.WithdrawlRequest, actor models.Actor, filter, set bson.M, now time.Time) {
+	if cur.FirstApproverID != nil {
+		return
+	}
+	filter["first_approver_id"] = bson.M{"$exists": false}
+	set["first_approver_id"] = actor.ID
+	set["first_approved_at"] = now
+}
+
+// secondApproval records actor as the checker, who must differ from the maker
+func secondApproval(cur models.WithdrawlRequest, actor models.Actor, filter, set bson.M, now time.Time) error {
+	if cur.FirstApproverID == nil {
+		return ErrFirstApprovalRequired
+	}
+	if *cur.FirstApproverID == actor.ID {
+		return ErrSameApprover
+	}
+	filter["first_approver_id"] = *cur.FirstApproverID
+	set["second_approver_id"] = actor.ID
+	set["second_approved_at"] = now
+	return nil
+}
+
+// checkUTR rejects a UTR already attached to another withdrawal. The unique
+// index is authoritative; this also covers databases where it could not be
+// built because of historical duplicates.
+func (r *WithdrawlRepo) checkUTR(ctx context.Context, utr string, oid primitive.ObjectID) error {
+	if utr == "" {
+		return nil
+	}
+	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
+
+	count, err := withdrawCollection.CountDocuments(ctx, bson.M{"utr": utr, "_id": bson.M{"$ne": oid}})
 	if err != nil {
-		log.Println(err)
 		return err
 	}
+	if count > 0 {
+		return ErrDuplicateUTR
+	}
 	return nil
 }
 
-func (r *WithdrawlRepo) UpdateWithdrawStatus(withdrawID string, approve bool, utr string) error {
+// commitTransition applies a checked status change, or just set when change
+// is nil, in one transaction and returns the updated withdrawal. Only one
+// caller can match filter. Final
+// statuses release the ledger hold: Approved pays it out, every other one
+// refunds the user.
+func (r *WithdrawlRepo) commitTransition(ctx context.Context, filter, set bson.M, change *models.StatusChange, status string, actor models.Actor) (*models.WithdrawlRequest, error) {
 	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
-	userCollection := db.GetCollection(config.Cfg.DBName, "users")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	update := bson.M{"$set": set}
+	if change != nil {
+		update["$push"] = bson.M{"status_history": change}
+	}
+
+	var wd models.WithdrawlRequest
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		// 1️⃣ Apply the transition
+		err := withdrawCollection.FindOneAndUpdate(ctx, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&wd)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrDuplicateUTR
+			}
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				log.Printf("Withdraw status changed concurrently: filter : %v", filter)
+				return ErrWithdrawlNotPending
+			}
+			return fmt.Errorf("failed to update withdraw status: %w", err)
+		}
+
+		// 2️⃣ Release the hold once the withdrawal is final
+		if !statemachine.IsFinal(status) {
+			return nil
+		}
+		txn := ledger.WithdrawalRefund(wd.UserId, wd.ID, wd.Amount, wd.Fee, actor.ID)
+		if status == models.StatusApproved {
+			txn = ledger.WithdrawalPayout(wd.ID, wd.Amount, wd.Fee, actor.ID)
+		}
+
+		ledgerRepo := ledger.LedgerRepository(&ledger.LedgerRepo{})
+		if err := ledgerRepo.Post(ctx, txn); err != nil {
+			return fmt.Errorf("failed to post withdrawal ledger entries: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &wd, nil
+}
+
+// AddAdminNote appends an internal note to the withdrawal
+func (r *WithdrawlRepo) AddAdminNote(withdrawID string, note models.AdminNote) error {
+	collection := db.GetCollection(config.Cfg.DBName, "withdrawl")
 
-	// Convert withdrawal ID
 	oid, err := primitive.ObjectIDFromHex(withdrawID)
 	if err != nil {
 		return fmt.Errorf("invalid withdraw ID: %w", err)
 	}
 
-	// 1️⃣ Find withdrawal
-	var wd models.WithdrawlRequest
-	err = withdrawCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&wd)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$push": bson.M{"admin_notes": note}})
 	if err != nil {
-		log.Printf("Withdraw not found: %v \n object id : %v \n withdraw id : %s", err, oid, withdrawID)
-		return fmt.Errorf("withdraw not found: %w", err)
+		return err
 	}
-
-	// 2️⃣ Prevent duplicate approvals/rejections
-	if wd.Status == "Approved" || wd.Status == "Rejected" {
-		return fmt.Errorf("withdraw already %s", wd.Status)
+	if res.MatchedCount == 0 {
+		return ErrWithdrawlNotFound
 	}
+	return nil
+}
 
-	if approve {
+// CancelWithdrawl lets the owner withdraw their own Pending request. The
+// status change and the refund of the hold happen in one transaction.
+func (r *WithdrawlRepo) CancelWithdrawl(withdrawID string, actor models.Actor, reason string) error {
+	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
 
-		// 5️⃣ Approve withdrawal + Update UTR
-		_, err = withdrawCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{
-				"status":      "Approved",
-				"utr":         utr,
-				"approved_at": time.Now(),
-			}},
-		)
-		if err != nil {
-			return fmt.Errorf("failed to approve withdraw: %w", err)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	} else {
+	oid, err := primitive.ObjectIDFromHex(withdrawID)
+	if err != nil {
+		return fmt.Errorf("invalid withdraw ID: %w", err)
+	}
+
+	now := time.Now()
+	change, err := statemachine.Withdrawl.Step(models.StatusPending, models.StatusCancelled, actor, reason, now)
+	if err != nil {
+		return err
+	}
 
-		_, err = userCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": wd.UserId},
-			bson.M{"$inc": bson.M{"balance": +wd.Amount}},
-		)
+	return db.WithTransaction(ctx, func(ctx context.Context) error {
+		// 1️⃣ Only the owner's Pending request matches
+		var wd models.WithdrawlRequest
+		err := withdrawCollection.FindOneAndUpdate(ctx,
+			bson.M{"_id": oid, "user_id": actor.ID, "status": models.StatusPending},
+			bson.M{
+				"$set": bson.M{
+					"status":       models.StatusCancelled,
+					"cancelled_by": actor.ID,
+					"cancelled_at": now,
+				},
+				"$push": bson.M{"status_history": change},
+			},
+		).Decode(&wd)
 		if err != nil {
-			return fmt.Errorf("failed to update user balance: %w", err)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return r.cancelFailure(ctx, oid, actor.ID)
+			}
+			return fmt.Errorf("failed to cancel withdrawal: %w", err)
 		}
 
-		// 7️⃣ Reject withdrawal
-		_, err = withdrawCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{"status": "Rejected"}},
-		)
-		if err != nil {
-			return fmt.Errorf("failed to reject withdraw: %w", err)
+		// 2️⃣ Give the held amount back
+		ledgerRepo := ledger.LedgerRepository(&ledger.LedgerRepo{})
+		if err := ledgerRepo.Post(ctx, ledger.WithdrawalRefund(wd.UserId, wd.ID, wd.Amount, wd.Fee, actor.ID)); err != nil {
+			return fmt.Errorf("failed to refund withdrawal: %w", err)
 		}
+		return nil
+	})
+}
+
+// cancelFailure explains why a cancel matched nothing
+func (r *WithdrawlRepo) cancelFailure(ctx context.Context, oid, userID primitive.ObjectID) error {
+	withdrawCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
+
+	count, err := withdrawCollection.CountDocuments(ctx, bson.M{"_id": oid, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrWithdrawlNotFound
 	}
+	return ErrWithdrawlNotCancellable
+}
 
-	return nil
+// EnsureIndexes makes non-empty UTRs unique across withdrawals
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "withdrawl")
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"utr": 1},
+		Options: options.Index().
+			SetName("utr_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"utr": bson.M{"$type": "string", "$gt": ""}}),
+	})
+	return err
 }
 
 func (r *WithdrawlRepo) GetAll() ([]models.WithdrawlRes, error) {
+	return r.findWithUsers(bson.M{}, -1)
+}
+
+// GetAllByStatus lists withdrawals in one status, oldest first so queues are worked in order
+func (r *WithdrawlRepo) GetAllByStatus(status string) ([]models.WithdrawlRes, error) {
+	return r.findWithUsers(bson.M{"status": status}, 1)
+}
+
+// findWithUsers lists matching withdrawals sorted by created_at (1 or -1) with user info merged in
+func (r *WithdrawlRepo) findWithUsers(filter bson.M, sortDir int) ([]models.WithdrawlRes, error) {
 	withdrawlCollection := db.GetCollection(config.Cfg.DBName, "withdrawl")
 	userCollection := db.GetCollection(config.Cfg.DBName, "users")
 
@@ -142,8 +394,8 @@ func (r *WithdrawlRepo) GetAll() ([]models.WithdrawlRes, error) {
 	defer cancel()
 
 	// 1️⃣ Fetch withdrawals (no pagination, only sort)
-	findOpts := options.Find().SetSort(bson.M{"created_at": -1})
-	withCursor, err := withdrawlCollection.Find(ctx, bson.M{}, findOpts)
+	findOpts := options.Find().SetSort(bson.M{"created_at": sortDir})
+	withCursor, err := withdrawlCollection.Find(ctx, filter, findOpts)
 	if err != nil {
 		return nil, err
 	}
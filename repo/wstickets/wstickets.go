@@ -0,0 +1,68 @@
+package wstickets
+
+import (
+	"context"
+	"errors"
+	"p2p/config"
+	"p2p/config/db"
+	"p2p/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type TicketRepository interface {
+	Create(ticket models.WebSocketTicket) error
+	Redeem(hash string) (*models.WebSocketTicket, error)
+}
+
+type TicketRepo struct{}
+
+// ErrTicketInvalid is returned for an unknown, expired or already used ticket
+var ErrTicketInvalid = errors.New("invalid or expired websocket ticket")
+
+func (r *TicketRepo) Create(ticket models.WebSocketTicket) error {
+	collection := db.GetCollection(config.Cfg.DBName, "ws_tickets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.InsertOne(ctx, ticket)
+	return err
+}
+
+// Redeem marks the ticket used and returns it; only one caller can win
+func (r *TicketRepo) Redeem(hash string) (*models.WebSocketTicket, error) {
+	collection := db.GetCollection(config.Cfg.DBName, "ws_tickets")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var ticket models.WebSocketTicket
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": hash, "used_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&ticket)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTicketInvalid
+		}
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// EnsureIndexes drops tickets an hour after they expire
+func EnsureIndexes(ctx context.Context) error {
+	collection := db.GetCollection(config.Cfg.DBName, "ws_tickets")
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(int32(time.Hour.Seconds())),
+	})
+	return err
+}
@@ -2,6 +2,13 @@ package admin
 
 import (
 	"p2p/handlers/admin"
+	"p2p/handlers/fees"
+	"p2p/handlers/ledger"
+	"p2p/handlers/limits"
+	"p2p/handlers/payout"
+	"p2p/handlers/sessions"
+	"p2p/handlers/twofactor"
+	midleware "p2p/utils/midleWare"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,17 +16,55 @@ import (
 func AdminRoutes(r *gin.Engine) {
 	h := admin.AdminHandler{}
 	d := admin.DashboardHandler{}
+	l := ledger.LedgerHandler{}
+	sh := sessions.SessionHandler{}
+	tf := twofactor.TwoFactorHandler{}
+	ph := payout.PayoutHandler{}
+	lh := limits.LimitsHandler{}
+	fh := fees.FeeHandler{}
 	adminRoutes := r.Group("/admin")
-	adminRoutes.POST("/register", h.RegisterAdmin)
 	adminRoutes.POST("/login", h.SignInAdmin)
+	adminRoutes.POST("/refresh", sh.Refresh)
+	adminRoutes.POST("/login/2fa", tf.LoginAdmin)
 
-	adminRoutes.GET("/dashboard/counts", d.GetCounts)
+	authAdminRoutes := adminRoutes.Group("")
+	authAdminRoutes.Use(midleware.AuthMiddleware(), midleware.AdminOnly())
+
+	// Only an existing admin can create another one
+	authAdminRoutes.POST("/register", h.RegisterAdmin)
+	authAdminRoutes.POST("/logout", sh.Logout)
+	authAdminRoutes.POST("/logout-all", sh.LogoutAll)
+
+	// Two-factor
+	authAdminRoutes.POST("/2fa/enroll", tf.Enroll)
+	authAdminRoutes.POST("/2fa/verify", tf.ConfirmEnrollment)
+	authAdminRoutes.POST("/2fa/disable", tf.Disable)
+	authAdminRoutes.POST("/2fa/recovery-codes", tf.RegenerateRecoveryCodes)
+
+	authAdminRoutes.GET("/dashboard/counts", d.GetCounts)
 	// Admin Config
-	adminRoutes.POST("/config/wallet", h.UpsertSecureWalletAddress) // update wallet address
-	adminRoutes.POST("/config/usdt", h.UpsertUSDTRate)              // update USDT rate
-	adminRoutes.POST("/config/qrcode", h.UpsertQRCode)              // update QR code via upload
-	adminRoutes.GET("/config", h.FetchAdminConfig)                  // fetch current config
-	adminRoutes.GET("/ledger/stats", h.GetLedgerStats)            // fetch ledger stats
+	authAdminRoutes.POST("/config/wallet", h.UpsertSecureWalletAddress)                    // update wallet address
+	authAdminRoutes.POST("/config/usdt", h.UpsertUSDTRate)                                 // update USDT rate
+	authAdminRoutes.POST("/config/qrcode", h.UpsertQRCode)                                 // update QR code via upload
+	authAdminRoutes.POST("/config/totp-threshold", h.UpsertTOTPThreshold)                  // approvals needing a TOTP code
+	authAdminRoutes.POST("/config/dual-approval-threshold", h.UpsertDualApprovalThreshold) // withdrawals needing two admins
+	authAdminRoutes.GET("/config/rejection-reasons", h.GetRejectionReasons)
+	authAdminRoutes.POST("/config/rejection-reasons", h.UpsertRejectionReasons)
+	authAdminRoutes.GET("/config/payout-templates", ph.GetTemplates)
+	authAdminRoutes.POST("/config/payout-templates", ph.UpsertTemplates)
+	authAdminRoutes.GET("/config/limits", lh.GetLimitsConfig)
+	authAdminRoutes.POST("/config/limits", lh.UpsertLimitsConfig)
+	authAdminRoutes.GET("/config/withdrawal-fees", fh.GetFeesConfig)
+	authAdminRoutes.POST("/config/withdrawal-fees", fh.UpsertFeesConfig)
+	authAdminRoutes.GET("/config", h.FetchAdminConfig)          // fetch current config
+	authAdminRoutes.GET("/ledger/stats", h.GetLedgerStats)      // fetch ledger stats
+	authAdminRoutes.GET("/reports/collisions", h.GetCollisions) // duplicate tx hashes / UTRs
 
+	// User ledger
+	authAdminRoutes.GET("/users/:id/ledger", l.GetUserLedger)            // entries with running balance
+	authAdminRoutes.POST("/users/:id/adjust", l.AdjustBalance)           // manual credit/debit
+	authAdminRoutes.POST("/users/:id/balance/rebuild", l.RebuildBalance) // recompute cached balance
+	authAdminRoutes.GET("/users/:id/limits", lh.GetUserLimits)
+	authAdminRoutes.PUT("/users/:id/limits", lh.SetUserLimits)
 
 }
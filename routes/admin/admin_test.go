@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"p2p/routes/routetest"
+	"testing"
+)
+
+func TestAdminRoutesRejectWrongRole(t *testing.T) {
+	r := routetest.New(t, AdminRoutes)
+	routetest.Check(t, r, []routetest.Route{
+		{Method: "POST", Path: "/admin/login", Policy: routetest.Public},
+		{Method: "POST", Path: "/admin/refresh", Policy: routetest.Public},
+		{Method: "POST", Path: "/admin/login/2fa", Policy: routetest.Public},
+
+		{Method: "POST", Path: "/admin/register", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/logout", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/logout-all", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/2fa/enroll", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/2fa/verify", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/2fa/disable", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/2fa/recovery-codes", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/dashboard/counts", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/wallet", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/usdt", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/qrcode", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/totp-threshold", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/dual-approval-threshold", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/config/rejection-reasons", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/rejection-reasons", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/config/payout-templates", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/payout-templates", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/config/limits", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/limits", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/config/withdrawal-fees", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/config/withdrawal-fees", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/config", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/ledger/stats", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/reports/collisions", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/users/:id/ledger", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/users/:id/adjust", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/admin/users/:id/balance/rebuild", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/admin/users/:id/limits", Policy: routetest.AdminOnly},
+		{Method: "PUT", Path: "/admin/users/:id/limits", Policy: routetest.AdminOnly},
+	})
+}
@@ -0,0 +1,23 @@
+package beneficiaries
+
+import (
+	"p2p/handlers/beneficiary"
+	midleware "p2p/utils/midleWare"
+
+	"github.com/gin-gonic/gin"
+)
+
+func BeneficiaryRoutes(r *gin.Engine) {
+	h := beneficiary.BeneficiaryHandler{}
+	beneficiaryRoutes := r.Group("/beneficiaries")
+	beneficiaryRoutes.Use(midleware.AuthMiddleware())
+
+	beneficiaryRoutes.POST("/", midleware.UserOnly(), h.CreateBeneficiary)
+	beneficiaryRoutes.GET("/", midleware.UserOnly(), h.GetMyBeneficiaries)
+	beneficiaryRoutes.GET("/all", midleware.AdminOnly(), h.ListBeneficiaries) // ?user_id=&verified=
+	beneficiaryRoutes.GET("/ifsc/:code", h.LookupIFSC)
+	beneficiaryRoutes.GET("/:id", midleware.OwnerOrAdmin(h.BeneficiaryOwner), h.GetBeneficiaryByID)
+	beneficiaryRoutes.PUT("/:id", midleware.UserOnly(), h.UpdateBeneficiary)
+	beneficiaryRoutes.DELETE("/:id", midleware.UserOnly(), h.DeleteBeneficiary)
+	beneficiaryRoutes.POST("/:id/verify", midleware.AdminOnly(), h.VerifyBeneficiary)
+}
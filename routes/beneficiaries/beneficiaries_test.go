@@ -0,0 +1,20 @@
+package beneficiaries
+
+import (
+	"p2p/routes/routetest"
+	"testing"
+)
+
+func TestBeneficiaryRoutesRejectWrongRole(t *testing.T) {
+	r := routetest.New(t, BeneficiaryRoutes)
+	routetest.Check(t, r, []routetest.Route{
+		{Method: "POST", Path: "/beneficiaries/", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/beneficiaries/", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/beneficiaries/all", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/beneficiaries/ifsc/:code", Policy: routetest.Authenticated},
+		{Method: "GET", Path: "/beneficiaries/:id", Policy: routetest.OwnerOrAdmin},
+		{Method: "PUT", Path: "/beneficiaries/:id", Policy: routetest.UserOnly},
+		{Method: "DELETE", Path: "/beneficiaries/:id", Policy: routetest.UserOnly},
+		{Method: "POST", Path: "/beneficiaries/:id/verify", Policy: routetest.AdminOnly},
+	})
+}
@@ -9,6 +9,10 @@ import (
 
 func RegisterChatRoutes(r *gin.Engine) {
 	h := chat.ChatHandler{}
+
+	// Browsers cannot set WebSocket headers, so they pass a ticket from /chat/ws-ticket
+	r.GET("/chat/ws", midleware.WebSocketAuth(), h.ServeWS)
+
 	chatHandler := r.Group("/chat")
 	chatHandler.Use(midleware.AuthMiddleware())
 
@@ -16,5 +20,16 @@ func RegisterChatRoutes(r *gin.Engine) {
 	chatHandler.GET("/", h.FetchChats)
 	chatHandler.GET("/users", h.GetUniqueChatUsers)
 	chatHandler.PUT("/read", h.UpdateChatsReadStatus)
+	chatHandler.POST("/ws-ticket", h.IssueWSTicket)
+
+	// Shared support inbox; users write to it with receiver_id "support"
+	supportRoutes := chatHandler.Group("/support", midleware.AdminOnly())
+	supportRoutes.GET("/inbox", h.GetSupportInbox) // ?view=unassigned|mine|all
+	supportRoutes.GET("/:id", h.GetSupportConversation)
+	supportRoutes.POST("/:id/claim", h.ClaimSupportConversation)
+	supportRoutes.PUT("/:id/assign", h.AssignSupportConversation) // assign or transfer
+	supportRoutes.POST("/:id/release", h.ReleaseSupportConversation)
+	supportRoutes.POST("/:id/reply", h.ReplySupportConversation)
+	supportRoutes.PUT("/:id/read", h.MarkSupportConversationRead)
 
 }
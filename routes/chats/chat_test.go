@@ -0,0 +1,26 @@
+package chats
+
+import (
+	"p2p/routes/routetest"
+	"testing"
+)
+
+func TestChatRoutesRejectWrongRole(t *testing.T) {
+	r := routetest.New(t, RegisterChatRoutes)
+	routetest.Check(t, r, []routetest.Route{
+		{Method: "GET", Path: "/chat/ws", Policy: routetest.Authenticated},
+		{Method: "POST", Path: "/chat/", Policy: routetest.Authenticated},
+		{Method: "GET", Path: "/chat/", Policy: routetest.Authenticated},
+		{Method: "GET", Path: "/chat/users", Policy: routetest.Authenticated},
+		{Method: "PUT", Path: "/chat/read", Policy: routetest.Authenticated},
+		{Method: "POST", Path: "/chat/ws-ticket", Policy: routetest.Authenticated},
+
+		{Method: "GET", Path: "/chat/support/inbox", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/chat/support/:id", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/chat/support/:id/claim", Policy: routetest.AdminOnly},
+		{Method: "PUT", Path: "/chat/support/:id/assign", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/chat/support/:id/release", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/chat/support/:id/reply", Policy: routetest.AdminOnly},
+		{Method: "PUT", Path: "/chat/support/:id/read", Policy: routetest.AdminOnly},
+	})
+}
@@ -13,11 +13,14 @@ func DepositRoutes(r *gin.Engine) {
 
 	depositRoutes.Use(midleware.AuthMiddleware())
 
-	depositRoutes.POST("/", h.CreateDeposit)
-	depositRoutes.GET("/", h.ListDeposits)
-	depositRoutes.GET("/:id", h.GetDepositByID)
-	depositRoutes.GET("/search", h.SearchDepositsByUsername)
-	depositRoutes.GET("/user", h.GetUserDeposits)       // GET /deposits/my
-	depositRoutes.PUT("/status", h.UpdateDepositStatus) // approve/reject deposit
+	depositRoutes.POST("/", midleware.UserOnly(), midleware.Idempotency("deposit_create"), h.CreateDeposit)
+	depositRoutes.GET("/", midleware.AdminOnly(), h.ListDeposits)
+	depositRoutes.GET("/:id", midleware.OwnerOrAdmin(h.DepositOwner), h.GetDepositByID)
+	depositRoutes.GET("/search", midleware.AdminOnly(), h.SearchDepositsByUsername)
+	depositRoutes.GET("/user", midleware.UserOnly(), h.GetUserDeposits)        // GET /deposits/my
+	depositRoutes.PUT("/status", midleware.AdminOnly(), h.UpdateDepositStatus) // approve/reject deposit
+	depositRoutes.POST("/status/bulk", midleware.AdminOnly(), h.BulkUpdateDepositStatus)
+	depositRoutes.POST("/:id/verify", midleware.AdminOnly(), h.VerifyDeposit) // re-check tx hash on chain
+	depositRoutes.POST("/:id/notes", midleware.AdminOnly(), h.AddAdminNote)   // internal, hidden from the owner
 
 }
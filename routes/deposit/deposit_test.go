@@ -0,0 +1,50 @@
+package deposit
+
+import (
+	"net/http"
+	"p2p/handlers/deposit"
+	"p2p/models"
+	"p2p/routes/routetest"
+	depositsvc "p2p/services/deposit"
+	midleware "p2p/utils/midleWare"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDepositRoutesRejectWrongRole(t *testing.T) {
+	r := routetest.New(t, DepositRoutes)
+	routetest.Check(t, r, []routetest.Route{
+		{Method: "POST", Path: "/deposits/", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/deposits/", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/deposits/:id", Policy: routetest.OwnerOrAdmin},
+		{Method: "GET", Path: "/deposits/search", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/deposits/user", Policy: routetest.UserOnly},
+		{Method: "PUT", Path: "/deposits/status", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/deposits/status/bulk", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/deposits/:id/verify", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/deposits/:id/notes", Policy: routetest.AdminOnly},
+	})
+}
+
+type missingDepositStub struct {
+	depositsvc.DepositServiceInterface
+}
+
+func (missingDepositStub) GetDepositByID(string) (*models.DepositRes, error) {
+	return nil, nil
+}
+
+func TestMissingDepositIsNotFound(t *testing.T) {
+	r := routetest.New(t, DepositRoutes)
+	prev := deposit.NewDepositService
+	deposit.NewDepositService = func() depositsvc.DepositServiceInterface { return missingDepositStub{} }
+	t.Cleanup(func() { deposit.NewDepositService = prev })
+
+	path := "/deposits/" + primitive.NewObjectID().Hex()
+	for _, role := range []string{midleware.RoleUser, midleware.RoleAdmin} {
+		if got := routetest.Do(r, "GET", path, routetest.Token(t, role)); got != http.StatusNotFound {
+			t.Errorf("GET missing deposit as %s: got %d, want 404", role, got)
+		}
+	}
+}
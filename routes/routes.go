@@ -3,8 +3,10 @@ package routes
 import (
 	healthcheck "p2p/handlers/healthCheck"
 	"p2p/routes/admin"
+	"p2p/routes/beneficiaries"
 	"p2p/routes/chats"
 	"p2p/routes/deposit"
+	"p2p/routes/tickets"
 	"p2p/routes/users"
 	"p2p/routes/withdrawls"
 
@@ -17,7 +19,9 @@ func Routes(r *gin.Engine) {
 	users.UserRoutes(r)
 	deposit.DepositRoutes(r)
 	withdrawls.WithdrawlRoutes(r)
+	beneficiaries.BeneficiaryRoutes(r)
 	chats.RegisterChatRoutes(r)
+	tickets.TicketRoutes(r)
 }
 
 func healthcheckRoutes(r *gin.Engine) {
@@ -0,0 +1,137 @@
+// Package routetest drives a route group over httptest with signed tokens and
+// no database, to check that every route turns away the roles it should.
+package routetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"p2p/config"
+	midleware "p2p/utils/midleWare"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Policy is who a route lets through
+type Policy int
+
+const (
+	Public        Policy = iota // no token needed
+	Authenticated               // any logged-in role
+	AdminOnly
+	UserOnly
+	OwnerOrAdmin
+)
+
+// RoleGuest is a role no policy accepts
+const RoleGuest = "guest"
+
+type Route struct {
+	Method string
+	Path   string
+	Policy Policy
+}
+
+// New registers a route group on a fresh engine. Sessions are taken as live
+// so only the role checks decide.
+func New(t *testing.T, register func(*gin.Engine)) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.Cfg.JWTSecret = "routetest-secret"
+
+	prev := midleware.SessionCheck
+	midleware.SessionCheck = func(midleware.Claims) (int, error) { return 0, nil }
+	t.Cleanup(func() { midleware.SessionCheck = prev })
+
+	r := gin.New()
+	register(r)
+	return r
+}
+
+// Token signs an access token for a new user with the given role
+func Token(t *testing.T, role string) string {
+	t.Helper()
+	token, err := midleware.GenerateJWT(role+"@example.com", primitive.NewObjectID().Hex(), role, primitive.NewObjectID().Hex())
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+// Do sends a request with an optional bearer token and returns the status
+func Do(r *gin.Engine, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+// Check fails unless routes lists exactly what r serves, and every protected
+// route answers 401 without a token and 403 to the roles its policy refuses.
+// Requests that would pass the role checks are never sent, since their
+// handlers need the database.
+func Check(t *testing.T, r *gin.Engine, routes []Route) {
+	t.Helper()
+
+	listed := map[string]bool{}
+	for _, rt := range routes {
+		listed[rt.Method+" "+rt.Path] = true
+	}
+	for _, info := range r.Routes() {
+		if !listed[info.Method+" "+info.Path] {
+			t.Errorf("%s %s is not covered by the role table", info.Method, info.Path)
+		}
+		delete(listed, info.Method+" "+info.Path)
+	}
+	for route := range listed {
+		t.Errorf("%s is in the role table but not registered", route)
+	}
+
+	tokens := map[string]string{}
+	for _, role := range []string{midleware.RoleAdmin, midleware.RoleUser, RoleGuest} {
+		tokens[role] = Token(t, role)
+	}
+
+	for _, rt := range routes {
+		if rt.Policy == Public {
+			continue
+		}
+		path := withParams(rt.Path)
+		name := rt.Method + " " + rt.Path
+
+		if got := Do(r, rt.Method, path, ""); got != http.StatusUnauthorized {
+			t.Errorf("%s without a token: got %d, want 401", name, got)
+		}
+
+		var refused []string
+		switch rt.Policy {
+		case AdminOnly:
+			refused = []string{midleware.RoleUser, RoleGuest}
+		case UserOnly:
+			refused = []string{midleware.RoleAdmin, RoleGuest}
+		case OwnerOrAdmin:
+			refused = []string{RoleGuest}
+		}
+		for _, role := range refused {
+			if got := Do(r, rt.Method, path, tokens[role]); got != http.StatusForbidden {
+				t.Errorf("%s as %s: got %d, want 403", name, role, got)
+			}
+		}
+	}
+}
+
+// withParams fills every :param with an ObjectID
+func withParams(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			parts[i] = primitive.NewObjectID().Hex()
+		}
+	}
+	return strings.Join(parts, "/")
+}
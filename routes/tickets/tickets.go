@@ -0,0 +1,22 @@
+package tickets
+
+import (
+	"p2p/handlers/tickets"
+	midleware "p2p/utils/midleWare"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TicketRoutes(r *gin.Engine) {
+	h := tickets.TicketHandler{}
+	ticketRoutes := r.Group("/tickets")
+	ticketRoutes.Use(midleware.AuthMiddleware())
+
+	ticketRoutes.POST("/", midleware.UserOnly(), h.OpenTicket)
+	ticketRoutes.GET("/", midleware.UserOnly(), h.GetMyTickets)
+	ticketRoutes.GET("/queue", midleware.AdminOnly(), h.GetTicketQueue) // ?status=&priority=&assignee=me|none|<id>
+	ticketRoutes.GET("/:id", midleware.OwnerOrAdmin(h.TicketOwner), h.GetTicket)
+	ticketRoutes.POST("/:id/messages", midleware.OwnerOrAdmin(h.TicketOwner), h.ReplyTicket)
+	ticketRoutes.PUT("/:id", midleware.AdminOnly(), h.UpdateTicket) // status, priority, assignee
+	ticketRoutes.POST("/:id/close", midleware.UserOnly(), h.CloseTicket)
+}
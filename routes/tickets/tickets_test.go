@@ -0,0 +1,19 @@
+package tickets
+
+import (
+	"p2p/routes/routetest"
+	"testing"
+)
+
+func TestTicketRoutesRejectWrongRole(t *testing.T) {
+	r := routetest.New(t, TicketRoutes)
+	routetest.Check(t, r, []routetest.Route{
+		{Method: "POST", Path: "/tickets/", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/tickets/", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/tickets/queue", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/tickets/:id", Policy: routetest.OwnerOrAdmin},
+		{Method: "POST", Path: "/tickets/:id/messages", Policy: routetest.OwnerOrAdmin},
+		{Method: "PUT", Path: "/tickets/:id", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/tickets/:id/close", Policy: routetest.UserOnly},
+	})
+}
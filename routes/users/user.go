@@ -1,7 +1,13 @@
 package users
 
 import (
+	"p2p/handlers/ledger"
+	"p2p/handlers/limits"
+	"p2p/handlers/rates"
+	"p2p/handlers/sessions"
+	"p2p/handlers/twofactor"
 	"p2p/handlers/users"
+	"p2p/handlers/wallets"
 	midleware "p2p/utils/midleWare"
 
 	"github.com/gin-gonic/gin"
@@ -10,15 +16,44 @@ import (
 func UserRoutes(r *gin.Engine) {
 	h := users.UserHandler{}
 	d := users.DashboardHandler{}
+	l := ledger.LedgerHandler{}
+	sh := sessions.SessionHandler{}
+	tf := twofactor.TwoFactorHandler{}
+	lh := limits.LimitsHandler{}
+	rh := rates.RateHandler{}
+	wh := wallets.WalletHandler{}
 	userRoutes := r.Group("/users")
 	userRoutes.POST("/register", h.RegisterUser)
 	userRoutes.POST("/login", h.SignInUser)
-	userRoutes.PUT("/:id/block", h.BlockUser)
-	userRoutes.PUT("/forgot", h.UpdatePassword)
+	userRoutes.POST("/login/2fa", tf.LoginUser)
+	userRoutes.POST("/refresh", sh.Refresh)
+	userRoutes.POST("/logout", midleware.AuthMiddleware(), sh.Logout)
+	userRoutes.POST("/logout-all", midleware.AuthMiddleware(), sh.LogoutAll)
+	userRoutes.POST("/forgot", h.ForgotPassword)
+	userRoutes.POST("/forgot/confirm", h.ResetPassword)
+
+	adminUserRoutes := userRoutes.Group("")
+	adminUserRoutes.Use(midleware.AuthMiddleware(), midleware.AdminOnly())
+	adminUserRoutes.PUT("/:id/block", h.BlockUser)
 
 	authUserRoutes := userRoutes.Group("/auth")
 	authUserRoutes.Use(midleware.AuthMiddleware())
-	authUserRoutes.GET("/all", h.GetAllUsers)
-	authUserRoutes.GET("/dashboard", d.GetUserDashboard)
+	authUserRoutes.GET("/all", midleware.AdminOnly(), h.GetAllUsers)
+	authUserRoutes.GET("/dashboard", midleware.UserOnly(), d.GetUserDashboard)
+	authUserRoutes.GET("/ledger", midleware.UserOnly(), l.GetMyLedger)
+	authUserRoutes.GET("/limits", midleware.UserOnly(), lh.GetMyLimits)
+	authUserRoutes.POST("/rate-quote", midleware.UserOnly(), rh.CreateQuote)
+
+	// Addresses the user deposits from; only these are auto-approved
+	authUserRoutes.GET("/wallets", midleware.UserOnly(), wh.GetMyWallets)
+	authUserRoutes.POST("/wallets", midleware.UserOnly(), wh.RegisterWallet)
+	authUserRoutes.DELETE("/wallets/:id", midleware.UserOnly(), wh.DeleteWallet)
+	authUserRoutes.POST("/wallets/:id/verify", midleware.UserOnly(), wh.VerifyWallet)
+
+	// Opt-in two-factor
+	authUserRoutes.POST("/2fa/enroll", tf.Enroll)
+	authUserRoutes.POST("/2fa/verify", tf.ConfirmEnrollment)
+	authUserRoutes.POST("/2fa/disable", tf.Disable)
+	authUserRoutes.POST("/2fa/recovery-codes", tf.RegenerateRecoveryCodes)
 
 }
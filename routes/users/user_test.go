@@ -0,0 +1,85 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"p2p/handlers/users"
+	"p2p/models"
+	"p2p/routes/routetest"
+	usersvc "p2p/services/users"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUserRoutesRejectWrongRole(t *testing.T) {
+	r := routetest.New(t, UserRoutes)
+	routetest.Check(t, r, []routetest.Route{
+		{Method: "POST", Path: "/users/register", Policy: routetest.Public},
+		{Method: "POST", Path: "/users/login", Policy: routetest.Public},
+		{Method: "POST", Path: "/users/login/2fa", Policy: routetest.Public},
+		{Method: "POST", Path: "/users/refresh", Policy: routetest.Public},
+		{Method: "POST", Path: "/users/forgot", Policy: routetest.Public},
+		{Method: "POST", Path: "/users/forgot/confirm", Policy: routetest.Public},
+
+		{Method: "POST", Path: "/users/logout", Policy: routetest.Authenticated},
+		{Method: "POST", Path: "/users/logout-all", Policy: routetest.Authenticated},
+		{Method: "PUT", Path: "/users/:id/block", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/users/auth/all", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/users/auth/dashboard", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/users/auth/ledger", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/users/auth/limits", Policy: routetest.UserOnly},
+		{Method: "POST", Path: "/users/auth/rate-quote", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/users/auth/wallets", Policy: routetest.UserOnly},
+		{Method: "POST", Path: "/users/auth/wallets", Policy: routetest.UserOnly},
+		{Method: "DELETE", Path: "/users/auth/wallets/:id", Policy: routetest.UserOnly},
+		{Method: "POST", Path: "/users/auth/wallets/:id/verify", Policy: routetest.UserOnly},
+		{Method: "POST", Path: "/users/auth/2fa/enroll", Policy: routetest.Authenticated},
+		{Method: "POST", Path: "/users/auth/2fa/verify", Policy: routetest.Authenticated},
+		{Method: "POST", Path: "/users/auth/2fa/disable", Policy: routetest.Authenticated},
+		{Method: "POST", Path: "/users/auth/2fa/recovery-codes", Policy: routetest.Authenticated},
+	})
+}
+
+type registerStub struct {
+	usersvc.UserServiceInterface
+	got models.User
+}
+
+func (s *registerStub) RegisterUser(req models.RegisterRequest) (primitive.ObjectID, error) {
+	s.got = req.NewUser("user")
+	return primitive.NewObjectID(), nil
+}
+
+func TestRegisterIgnoresPrivilegedFields(t *testing.T) {
+	r := routetest.New(t, UserRoutes)
+	stub := &registerStub{}
+	prev := users.NewUserService
+	users.NewUserService = func() usersvc.UserServiceInterface { return stub }
+	t.Cleanup(func() { users.NewUserService = prev })
+
+	body := `{"name":"a","email":"a@example.com","password":"pw","role":"admin",
+		"limit_tier":"vip","limit_override":{},"totp_enabled":true,"is_blocked":true}`
+	req := httptest.NewRequest("POST", "/users/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	u := stub.got
+	if u.Email != "a@example.com" || u.Role != "user" {
+		t.Fatalf("email %q role %q", u.Email, u.Role)
+	}
+	if u.LimitTier != "" || u.LimitOverride != nil {
+		t.Fatalf("limits taken from body: tier %q override %v", u.LimitTier, u.LimitOverride)
+	}
+	if u.IsBlocked {
+		t.Fatal("is_blocked taken from body")
+	}
+	if u.TOTPEnabled || u.TOTPSecret != "" || len(u.TOTPRecoveryCodes) != 0 {
+		t.Fatal("two-factor state taken from body")
+	}
+}
@@ -1,6 +1,8 @@
 package withdrawls
 
 import (
+	"p2p/handlers/fees"
+	"p2p/handlers/payout"
 	"p2p/handlers/withdrawl"
 	midleware "p2p/utils/midleWare"
 
@@ -9,14 +11,29 @@ import (
 
 func WithdrawlRoutes(r *gin.Engine) {
 	h := withdrawl.WithdrawlHandler{}
+	fh := fees.FeeHandler{}
 	withdrawlRoutes := r.Group("/withdrawls")
 	withdrawlRoutes.Use(midleware.AuthMiddleware())
 
-	withdrawlRoutes.POST("/", h.CreateWithdrawl)
-	withdrawlRoutes.GET("/", h.ListWithdrawls)
-	withdrawlRoutes.GET("/:id", h.GetWithdrawlByID)
-	withdrawlRoutes.GET("/search", h.SearchWithdrawlsByUsername)
-	withdrawlRoutes.GET("/user", h.GetUserWithdrawls)       // GET /withdrawls/my
-	withdrawlRoutes.PUT("/status", h.UpdateWithdrawlStatus) // approve/reject deposit
+	withdrawlRoutes.POST("/", midleware.UserOnly(), midleware.Idempotency("withdrawl_create"), h.CreateWithdrawl)
+	withdrawlRoutes.GET("/", midleware.AdminOnly(), h.ListWithdrawls)
+	withdrawlRoutes.GET("/fee-quote", midleware.UserOnly(), fh.QuoteWithdrawl)
+	withdrawlRoutes.GET("/:id", midleware.OwnerOrAdmin(h.WithdrawlOwner), h.GetWithdrawlByID)
+	withdrawlRoutes.GET("/search", midleware.AdminOnly(), h.SearchWithdrawlsByUsername)
+	withdrawlRoutes.GET("/user", midleware.UserOnly(), h.GetUserWithdrawls)        // GET /withdrawls/my
+	withdrawlRoutes.PUT("/status", midleware.AdminOnly(), h.UpdateWithdrawlStatus) // approve/reject deposit
+	withdrawlRoutes.POST("/status/bulk", midleware.AdminOnly(), h.BulkUpdateWithdrawlStatus)
+	withdrawlRoutes.POST("/:id/cancel", midleware.UserOnly(), h.CancelWithdrawl) // owner only, Pending only
+	withdrawlRoutes.GET("/queue/second-approval", midleware.AdminOnly(), h.ListAwaitingSecondApproval)
+	withdrawlRoutes.POST("/:id/notes", midleware.AdminOnly(), h.AddAdminNote) // internal, hidden from the owner
+
+	// Bank payout batches: build → export file → import bank response
+	ph := payout.PayoutHandler{}
+	payoutRoutes := withdrawlRoutes.Group("/payouts", midleware.AdminOnly())
+	payoutRoutes.POST("/", ph.CreateBatch)
+	payoutRoutes.GET("/", ph.ListBatches)
+	payoutRoutes.GET("/:id", ph.GetBatch)
+	payoutRoutes.GET("/:id/export", ph.ExportBatch)
+	payoutRoutes.POST("/:id/import", ph.ImportBatch)
 
 }
@@ -0,0 +1,29 @@
+package withdrawls
+
+import (
+	"p2p/routes/routetest"
+	"testing"
+)
+
+func TestWithdrawlRoutesRejectWrongRole(t *testing.T) {
+	r := routetest.New(t, WithdrawlRoutes)
+	routetest.Check(t, r, []routetest.Route{
+		{Method: "POST", Path: "/withdrawls/", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/withdrawls/", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/withdrawls/fee-quote", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/withdrawls/:id", Policy: routetest.OwnerOrAdmin},
+		{Method: "GET", Path: "/withdrawls/search", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/withdrawls/user", Policy: routetest.UserOnly},
+		{Method: "PUT", Path: "/withdrawls/status", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/withdrawls/status/bulk", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/withdrawls/:id/cancel", Policy: routetest.UserOnly},
+		{Method: "GET", Path: "/withdrawls/queue/second-approval", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/withdrawls/:id/notes", Policy: routetest.AdminOnly},
+
+		{Method: "POST", Path: "/withdrawls/payouts/", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/withdrawls/payouts/", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/withdrawls/payouts/:id", Policy: routetest.AdminOnly},
+		{Method: "GET", Path: "/withdrawls/payouts/:id/export", Policy: routetest.AdminOnly},
+		{Method: "POST", Path: "/withdrawls/payouts/:id/import", Policy: routetest.AdminOnly},
+	})
+}
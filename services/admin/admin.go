@@ -7,26 +7,25 @@ import (
 	"p2p/repo/admin"
 	"p2p/repo/users"
 	"p2p/utils"
-	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AdminServiceInterface interface {
-	RegisterAdmin(user models.User) (primitive.ObjectID, error)
+	RegisterAdmin(req models.RegisterRequest) (primitive.ObjectID, error)
 	SignInAdmin(user models.Login) (models.User, error)
 	FetchAdminConfig() (*models.AdminConfigData, error)
 	UpsertAdminConfig(adminConfig models.AdminConfigData) (primitive.ObjectID, error)
 	GetLedgerStats() (*models.LedgerRes, error)
+	GetCollisions() (*models.CollisionReport, error)
+	RejectionReasons() ([]models.RejectionReason, error)
+	UpsertRejectionReasons(reasons []models.RejectionReason) error
+	ResolveRejection(status, code, note string, actor models.Actor) (*models.Rejection, error)
 }
 type AdminService struct{}
 
-func (s *AdminService) RegisterAdmin(user models.User) (primitive.ObjectID, error) {
-	user.Role = "admin"
-
-	user.IsBlocked = false
-	user.Balance = 0.00
-	user.CreatedAt = time.Now()
+func (s *AdminService) RegisterAdmin(req models.RegisterRequest) (primitive.ObjectID, error) {
+	user := req.NewUser("admin")
 
 	if user.Email == "" || user.Password == "" {
 		return primitive.NilObjectID, errors.New("email or password can't be empty")
@@ -82,6 +81,10 @@ func (s *AdminService) SignInAdmin(user models.Login) (models.User, error) {
 		log.Println("Error fetching user by email:", err)
 		return models.User{}, err
 	}
+
+	if userData.Role != "admin" {
+		return models.User{}, errors.New("not an admin account")
+	}
 	//  password check
 	if err := utils.CheckPasswordHash(user.Password, userData.Password); err != nil {
 		log.Println("Password Mismatch", err)
@@ -119,3 +122,13 @@ func (r AdminService) GetLedgerStats() (*models.LedgerRes, error) {
 	}
 	return stats, nil
 }
+
+func (s *AdminService) GetCollisions() (*models.CollisionReport, error) {
+	repo := admin.AdminRepository(&admin.AdminRepo{})
+	report, err := repo.GetCollisions()
+	if err != nil {
+		log.Println("Error fetching collision report:", err)
+		return nil, err
+	}
+	return report, nil
+}
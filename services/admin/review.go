@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"p2p/models"
+	"p2p/repo/admin"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const maxNoteLength = 2000
+
+var (
+	ErrReasonRequired = errors.New("reason_code is required when rejecting")
+	ErrUnknownReason  = errors.New("unknown reason_code")
+	ErrNoteRequired   = errors.New("note is required for reason_code \"other\"")
+	ErrNoteEmpty      = errors.New("note can't be empty")
+	ErrNoteTooLong    = fmt.Errorf("note can't exceed %d characters", maxNoteLength)
+	ErrInvalidReasons = errors.New("rejection_reasons must be non-empty with unique codes and labels")
+	reasonCodePattern = regexp.MustCompile(`^[a-z0-9_]{2,40}$`)
+)
+
+// RejectionReasons returns the configured list or the defaults
+func (s *AdminService) RejectionReasons() ([]models.RejectionReason, error) {
+	repo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := repo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	if len(cnf.RejectionReasons) == 0 {
+		return models.DefaultRejectionReasons, nil
+	}
+	return cnf.RejectionReasons, nil
+}
+
+// UpsertRejectionReasons replaces the reason list. Codes are stored on
+// rejected requests, so removing one does not touch past rejections.
+func (s *AdminService) UpsertRejectionReasons(reasons []models.RejectionReason) error {
+	if len(reasons) == 0 {
+		return ErrInvalidReasons
+	}
+
+	seen := make(map[string]bool, len(reasons))
+	cleaned := make([]models.RejectionReason, 0, len(reasons))
+	for _, r := range reasons {
+		code := strings.ToLower(strings.TrimSpace(r.Code))
+		label := strings.TrimSpace(r.Label)
+		if !reasonCodePattern.MatchString(code) || label == "" || seen[code] {
+			return ErrInvalidReasons
+		}
+		seen[code] = true
+		cleaned = append(cleaned, models.RejectionReason{Code: code, Label: label})
+	}
+
+	_, err := s.UpsertAdminConfig(models.AdminConfigData{RejectionReasons: cleaned})
+	return err
+}
+
+// ResolveRejection validates the reason for a Rejected or Failed target and
+// returns what gets stored on the document. Other targets return nil.
+func (s *AdminService) ResolveRejection(status, code, note string, actor models.Actor) (*models.Rejection, error) {
+	if status != models.StatusRejected && status != models.StatusFailed {
+		return nil, nil
+	}
+
+	code = strings.ToLower(strings.TrimSpace(code))
+	note = strings.TrimSpace(note)
+	if code == "" {
+		return nil, ErrReasonRequired
+	}
+	if len(note) > maxNoteLength {
+		return nil, ErrNoteTooLong
+	}
+
+	reasons, err := s.RejectionReasons()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reasons {
+		if r.Code != code {
+			continue
+		}
+		if code == models.ReasonOther && note == "" {
+			return nil, ErrNoteRequired
+		}
+		return &models.Rejection{
+			Code:       r.Code,
+			Label:      r.Label,
+			Note:       note,
+			RejectedBy: actor.ID,
+			RejectedAt: time.Now(),
+		}, nil
+	}
+	return nil, ErrUnknownReason
+}
+
+// NewAdminNote validates an internal note
+func NewAdminNote(note string, actor models.Actor) (models.AdminNote, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return models.AdminNote{}, ErrNoteEmpty
+	}
+	if len(note) > maxNoteLength {
+		return models.AdminNote{}, ErrNoteTooLong
+	}
+	return models.AdminNote{AuthorID: actor.ID, Note: note, CreatedAt: time.Now()}, nil
+}
+
+// IsReviewInputError reports whether err is a validation error from this file
+func IsReviewInputError(err error) bool {
+	for _, target := range []error{ErrReasonRequired, ErrUnknownReason, ErrNoteRequired, ErrNoteEmpty, ErrNoteTooLong, ErrInvalidReasons} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
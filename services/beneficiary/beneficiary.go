@@ -0,0 +1,212 @@
+package beneficiary
+
+import (
+	"errors"
+	"p2p/models"
+	"p2p/repo/beneficiary"
+	"p2p/utils/ifsc"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BeneficiaryServiceInterface interface {
+	Create(userID string, req models.BeneficiaryRequest) (*models.Beneficiary, error)
+	Update(id, userID string, req models.BeneficiaryRequest) (*models.Beneficiary, error)
+	Delete(id, userID string) error
+	Get(id string) (*models.Beneficiary, error)
+	ListForUser(userID string) ([]models.Beneficiary, error)
+	List(userID string, verified *bool) ([]models.Beneficiary, error)
+	Verify(id string, actor models.Actor, verified bool) error
+	LookupIFSC(code string) (ifsc.Branch, error)
+	ForWithdrawal(id, userID primitive.ObjectID) (*models.Beneficiary, error)
+}
+
+type BeneficiaryService struct{}
+
+const (
+	maxNameLength     = 100
+	maxNicknameLength = 50
+)
+
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrBeneficiaryNotFound  = beneficiary.ErrBeneficiaryNotFound
+	ErrDuplicateBeneficiary = beneficiary.ErrDuplicateBeneficiary
+	ErrInvalidIFSC          = ifsc.ErrInvalidFormat
+	ErrUnknownIFSC          = ifsc.ErrUnknownIFSC
+	ErrInvalidAccountNumber = errors.New("account number must be 9 to 18 digits")
+	ErrInvalidHolderName    = errors.New("holder name must be 2 to 100 characters")
+	ErrInvalidNickname      = errors.New("nickname can't exceed 50 characters")
+	ErrBankNameRequired     = errors.New("bank_name is required")
+
+	accountNumberPattern = regexp.MustCompile(`^[0-9]{9,18}$`)
+)
+
+// IsValidationError reports whether err comes from bad beneficiary input
+func IsValidationError(err error) bool {
+	return errors.Is(err, ErrInvalidIFSC) ||
+		errors.Is(err, ErrUnknownIFSC) ||
+		errors.Is(err, ErrInvalidAccountNumber) ||
+		errors.Is(err, ErrInvalidHolderName) ||
+		errors.Is(err, ErrInvalidNickname) ||
+		errors.Is(err, ErrBankNameRequired)
+}
+
+// clean validates the request and fills bank and branch from the IFSC master file
+func clean(req models.BeneficiaryRequest) (models.Beneficiary, error) {
+	b := models.Beneficiary{
+		Nickname:   strings.TrimSpace(req.Nickname),
+		HolderName: strings.Join(strings.Fields(req.HolderName), " "),
+		// Users paste account numbers with spaces or dashes
+		AccountNumber: strings.NewReplacer(" ", "", "-", "").Replace(req.AccountNumber),
+	}
+	if n := len([]rune(b.HolderName)); n < 2 || n > maxNameLength {
+		return b, ErrInvalidHolderName
+	}
+	if len([]rune(b.Nickname)) > maxNicknameLength {
+		return b, ErrInvalidNickname
+	}
+	if !accountNumberPattern.MatchString(b.AccountNumber) {
+		return b, ErrInvalidAccountNumber
+	}
+
+	branch, err := ifsc.Resolve(req.IFSCCode)
+	if err != nil {
+		return b, err
+	}
+	b.IFSCCode = branch.IFSC
+	b.BankName = branch.Bank
+	b.BranchName = branch.Branch
+	if b.BankName == "" {
+		b.BankName = strings.TrimSpace(req.BankName)
+	}
+	if b.BankName == "" {
+		return b, ErrBankNameRequired
+	}
+	if b.Nickname == "" {
+		b.Nickname = b.BankName + " ••" + b.AccountNumber[len(b.AccountNumber)-4:]
+	}
+	return b, nil
+}
+
+func (s *BeneficiaryService) Create(userID string, req models.BeneficiaryRequest) (*models.Beneficiary, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := clean(req)
+	if err != nil {
+		return nil, err
+	}
+	b.UserId = uid
+
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	id, err := repo.Create(b)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Update replaces the owner's beneficiary. Changing the account, IFSC or
+// holder name drops any admin verification.
+func (s *BeneficiaryService) Update(id, userID string, req models.BeneficiaryRequest) (*models.Beneficiary, error) {
+	cur, err := s.owned(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := clean(req)
+	if err != nil {
+		return nil, err
+	}
+	b.ID, b.UserId = cur.ID, cur.UserId
+
+	changed := b.AccountNumber != cur.AccountNumber || b.IFSCCode != cur.IFSCCode || b.HolderName != cur.HolderName
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	if err := repo.Update(b, changed); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(b.ID)
+}
+
+func (s *BeneficiaryService) Delete(id, userID string) error {
+	cur, err := s.owned(id, userID)
+	if err != nil {
+		return err
+	}
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	return repo.Delete(cur.ID, cur.UserId)
+}
+
+func (s *BeneficiaryService) Get(id string) (*models.Beneficiary, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrBeneficiaryNotFound
+	}
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	return repo.GetByID(oid)
+}
+
+func (s *BeneficiaryService) ListForUser(userID string) ([]models.Beneficiary, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	return repo.List(&uid, nil)
+}
+
+// List is the admin view; an empty userID lists every user's beneficiaries
+func (s *BeneficiaryService) List(userID string, verified *bool) ([]models.Beneficiary, error) {
+	var uid *primitive.ObjectID
+	if userID != "" {
+		oid, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return nil, err
+		}
+		uid = &oid
+	}
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	return repo.List(uid, verified)
+}
+
+func (s *BeneficiaryService) Verify(id string, actor models.Actor, verified bool) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrBeneficiaryNotFound
+	}
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	return repo.SetVerified(oid, actor.ID, verified)
+}
+
+func (s *BeneficiaryService) LookupIFSC(code string) (ifsc.Branch, error) {
+	return ifsc.Resolve(code)
+}
+
+// ForWithdrawal returns the user's beneficiary a withdrawal is paid to
+func (s *BeneficiaryService) ForWithdrawal(id, userID primitive.ObjectID) (*models.Beneficiary, error) {
+	repo := beneficiary.BeneficiaryRepository(&beneficiary.BeneficiaryRepo{})
+	b, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if b.UserId != userID {
+		return nil, ErrBeneficiaryNotFound
+	}
+	return b, nil
+}
+
+// owned loads a beneficiary only if userID owns it
+func (s *BeneficiaryService) owned(id, userID string) (*models.Beneficiary, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrBeneficiaryNotFound
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrBeneficiaryNotFound
+	}
+	return s.ForWithdrawal(oid, uid)
+}
@@ -1,29 +1,109 @@
 package chat
 
 import (
+	"errors"
 	"p2p/models"
 	"p2p/repo/chats"
+	"p2p/repo/users"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/realtime"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ChatServiceInterface interface {
-	CreateChat(chatData *models.Chat) error
-	GetChatsBetweenUsers(userA, userB primitive.ObjectID) (models.ChatsRes, error)
+	CanMessage(senderRole string, senderID, receiverID primitive.ObjectID) error
+	CreateChat(senderRole string, chatData *models.Chat) error
+	GetChatsBetweenUsers(userA, userB primitive.ObjectID, q models.ChatPageQuery) (models.ChatPage, error)
 	GetUniqueChatUsers(userID primitive.ObjectID) ([]models.ChatUsers, error)
-	UpdateChatsReadStatus(chatIDs []primitive.ObjectID) error
+	MarkConversationRead(readerID, peerID primitive.ObjectID, upTo time.Time) (int, error)
+	Connect(client *realtime.Client) ([]models.ChatPresenceEvent, error)
+	Disconnect(client *realtime.Client)
+	Typing(fromRole string, from primitive.ObjectID, to string, typing bool) error
+	SupportInbox(view string, actor models.Actor) ([]models.SupportInboxItem, error)
+	SupportConversation(userID string) (*models.SupportConversation, error)
+	ClaimSupport(userID string, actor models.Actor) (*models.SupportConversation, error)
+	AssignSupport(userID string, actor models.Actor, req models.SupportAssignRequest) (*models.SupportConversation, error)
+	ReleaseSupport(userID string, actor models.Actor, reason string) (*models.SupportConversation, error)
+	SupportReply(userID string, actor models.Actor, message string, files []string) (*models.Chat, error)
+	MarkSupportRead(userID string, upTo time.Time) (int, error)
 }
 
 type ChatService struct{}
 
-func (s *ChatService) CreateChat(chatData *models.Chat) error {
+const (
+	defaultChatPage = 50
+	maxChatPage     = 100
+)
+
+var (
+	ErrInvalidCursor = chats.ErrInvalidCursor
+	ErrBothCursors   = errors.New("use either before or after, not both")
+
+	ErrRecipientNotAllowed = errors.New("users can only message admins")
+)
+
+// CanMessage applies the chat rules: admins may message anyone, users
+// only admins or the support inbox, and nobody themselves
+func (s *ChatService) CanMessage(senderRole string, senderID, receiverID primitive.ObjectID) error {
+	if receiverID.IsZero() || receiverID == senderID {
+		return ErrInvalidRecipient
+	}
+	if receiverID == models.SupportInboxID {
+		// Admins answer the inbox through /chat/support instead
+		if senderRole == midleware.RoleAdmin {
+			return ErrInvalidRecipient
+		}
+		return nil
+	}
+	userRepo := users.UserRepository(&users.UserRepo{})
+	receiver, err := userRepo.GetUserByID(receiverID)
+	if err != nil {
+		return ErrInvalidRecipient
+	}
+	if senderRole != midleware.RoleAdmin && receiver.Role != midleware.RoleAdmin {
+		return ErrRecipientNotAllowed
+	}
+	return nil
+}
+
+func (s *ChatService) CreateChat(senderRole string, chatData *models.Chat) error {
+	if err := s.CanMessage(senderRole, chatData.Sender, chatData.Receiver); err != nil {
+		return err
+	}
+
 	repo := chats.ChatRepoInterface(&chats.ChatRepo{})
-	return repo.CreateChat(chatData)
+	if err := repo.CreateChat(chatData); err != nil {
+		return err
+	}
+
+	// The sender's other tabs get it too, and so does whoever handles support
+	ids := []string{chatData.Receiver.Hex(), chatData.Sender.Hex()}
+	if chatData.Receiver == models.SupportInboxID {
+		if assignee := touchSupport(chatData.Sender, chatData.Timestamp, true); assignee != nil {
+			ids = append(ids, assignee.Hex())
+		}
+	}
+	realtime.Default().Publish(models.ChatEventMessage, chatData, ids...)
+	return nil
 }
 
-func (s *ChatService) GetChatsBetweenUsers(userA, userB primitive.ObjectID) (models.ChatsRes, error) {
+// GetChatsBetweenUsers pages through a conversation, defaulting to the
+// latest 50 messages
+func (s *ChatService) GetChatsBetweenUsers(userA, userB primitive.ObjectID, q models.ChatPageQuery) (models.ChatPage, error) {
+	if q.Before != "" && q.After != "" {
+		return models.ChatPage{}, ErrBothCursors
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultChatPage
+	}
+	if q.Limit > maxChatPage {
+		q.Limit = maxChatPage
+	}
+
 	repo := chats.ChatRepoInterface(&chats.ChatRepo{})
-	return repo.GetChatsBetweenUsers(userA, userB)
+	return repo.GetChatsBetweenUsers(userA, userB, q)
 }
 
 func (s *ChatService) GetUniqueChatUsers(userID primitive.ObjectID) ([]models.ChatUsers, error) {
@@ -31,7 +111,33 @@ func (s *ChatService) GetUniqueChatUsers(userID primitive.ObjectID) ([]models.Ch
 	return repo.GetUniqueChatUsers(userID)
 }
 
-func (s *ChatService) UpdateChatsReadStatus(chatIDs []primitive.ObjectID) error {
+// MarkConversationRead marks what peerID sent the reader as read, up to
+// upTo, and returns how many messages changed. Only the receiver can mark
+// a message read, so admins viewing other conversations leave them unread.
+func (s *ChatService) MarkConversationRead(readerID, peerID primitive.ObjectID, upTo time.Time) (int, error) {
+	if peerID.IsZero() || peerID == readerID {
+		return 0, ErrInvalidRecipient
+	}
 	repo := chats.ChatRepoInterface(&chats.ChatRepo{})
-	return repo.UpdateChatsReadStatus(chatIDs)
-}
\ No newline at end of file
+	read, err := repo.MarkConversationRead(readerID, peerID, upTo)
+	if err != nil {
+		return 0, err
+	}
+	publishReadReceipts(read)
+	return len(read), nil
+}
+
+// publishReadReceipts tells each sender which of their messages were read,
+// grouped per conversation
+func publishReadReceipts(read []models.Chat) {
+	type pair struct{ sender, reader primitive.ObjectID }
+	grouped := map[pair][]primitive.ObjectID{}
+	for _, c := range read {
+		k := pair{c.Sender, c.Receiver}
+		grouped[k] = append(grouped[k], c.ID)
+	}
+	for k, ids := range grouped {
+		ev := models.ChatReadEvent{ChatIDs: ids, ReaderID: k.reader}
+		realtime.Default().Publish(models.ChatEventRead, ev, k.sender.Hex(), k.reader.Hex())
+	}
+}
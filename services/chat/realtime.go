@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"errors"
+	"log"
+	"p2p/models"
+	"p2p/repo/chats"
+	"p2p/repo/support"
+	"p2p/utils/realtime"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidRecipient = errors.New("invalid recipient")
+
+// Connect registers a WebSocket client. The user's first connection
+// announces them online to everyone they chat with. It returns the presence
+// of those peers so the client starts with a full picture.
+func (s *ChatService) Connect(client *realtime.Client) ([]models.ChatPresenceEvent, error) {
+	uid, err := primitive.ObjectIDFromHex(client.UserID)
+	if err != nil {
+		return nil, err
+	}
+	repo := chats.ChatRepoInterface(&chats.ChatRepo{})
+	peers, err := repo.GetPeerIDs(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	hub := realtime.Default()
+	first, err := hub.Register(client)
+	if err != nil {
+		hub.Unregister(client)
+		return nil, err
+	}
+	if first {
+		publishPresence(uid, true, peers)
+	}
+
+	ids := make([]string, len(peers))
+	for i, p := range peers {
+		ids[i] = p.Hex()
+	}
+	online, err := hub.Online(ids)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make([]models.ChatPresenceEvent, len(peers))
+	for i, p := range peers {
+		snapshot[i] = models.ChatPresenceEvent{UserID: p, Online: online[p.Hex()]}
+	}
+	return snapshot, nil
+}
+
+// Disconnect unregisters the client; the user's last connection announces
+// them offline
+func (s *ChatService) Disconnect(client *realtime.Client) {
+	last, err := realtime.Default().Unregister(client)
+	if err != nil {
+		log.Printf("Failed to unregister realtime client of %s: %v", client.UserID, err)
+		return
+	}
+	if !last {
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(client.UserID)
+	if err != nil {
+		return
+	}
+	repo := chats.ChatRepoInterface(&chats.ChatRepo{})
+	peers, err := repo.GetPeerIDs(uid)
+	if err != nil {
+		log.Printf("Failed to load chat peers of %s: %v", client.UserID, err)
+		return
+	}
+	publishPresence(uid, false, peers)
+}
+
+// Typing forwards a typing indicator to someone the sender may message.
+// Typing to support reaches the admin holding the conversation, if any.
+func (s *ChatService) Typing(fromRole string, from primitive.ObjectID, to string, typing bool) error {
+	toID, err := ParsePeer(to)
+	if err != nil {
+		return ErrInvalidRecipient
+	}
+	if err := s.CanMessage(fromRole, from, toID); err != nil {
+		return err
+	}
+	if toID == models.SupportInboxID {
+		repo := support.SupportRepository(&support.SupportRepo{})
+		conv, err := repo.Get(from)
+		if err != nil || conv.AssigneeID == nil {
+			return nil
+		}
+		toID = *conv.AssigneeID
+	}
+	realtime.Default().Publish(models.ChatEventTyping, models.ChatTypingEvent{From: from, Typing: typing}, toID.Hex())
+	return nil
+}
+
+func publishPresence(userID primitive.ObjectID, online bool, peers []primitive.ObjectID) {
+	ids := make([]string, len(peers))
+	for i, p := range peers {
+		ids[i] = p.Hex()
+	}
+	realtime.Default().Publish(models.ChatEventPresence, models.ChatPresenceEvent{UserID: userID, Online: online}, ids...)
+}
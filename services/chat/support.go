@@ -0,0 +1,269 @@
+package chat
+
+import (
+	"errors"
+	"log"
+	"p2p/models"
+	"p2p/repo/chats"
+	"p2p/repo/support"
+	"p2p/repo/users"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/realtime"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Support inbox views for admins
+const (
+	InboxUnassigned = "unassigned"
+	InboxMine       = "mine"
+	InboxAll        = "all"
+)
+
+var (
+	ErrConversationNotFound = support.ErrConversationNotFound
+	ErrAssignmentChanged    = support.ErrAssignmentChanged
+	ErrAlreadyAssigned      = errors.New("conversation is already assigned")
+	ErrAssignedElsewhere    = errors.New("conversation is assigned to another admin, transfer it first")
+	ErrNotAssigned          = errors.New("conversation is not assigned")
+	ErrInvalidAssignee      = errors.New("conversations can only be assigned to admins")
+	ErrInvalidInboxView     = errors.New("view must be unassigned, mine or all")
+	ErrEmptyMessage         = errors.New("message or files are required")
+)
+
+// ParsePeer reads a chat peer from a request: a user ID, or "support" for
+// the shared support inbox
+func ParsePeer(peer string) (primitive.ObjectID, error) {
+	if strings.EqualFold(strings.TrimSpace(peer), models.SupportInbox) {
+		return models.SupportInboxID, nil
+	}
+	return primitive.ObjectIDFromHex(peer)
+}
+
+// touchSupport records a message in the user's support conversation and
+// returns who it is assigned to, if anyone
+func touchSupport(userID primitive.ObjectID, at time.Time, waiting bool) *primitive.ObjectID {
+	repo := support.SupportRepository(&support.SupportRepo{})
+	conv, err := repo.Touch(userID, at, waiting)
+	if err != nil {
+		// The message is saved; only the inbox bookkeeping is behind
+		log.Printf("Failed to update support conversation of %s: %v", userID.Hex(), err)
+		return nil
+	}
+	return conv.AssigneeID
+}
+
+// SupportInbox lists support conversations with how long each user has
+// been waiting. view defaults to the unassigned ones.
+func (s *ChatService) SupportInbox(view string, actor models.Actor) ([]models.SupportInboxItem, error) {
+	repo := support.SupportRepository(&support.SupportRepo{})
+
+	var list []models.SupportConversation
+	var err error
+	switch view {
+	case "", InboxUnassigned:
+		list, err = repo.Inbox(nil, true)
+	case InboxMine:
+		list, err = repo.Inbox(&actor.ID, false)
+	case InboxAll:
+		list, err = repo.Inbox(nil, false)
+	default:
+		return nil, ErrInvalidInboxView
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(list))
+	for i, conv := range list {
+		ids[i] = conv.UserId
+	}
+	userRepo := users.UserRepository(&users.UserRepo{})
+	byID, err := userRepo.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	items := make([]models.SupportInboxItem, len(list))
+	for i, conv := range list {
+		u := byID[conv.UserId]
+		items[i] = models.SupportInboxItem{
+			SupportConversation: conv,
+			User:                models.UserInfo{Username: u.Name, Email: u.Email},
+		}
+		if conv.WaitingSince != nil {
+			items[i].WaitingSeconds = int64(now.Sub(*conv.WaitingSince).Seconds())
+		}
+	}
+	return items, nil
+}
+
+// SupportConversation returns a user's support conversation with its
+// assignment history
+func (s *ChatService) SupportConversation(userID string) (*models.SupportConversation, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	repo := support.SupportRepository(&support.SupportRepo{})
+	return repo.Get(uid)
+}
+
+// ClaimSupport takes an unassigned conversation for the calling admin
+func (s *ChatService) ClaimSupport(userID string, actor models.Actor) (*models.SupportConversation, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	conv, err := s.assign(uid, nil, &actor.ID, models.SupportClaim, actor, "")
+	if errors.Is(err, ErrAssignmentChanged) {
+		return nil, ErrAlreadyAssigned
+	}
+	return conv, err
+}
+
+// AssignSupport hands a conversation to an admin, or transfers it from
+// whoever holds it now
+func (s *ChatService) AssignSupport(userID string, actor models.Actor, req models.SupportAssignRequest) (*models.SupportConversation, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	to, err := primitive.ObjectIDFromHex(req.AssigneeID)
+	if err != nil {
+		return nil, ErrInvalidAssignee
+	}
+	userRepo := users.UserRepository(&users.UserRepo{})
+	assignee, err := userRepo.GetUserByID(to)
+	if err != nil || assignee.Role != midleware.RoleAdmin {
+		return nil, ErrInvalidAssignee
+	}
+
+	// Only an existing conversation can be transferred; a missing one is assigned fresh
+	repo := support.SupportRepository(&support.SupportRepo{})
+	var from *primitive.ObjectID
+	conv, err := repo.Get(uid)
+	switch {
+	case err == nil:
+		from = conv.AssigneeID
+	case !errors.Is(err, ErrConversationNotFound):
+		return nil, err
+	}
+	if from != nil && *from == to {
+		return conv, nil
+	}
+
+	action := models.SupportAssign
+	if from != nil {
+		action = models.SupportTransfer
+	}
+	return s.assign(uid, from, &to, action, actor, req.Reason)
+}
+
+// ReleaseSupport puts a conversation back in the unassigned inbox
+func (s *ChatService) ReleaseSupport(userID string, actor models.Actor, reason string) (*models.SupportConversation, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	repo := support.SupportRepository(&support.SupportRepo{})
+	conv, err := repo.Get(uid)
+	if err != nil {
+		return nil, err
+	}
+	if conv.AssigneeID == nil {
+		return nil, ErrNotAssigned
+	}
+	return s.assign(uid, conv.AssigneeID, nil, models.SupportRelease, actor, reason)
+}
+
+// assign records the change of hands and tells both admins about it
+func (s *ChatService) assign(userID primitive.ObjectID, from, to *primitive.ObjectID, action string, actor models.Actor, reason string) (*models.SupportConversation, error) {
+	entry := models.SupportAssignment{
+		Action:  action,
+		From:    from,
+		To:      to,
+		ActorID: actor.ID,
+		Reason:  strings.TrimSpace(reason),
+		At:      time.Now(),
+	}
+	repo := support.SupportRepository(&support.SupportRepo{})
+	conv, err := repo.Assign(userID, from, to, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, id := range []*primitive.ObjectID{from, to} {
+		if id != nil && *id != actor.ID {
+			ids = append(ids, id.Hex())
+		}
+	}
+	realtime.Default().Publish(models.ChatEventAssignment, conv, ids...)
+	return conv, nil
+}
+
+// SupportReply answers a user as the support inbox. An unassigned
+// conversation is claimed by whoever answers first; one held by another
+// admin has to be transferred before replying.
+func (s *ChatService) SupportReply(userID string, actor models.Actor, message string, files []string) (*models.Chat, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidRecipient
+	}
+	message = strings.TrimSpace(message)
+	if message == "" && len(files) == 0 {
+		return nil, ErrEmptyMessage
+	}
+	if err := s.CanMessage(midleware.RoleAdmin, models.SupportInboxID, uid); err != nil {
+		return nil, err
+	}
+
+	// 1️⃣ Make sure the caller holds the conversation
+	repo := support.SupportRepository(&support.SupportRepo{})
+	conv, err := repo.Get(uid)
+	if err != nil && !errors.Is(err, ErrConversationNotFound) {
+		return nil, err
+	}
+	switch {
+	case conv == nil || conv.AssigneeID == nil:
+		if _, err := s.assign(uid, nil, &actor.ID, models.SupportClaim, actor, ""); err != nil {
+			if errors.Is(err, ErrAssignmentChanged) {
+				return nil, ErrAssignedElsewhere
+			}
+			return nil, err
+		}
+	case *conv.AssigneeID != actor.ID:
+		return nil, ErrAssignedElsewhere
+	}
+
+	// 2️⃣ Send it as the inbox, remembering which admin wrote it
+	chatData := &models.Chat{
+		Sender:   models.SupportInboxID,
+		Receiver: uid,
+		Message:  message,
+		FilesUrl: files,
+		AgentID:  &actor.ID,
+	}
+	chatRepo := chats.ChatRepoInterface(&chats.ChatRepo{})
+	if err := chatRepo.CreateChat(chatData); err != nil {
+		return nil, err
+	}
+	touchSupport(uid, chatData.Timestamp, false)
+
+	realtime.Default().Publish(models.ChatEventMessage, chatData, uid.Hex(), actor.ID.Hex())
+	return chatData, nil
+}
+
+// MarkSupportRead marks what the user sent the support inbox as read, up
+// to upTo. Any admin may do this since the inbox is the receiver.
+func (s *ChatService) MarkSupportRead(userID string, upTo time.Time) (int, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, ErrInvalidRecipient
+	}
+	return s.MarkConversationRead(models.SupportInboxID, uid, upTo)
+}
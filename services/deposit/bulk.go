@@ -0,0 +1,89 @@
+package deposit
+
+import (
+	"errors"
+	"p2p/models"
+	"p2p/repo/deposit"
+	adminService "p2p/services/admin"
+	"p2p/services/twofactor"
+	"p2p/utils/money"
+	"p2p/utils/statemachine"
+	"strings"
+)
+
+// MaxBulkItems caps one bulk request so it fits in a single HTTP round trip
+const MaxBulkItems = 100
+
+var (
+	ErrBulkEmpty     = errors.New("at least one deposit id is required")
+	ErrBulkTooLarge  = errors.New("too many deposits in one bulk request")
+	ErrDuplicateItem = errors.New("deposit listed more than once in this request")
+)
+
+// BulkUpdateDepositStatus moves every listed deposit to the same status.
+// Each item runs in its own transaction, so one failure never rolls back
+// the others; request-wide problems (bad status, missing reason, TOTP)
+// fail the whole batch before anything is touched.
+func (s *DepositService) BulkUpdateDepositStatus(req models.BulkStatusRequest, actor models.Actor) (*models.BulkStatusRes, error) {
+	status, err := statemachine.Normalize(req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := req.Targets()
+	if len(targets) == 0 {
+		return nil, ErrBulkEmpty
+	}
+	if len(targets) > MaxBulkItems {
+		return nil, ErrBulkTooLarge
+	}
+
+	adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+	rejection, err := adminSvc.ResolveRejection(status, req.ReasonCode, req.Note, actor)
+	if err != nil {
+		return nil, err
+	}
+	reason := historyReason(req.Reason, rejection)
+
+	repo := deposit.DepositRepository(&deposit.DepositRepo{})
+
+	// A TOTP code can only be used once, so it covers the whole batch and is
+	// checked against the largest deposit in it
+	if status == models.StatusApproved {
+		largest := money.Zero()
+		for _, t := range targets {
+			dep, err := repo.GetByID(strings.TrimSpace(t.ID))
+			if err == nil && dep != nil {
+				largest = money.Max(largest, dep.Amount)
+			}
+		}
+		tf := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+		if err := tf.CheckApproval(actor.ID.Hex(), largest, req.TOTPCode); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &models.BulkStatusRes{Results: make([]models.BulkItemResult, 0, len(targets))}
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		id := strings.TrimSpace(t.ID)
+		item := models.BulkItemResult{ID: id}
+
+		if seen[id] {
+			item.Err = ErrDuplicateItem
+		} else {
+			seen[id] = true
+			item.Err = repo.UpdateDepositStatus(id, status, actor, reason, rejection)
+		}
+
+		if item.Err == nil {
+			item.Success = true
+			item.Status = status
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+		res.Results = append(res.Results, item)
+	}
+	return res, nil
+}
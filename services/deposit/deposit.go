@@ -1,55 +1,283 @@
 package deposit
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"p2p/config"
 	"p2p/models"
+	"p2p/repo/admin"
 	"p2p/repo/deposit"
+	adminService "p2p/services/admin"
+	"p2p/services/limits"
+	"p2p/services/rates"
+	"p2p/services/tickets"
+	"p2p/services/twofactor"
+	"p2p/services/wallets"
+	"p2p/utils/chain"
+	"p2p/utils/money"
+	"p2p/utils/statemachine"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // DepositServiceInterface defines the methods for deposit operations
 type DepositServiceInterface interface {
-	UpdateDepositStatus(depositID string, approve bool) error
+	UpdateDepositStatus(req models.UpdateStatusRequest, actor models.Actor) error
+	BulkUpdateDepositStatus(req models.BulkStatusRequest, actor models.Actor) (*models.BulkStatusRes, error)
+	AddAdminNote(depositID string, actor models.Actor, note string) error
 	CreateDeposit(req models.DepositRequest) error
 	ListDeposits() ([]models.DepositRes, error)
 	GetDepositByID(id string) (*models.DepositRes, error)
 	SearchDepositsByUsername(username string) ([]models.DepositRes, error)
 	GetDepositsByUserID(userID string) ([]models.DepositRes, error)
+	VerifyDeposit(depositID string) (*models.ChainVerification, error)
 }
 
 // DepositService implements DepositServiceInterface
 type DepositService struct {
 }
 
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrDepositNotPending    = deposit.ErrDepositNotPending
+	ErrDuplicateTxHash      = deposit.ErrDuplicateTransactionHash
+	ErrInvalidAmount        = errors.New("deposit amount must be greater than zero")
+	ErrDepositNotFound      = deposit.ErrDepositNotFound
+	ErrMissingTxHash        = errors.New("transaction_hash is required")
+	ErrVerificationDisabled = chain.ErrVerificationDisabled
+	ErrIllegalTransition    = statemachine.ErrIllegalTransition
+	ErrUnknownStatus        = statemachine.ErrUnknownStatus
+)
+
 // Create new deposit request
 func (s *DepositService) CreateDeposit(req models.DepositRequest) error {
+	// USDT amounts are kept to 6 decimal places
+	req.Amount = req.Amount.RoundUSDT()
+	if !req.Amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+
+	// Hashes are hex on both supported chains, so case never matters
+	req.TransactionHash = strings.ToLower(strings.TrimSpace(req.TransactionHash))
+	if req.TransactionHash == "" {
+		return ErrMissingTxHash
+	}
+
+	// The INR value is locked now so later rate changes never reprice it
+	rateSvc := rates.RateServiceInterface(&rates.RateService{})
+	rate, err := rateSvc.Lock(req.UserId, models.LimitDeposit, req.RateQuoteID)
+	if err != nil {
+		return err
+	}
+
+	// Only client inputs are carried over; verification, history and notes start empty
+	dep := models.DepositRequest{
+		UserId:          req.UserId,
+		Amount:          req.Amount,
+		TransactionHash: req.TransactionHash,
+		Status:          models.StatusPending,
+		INRRate:         rate,
+		INRAmount:       money.ToINR(req.Amount, rate),
+		RateQuoteID:     req.RateQuoteID,
+	}
+
+	// Limits are checked in the insert's transaction so parallel requests can't all pass
+	limitSvc := limits.LimitsServiceInterface(&limits.LimitsService{})
+	checkLimits := func(ctx context.Context) error {
+		return limitSvc.Check(ctx, models.LimitDeposit, dep.UserId, dep.Amount)
+	}
+
 	repo := deposit.DepositRepository(&deposit.DepositRepo{})
-	return repo.DepositRequest(req)
+	id, err := repo.DepositRequest(dep, checkLimits)
+	if err != nil {
+		rateSvc.Release(req.RateQuoteID)
+		return err
+	}
+
+	// Chain lookups can be slow; verify without holding up the user
+	go s.verifyAfterCreate(id)
+
+	return nil
+}
+
+func (s *DepositService) verifyAfterCreate(id primitive.ObjectID) {
+	if _, err := s.VerifyDeposit(id.Hex()); err != nil && !errors.Is(err, ErrVerificationDisabled) {
+		log.Printf("Deposit %s chain verification failed: %v", id.Hex(), err)
+	}
 }
 
-func (s *DepositService) UpdateDepositStatus(depositID string, approve bool) error {
+// VerifyDeposit checks the deposit's transaction hash on chain, stores the
+// result and, when enabled, approves a Pending deposit that fully matches.
+func (s *DepositService) VerifyDeposit(depositID string) (*models.ChainVerification, error) {
 	repo := deposit.DepositRepository(&deposit.DepositRepo{})
-	return repo.UpdateDepositStatus(depositID, approve)
+	dep, err := repo.GetByID(depositID)
+	if err != nil {
+		return nil, err
+	}
+	if dep == nil {
+		return nil, ErrDepositNotFound
+	}
+
+	verifier, err := chain.FromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	adminRepo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := adminRepo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	walletSvc := wallets.WalletServiceInterface(&wallets.WalletService{})
+	senders, err := walletSvc.AddressesFor(dep.UserId, verifier.Network())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	result, verifyErr := verifier.Verify(ctx, chain.VerifyRequest{
+		TxHash:        dep.TransactionHash,
+		WalletAddress: cnf.SecureWalletAddress,
+		Amount:        dep.Amount,
+		SenderWallets: senders,
+	})
+
+	if verifyErr == nil && result.Status == models.VerificationVerified &&
+		config.Cfg.AutoApproveDeposits && dep.Status == models.StatusPending {
+		if reason := manualReason(cnf, dep.Amount, result); reason != "" {
+			result.ManualReason = reason
+		} else {
+			// The system walks the same steps an admin would, one move each
+			system := models.Actor{Role: models.ActorSystem}
+			result.AutoApproved = true
+			for _, next := range []string{models.StatusUnderReview, models.StatusProcessing, models.StatusApproved} {
+				if err := repo.UpdateDepositStatus(depositID, next, system, "verified on chain", nil); err != nil {
+					log.Printf("Deposit %s auto-approval stopped before %s: %v", depositID, next, err)
+					result.AutoApproved = false
+					break
+				}
+			}
+		}
+	}
+
+	if err := repo.SetVerification(dep.ID, result); err != nil {
+		return nil, err
+	}
+	if verifyErr != nil {
+		return &result, verifyErr
+	}
+	return &result, nil
+}
+
+// manualReason says why a verified deposit still needs an admin: the system
+// cannot give the TOTP code large approvals need, and a hash only proves the
+// payment was made, not that the depositor made it
+func manualReason(cnf *models.AdminConfigData, amount money.Amount, result models.ChainVerification) string {
+	switch {
+	case twofactor.NeedsApprovalCode(cnf, amount):
+		return "amount is at or above the approval threshold"
+	case !result.SenderRegistered:
+		return fmt.Sprintf("sent from %s, which is not a verified wallet of the depositor", result.FromAddress)
+	}
+	return ""
+}
+
+func (s *DepositService) UpdateDepositStatus(req models.UpdateStatusRequest, actor models.Actor) error {
+	status, err := statemachine.Normalize(req.Status)
+	if err != nil {
+		return err
+	}
+
+	repo := deposit.DepositRepository(&deposit.DepositRepo{})
+
+	// Large approvals need a fresh code from the approving admin
+	if status == models.StatusApproved {
+		dep, err := repo.GetByID(req.ID)
+		if err != nil || dep == nil {
+			return ErrDepositNotFound
+		}
+		tf := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+		if err := tf.CheckApproval(actor.ID.Hex(), dep.Amount, req.TOTPCode); err != nil {
+			return err
+		}
+	}
+
+	adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+	rejection, err := adminSvc.ResolveRejection(status, req.ReasonCode, req.Note, actor)
+	if err != nil {
+		return err
+	}
+
+	return repo.UpdateDepositStatus(req.ID, status, actor, historyReason(req.Reason, rejection), rejection)
+}
+
+// AddAdminNote appends an internal note that the owner never sees
+func (s *DepositService) AddAdminNote(depositID string, actor models.Actor, note string) error {
+	n, err := adminService.NewAdminNote(note, actor)
+	if err != nil {
+		return err
+	}
+	repo := deposit.DepositRepository(&deposit.DepositRepo{})
+	return repo.AddAdminNote(depositID, n)
+}
+
+// historyReason prefers the explicit reason and falls back to the rejection code
+func historyReason(reason string, rejection *models.Rejection) string {
+	reason = strings.TrimSpace(reason)
+	if reason == "" && rejection != nil {
+		return rejection.Code
+	}
+	return reason
 }
 
 // GetDepositsByUserID - paginated deposits for a given user
 func (s *DepositService) GetDepositsByUserID(userID string) ([]models.DepositRes, error) {
 	repo := deposit.DepositRepository(&deposit.DepositRepo{})
-	return repo.GetAllByUserID(userID)
+	list, err := repo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	tickets.AttachDeposits(list)
+	return list, nil
 }
 
 // List deposits with pagination
 func (s *DepositService) ListDeposits() ([]models.DepositRes, error) {
 	repo := deposit.DepositRepository(&deposit.DepositRepo{})
-	return repo.GetAll()
+	list, err := repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	tickets.AttachDeposits(list)
+	return list, nil
 }
 
 // Get deposit by ID
 func (s *DepositService) GetDepositByID(id string) (*models.DepositRes, error) {
 	repo := deposit.DepositRepository(&deposit.DepositRepo{})
-	return repo.GetByID(id)
+	dep, err := repo.GetByID(id)
+	if err != nil || dep == nil {
+		return dep, err
+	}
+	list := []models.DepositRes{*dep}
+	tickets.AttachDeposits(list)
+	return &list[0], nil
 }
 
 // Search deposits by username
 func (s *DepositService) SearchDepositsByUsername(username string) ([]models.DepositRes, error) {
 	repo := deposit.DepositRepository(&deposit.DepositRepo{})
-	return repo.SearchByUsername(username)
+	list, err := repo.SearchByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	tickets.AttachDeposits(list)
+	return list, nil
 }
@@ -0,0 +1,163 @@
+package fees
+
+import (
+	"errors"
+	"p2p/models"
+	"p2p/repo/admin"
+	"p2p/repo/users"
+	adminService "p2p/services/admin"
+	"p2p/utils/money"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FeeServiceInterface interface {
+	Quote(userID primitive.ObjectID, amount money.Amount) (*models.FeeQuote, error)
+	Config() (*models.FeesConfig, error)
+	UpsertConfig(cnf models.FeesConfig) error
+}
+
+type FeeService struct{}
+
+var (
+	ErrInvalidAmount   = errors.New("amount must be greater than zero")
+	ErrInvalidSchedule = errors.New("fee schedules need a known type, non-negative values, min not above max, and tiered bands in increasing order with the last one open-ended")
+	ErrDuplicateTier   = errors.New("each tier can only have one fee schedule")
+)
+
+// Calculate prices amount with schedule, rounded to USDT precision. The
+// fee never exceeds the amount itself.
+func Calculate(schedule models.FeeSchedule, amount money.Amount) money.Amount {
+	flat, percent := schedule.Flat, schedule.Percent
+	switch schedule.Type {
+	case models.FeeFlat:
+		percent = money.Zero()
+	case models.FeePercent:
+		flat = money.Zero()
+	case models.FeeTiered:
+		flat, percent = money.Zero(), money.Zero()
+		for _, band := range schedule.Bands {
+			if band.UpTo == nil || !amount.GreaterThan(*band.UpTo) {
+				flat, percent = band.Flat, band.Percent
+				break
+			}
+		}
+	default:
+		return money.Zero()
+	}
+
+	fee := flat.Add(amount.Percent(percent))
+	if schedule.Min != nil {
+		fee = money.Max(fee, *schedule.Min)
+	}
+	if schedule.Max != nil && schedule.Max.IsPositive() {
+		fee = money.Min(fee, *schedule.Max)
+	}
+	return money.Min(fee, amount).RoundUSDT()
+}
+
+// Config returns the stored fee setup, or an empty one that charges nothing
+func (s *FeeService) Config() (*models.FeesConfig, error) {
+	repo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := repo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	if cnf.WithdrawalFees == nil {
+		return &models.FeesConfig{}, nil
+	}
+	return cnf.WithdrawalFees, nil
+}
+
+// Quote prices a withdrawal of amount for the user, using their limits
+// tier's schedule when one is set and the default otherwise
+func (s *FeeService) Quote(userID primitive.ObjectID, amount money.Amount) (*models.FeeQuote, error) {
+	amount = amount.RoundUSDT()
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	userRepo := users.UserRepository(&users.UserRepo{})
+	user, err := userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	cnf, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.FeeQuote{Amount: amount, Fee: money.Zero(), Schedule: cnf.Default}
+	for _, t := range cnf.Tiers {
+		if user.LimitTier != "" && t.Tier == user.LimitTier {
+			schedule := t.Schedule
+			quote.Schedule, quote.Tier = &schedule, t.Tier
+			break
+		}
+	}
+	if quote.Schedule != nil {
+		quote.Fee = Calculate(*quote.Schedule, amount)
+	}
+	quote.Total = amount.Add(quote.Fee)
+	return quote, nil
+}
+
+// UpsertConfig replaces the fee setup. Withdrawals keep the fee they were
+// created with.
+func (s *FeeService) UpsertConfig(cnf models.FeesConfig) error {
+	if cnf.Default != nil && !validSchedule(*cnf.Default) {
+		return ErrInvalidSchedule
+	}
+	seen := make(map[string]bool, len(cnf.Tiers))
+	for i, t := range cnf.Tiers {
+		t.Tier = strings.ToLower(strings.TrimSpace(t.Tier))
+		if t.Tier == "" || !validSchedule(t.Schedule) {
+			return ErrInvalidSchedule
+		}
+		if seen[t.Tier] {
+			return ErrDuplicateTier
+		}
+		seen[t.Tier] = true
+		cnf.Tiers[i] = t
+	}
+
+	adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+	_, err := adminSvc.UpsertAdminConfig(models.AdminConfigData{WithdrawalFees: &cnf})
+	return err
+}
+
+func validSchedule(sch models.FeeSchedule) bool {
+	if sch.Flat.IsNegative() || sch.Percent.IsNegative() || sch.Percent.GreaterThan(money.New(100)) {
+		return false
+	}
+	if (sch.Min != nil && sch.Min.IsNegative()) || (sch.Max != nil && sch.Max.IsNegative()) {
+		return false
+	}
+	if sch.Min != nil && sch.Max != nil && sch.Max.IsPositive() && sch.Min.GreaterThan(*sch.Max) {
+		return false
+	}
+
+	switch sch.Type {
+	case models.FeeFlat, models.FeePercent:
+		return true
+	case models.FeeTiered:
+		if len(sch.Bands) == 0 {
+			return false
+		}
+		for i, band := range sch.Bands {
+			if band.Flat.IsNegative() || band.Percent.IsNegative() || band.Percent.GreaterThan(money.New(100)) {
+				return false
+			}
+			last := i == len(sch.Bands)-1
+			if (band.UpTo == nil) != last {
+				return false
+			}
+			if i > 0 && !last && !band.UpTo.GreaterThan(*sch.Bands[i-1].UpTo) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
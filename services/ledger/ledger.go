@@ -0,0 +1,68 @@
+package ledger
+
+import (
+	"errors"
+	"p2p/models"
+	"p2p/repo/ledger"
+	"p2p/utils/money"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LedgerServiceInterface interface {
+	GetUserLedger(userID primitive.ObjectID) (*models.UserLedgerRes, error)
+	AdjustBalance(userID primitive.ObjectID, req models.BalanceAdjustmentRequest, actor primitive.ObjectID) error
+	RebuildUserBalance(userID primitive.ObjectID) (money.Amount, error)
+}
+
+type LedgerService struct{}
+
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrInsufficientBalance = ledger.ErrInsufficientBalance
+	ErrUserNotFound        = ledger.ErrUserNotFound
+	ErrInvalidAdjustment   = errors.New("adjustment amount must be positive and direction credit or debit")
+)
+
+// GetUserLedger lists the user's entries oldest first with a running balance
+func (s *LedgerService) GetUserLedger(userID primitive.ObjectID) (*models.UserLedgerRes, error) {
+	repo := ledger.LedgerRepository(&ledger.LedgerRepo{})
+	entries, err := repo.GetUserEntries(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.UserLedgerRes{
+		UserId:  userID,
+		Entries: make([]models.UserLedgerEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		if e.Direction == models.LedgerCredit {
+			res.Balance = res.Balance.Add(e.Amount)
+		} else {
+			res.Balance = res.Balance.Sub(e.Amount)
+		}
+		res.Entries = append(res.Entries, models.UserLedgerEntry{
+			LedgerEntry:    e,
+			RunningBalance: res.Balance,
+		})
+	}
+
+	return res, nil
+}
+
+// AdjustBalance books a manual admin credit or debit against the user
+func (s *LedgerService) AdjustBalance(userID primitive.ObjectID, req models.BalanceAdjustmentRequest, actor primitive.ObjectID) error {
+	if !req.Amount.IsPositive() || (req.Direction != models.LedgerCredit && req.Direction != models.LedgerDebit) {
+		return ErrInvalidAdjustment
+	}
+
+	repo := ledger.LedgerRepository(&ledger.LedgerRepo{})
+	return repo.PostTxn(ledger.Adjustment(userID, req.Amount, req.Direction, req.Note, actor))
+}
+
+// RebuildUserBalance resets the cached balance to the ledger total
+func (s *LedgerService) RebuildUserBalance(userID primitive.ObjectID) (money.Amount, error) {
+	repo := ledger.LedgerRepository(&ledger.LedgerRepo{})
+	return repo.RebuildUserBalance(userID)
+}
@@ -0,0 +1,332 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"p2p/config"
+	"p2p/models"
+	"p2p/repo/admin"
+	"p2p/repo/limits"
+	"p2p/repo/users"
+	adminService "p2p/services/admin"
+	"p2p/utils/money"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LimitsServiceInterface interface {
+	Check(ctx context.Context, kind string, userID primitive.ObjectID, amount money.Amount) error
+	Summary(userID string) (*models.LimitsRes, error)
+	Config() (*models.LimitsConfig, error)
+	UpsertConfig(cnf models.LimitsConfig) error
+	SetUserLimits(userID string, req models.UserLimitsRequest) error
+}
+
+type LimitsService struct{}
+
+var (
+	ErrBelowMinimum   = errors.New("amount is below the minimum per request")
+	ErrAboveMaximum   = errors.New("amount is above the maximum per request")
+	ErrDailyLimit     = errors.New("daily limit reached")
+	ErrMonthlyLimit   = errors.New("monthly limit reached")
+	ErrTooManyPending = errors.New("too many requests still pending")
+
+	ErrInvalidLimits = errors.New("limits must be non-negative, min not above max, and tiers need unique codes and names")
+	ErrUnknownTier   = errors.New("unknown limit tier")
+	ErrUserNotFound  = users.ErrUserNotFound
+
+	tierCodePattern = regexp.MustCompile(`^[a-z0-9_]{2,40}$`)
+)
+
+// IsLimitError reports whether err is a request refused by a limit
+func IsLimitError(err error) bool {
+	for _, target := range []error{ErrBelowMinimum, ErrAboveMaximum, ErrDailyLimit, ErrMonthlyLimit, ErrTooManyPending} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	zoneOnce sync.Once
+	zone     *time.Location
+)
+
+// periodStarts returns the current day and month starts in LimitsTimezone
+func periodStarts(now time.Time) (time.Time, time.Time) {
+	zoneOnce.Do(func() {
+		var err error
+		if zone, err = time.LoadLocation(config.Cfg.LimitsTimezone); err != nil {
+			log.Printf("Unknown LimitsTimezone %q, using UTC: %v", config.Cfg.LimitsTimezone, err)
+			zone = time.UTC
+		}
+	})
+	now = now.In(zone)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, zone)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, zone)
+	return day, month
+}
+
+// merge lays the set fields of over on top of base
+func merge(base, over models.LimitRule) models.LimitRule {
+	if over.MinPerRequest != nil {
+		base.MinPerRequest = over.MinPerRequest
+	}
+	if over.MaxPerRequest != nil {
+		base.MaxPerRequest = over.MaxPerRequest
+	}
+	if over.DailyCap != nil {
+		base.DailyCap = over.DailyCap
+	}
+	if over.MonthlyCap != nil {
+		base.MonthlyCap = over.MonthlyCap
+	}
+	if over.MaxPending != nil {
+		base.MaxPending = over.MaxPending
+	}
+	return base
+}
+
+func pick(set models.LimitSet, kind string) models.LimitRule {
+	if kind == models.LimitDeposit {
+		return set.Deposit
+	}
+	return set.Withdrawl
+}
+
+// limited reports whether an amount limit is set; zero means none
+func limited(a *money.Amount) bool {
+	return a != nil && a.IsPositive()
+}
+
+// Config returns the stored limits, or an empty config when none are set
+func (s *LimitsService) Config() (*models.LimitsConfig, error) {
+	repo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := repo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	if cnf.Limits == nil {
+		return &models.LimitsConfig{}, nil
+	}
+	return cnf.Limits, nil
+}
+
+// effective resolves a user's rule: global, then their tier, then their override.
+// Users on a tier that was since removed fall back to the global limits.
+func (s *LimitsService) effective(user models.User, kind string) (models.LimitRule, error) {
+	cnf, err := s.Config()
+	if err != nil {
+		return models.LimitRule{}, err
+	}
+	rule := pick(cnf.Global, kind)
+	for _, t := range cnf.Tiers {
+		if t.Code == user.LimitTier {
+			rule = merge(rule, pick(t.Limits, kind))
+			break
+		}
+	}
+	if user.LimitOverride != nil {
+		rule = merge(rule, pick(*user.LimitOverride, kind))
+	}
+	return rule, nil
+}
+
+// Check refuses a new request of kind that would break one of the user's
+// limits. It must run inside the transaction in ctx that inserts the request,
+// which makes concurrent requests from one user take turns.
+func (s *LimitsService) Check(ctx context.Context, kind string, userID primitive.ObjectID, amount money.Amount) error {
+	userRepo := users.UserRepository(&users.UserRepo{})
+	user, err := userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	rule, err := s.effective(user, kind)
+	if err != nil {
+		return err
+	}
+
+	// 1️⃣ Per-request bounds need no lookups
+	if limited(rule.MinPerRequest) && amount.LessThan(*rule.MinPerRequest) {
+		return fmt.Errorf("%w of %s USDT", ErrBelowMinimum, rule.MinPerRequest)
+	}
+	if limited(rule.MaxPerRequest) && amount.GreaterThan(*rule.MaxPerRequest) {
+		return fmt.Errorf("%w of %s USDT", ErrAboveMaximum, rule.MaxPerRequest)
+	}
+	if !limited(rule.DailyCap) && !limited(rule.MonthlyCap) && (rule.MaxPending == nil || *rule.MaxPending <= 0) {
+		return nil
+	}
+
+	// 2️⃣ Velocity limits against what the user already requested
+	dayStart, monthStart := periodStarts(time.Now())
+	repo := limits.LimitsRepository(&limits.LimitsRepo{})
+	used, err := repo.LockedUsage(ctx, kind, userID, dayStart, monthStart)
+	if err != nil {
+		return err
+	}
+	if rule.MaxPending != nil && *rule.MaxPending > 0 && used.Pending >= *rule.MaxPending {
+		return fmt.Errorf("%w: at most %d at a time", ErrTooManyPending, *rule.MaxPending)
+	}
+	if limited(rule.DailyCap) && used.Today.Add(amount).GreaterThan(*rule.DailyCap) {
+		return fmt.Errorf("%w: %s of %s USDT left today", ErrDailyLimit, money.Max(rule.DailyCap.Sub(used.Today), money.Zero()), rule.DailyCap)
+	}
+	if limited(rule.MonthlyCap) && used.ThisMonth.Add(amount).GreaterThan(*rule.MonthlyCap) {
+		return fmt.Errorf("%w: %s of %s USDT left this month", ErrMonthlyLimit, money.Max(rule.MonthlyCap.Sub(used.ThisMonth), money.Zero()), rule.MonthlyCap)
+	}
+	return nil
+}
+
+// Summary shows a user's effective limits, usage and remaining allowance
+func (s *LimitsService) Summary(userID string) (*models.LimitsRes, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	userRepo := users.UserRepository(&users.UserRepo{})
+	user, err := userRepo.GetUserByID(uid)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	res := &models.LimitsRes{UserID: uid, Tier: user.LimitTier}
+	dayStart, monthStart := periodStarts(time.Now())
+	repo := limits.LimitsRepository(&limits.LimitsRepo{})
+	for kind, out := range map[string]*models.LimitStatus{models.LimitDeposit: &res.Deposit, models.LimitWithdrawl: &res.Withdrawl} {
+		rule, err := s.effective(user, kind)
+		if err != nil {
+			return nil, err
+		}
+		used, err := repo.Usage(kind, uid, dayStart, monthStart)
+		if err != nil {
+			return nil, err
+		}
+		*out = models.LimitStatus{Rule: rule, Used: used, Remaining: remaining(rule, used)}
+	}
+	return res, nil
+}
+
+// remaining works out the allowance left; nil fields are unlimited
+func remaining(rule models.LimitRule, used models.LimitUsage) models.LimitRemaining {
+	var rem models.LimitRemaining
+	left := func(cap *money.Amount, spent money.Amount) *money.Amount {
+		if !limited(cap) {
+			return nil
+		}
+		v := money.Max(cap.Sub(spent), money.Zero())
+		return &v
+	}
+	rem.Today = left(rule.DailyCap, used.Today)
+	rem.ThisMonth = left(rule.MonthlyCap, used.ThisMonth)
+	if rule.MaxPending != nil && *rule.MaxPending > 0 {
+		n := *rule.MaxPending - used.Pending
+		if n < 0 {
+			n = 0
+		}
+		rem.Pending = &n
+	}
+
+	// The largest request is the tightest of the amount limits
+	for _, a := range []*money.Amount{limitOrNil(rule.MaxPerRequest), rem.Today, rem.ThisMonth} {
+		if a != nil && (rem.MaxRequest == nil || a.LessThan(*rem.MaxRequest)) {
+			v := *a
+			rem.MaxRequest = &v
+		}
+	}
+	blocked := rem.Pending != nil && *rem.Pending == 0
+	if rem.MaxRequest != nil && limited(rule.MinPerRequest) && rem.MaxRequest.LessThan(*rule.MinPerRequest) {
+		blocked = true
+	}
+	if blocked {
+		zero := money.Zero()
+		rem.MaxRequest = &zero
+	}
+	return rem
+}
+
+func limitOrNil(a *money.Amount) *money.Amount {
+	if !limited(a) {
+		return nil
+	}
+	return a
+}
+
+// UpsertConfig replaces the global limits and tiers. Users on a tier that
+// is no longer listed fall back to the global limits.
+func (s *LimitsService) UpsertConfig(cnf models.LimitsConfig) error {
+	if !validSet(cnf.Global) {
+		return ErrInvalidLimits
+	}
+	seen := make(map[string]bool, len(cnf.Tiers))
+	for i, t := range cnf.Tiers {
+		t.Code = strings.ToLower(strings.TrimSpace(t.Code))
+		t.Name = strings.TrimSpace(t.Name)
+		if !tierCodePattern.MatchString(t.Code) || t.Name == "" || seen[t.Code] || !validSet(t.Limits) {
+			return ErrInvalidLimits
+		}
+		seen[t.Code] = true
+		cnf.Tiers[i] = t
+	}
+
+	adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+	_, err := adminSvc.UpsertAdminConfig(models.AdminConfigData{Limits: &cnf})
+	return err
+}
+
+// SetUserLimits puts a user on a tier and stores their overrides
+func (s *LimitsService) SetUserLimits(userID string, req models.UserLimitsRequest) error {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	tier := strings.ToLower(strings.TrimSpace(req.Tier))
+	if tier != "" {
+		cnf, err := s.Config()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, t := range cnf.Tiers {
+			found = found || t.Code == tier
+		}
+		if !found {
+			return ErrUnknownTier
+		}
+	}
+
+	override := req.Override
+	if override != nil {
+		if !validSet(*override) {
+			return ErrInvalidLimits
+		}
+		if *override == (models.LimitSet{}) {
+			override = nil
+		}
+	}
+
+	userRepo := users.UserRepository(&users.UserRepo{})
+	return userRepo.SetLimits(uid, tier, override)
+}
+
+func validSet(set models.LimitSet) bool {
+	return validRule(set.Deposit) && validRule(set.Withdrawl)
+}
+
+func validRule(r models.LimitRule) bool {
+	for _, a := range []*money.Amount{r.MinPerRequest, r.MaxPerRequest, r.DailyCap, r.MonthlyCap} {
+		if a != nil && a.IsNegative() {
+			return false
+		}
+	}
+	if r.MaxPending != nil && *r.MaxPending < 0 {
+		return false
+	}
+	return !(limited(r.MinPerRequest) && limited(r.MaxPerRequest) && r.MinPerRequest.GreaterThan(*r.MaxPerRequest))
+}
@@ -0,0 +1,202 @@
+package payout
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"p2p/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidFile is returned when the response file can't be read with the template
+var ErrInvalidFile = errors.New("response file does not match the payout template")
+
+// headerScanRows bounds how far into a bank statement the header row is searched
+const headerScanRows = 20
+
+// responseRow is one data row of a bank response file
+type responseRow struct {
+	Line      int
+	Reference string
+	UTR       string
+	Status    string
+	Reason    string
+}
+
+func delimiter(t models.PayoutTemplate) rune {
+	if t.Delimiter == "" {
+		return ','
+	}
+	r, _ := utf8.DecodeRuneInString(t.Delimiter)
+	return r
+}
+
+// writeBatch renders the bank bulk-transfer file for the batch's items
+func writeBatch(batch *models.PayoutBatch, t models.PayoutTemplate, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = delimiter(t)
+
+	if !t.NoHeader {
+		header := make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			header[i] = col.Header
+		}
+		if err := w.Write(header); err != nil {
+			return nil, err
+		}
+	}
+
+	dateFormat := t.DateFormat
+	if dateFormat == "" {
+		dateFormat = "02/01/2006"
+	}
+
+	for _, item := range batch.Items {
+		record := make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			if col.Value != "" {
+				record[i] = col.Value
+				continue
+			}
+			switch col.Field {
+			case models.PayoutFieldReference:
+				record[i] = item.Reference
+			case models.PayoutFieldWithdrawlID:
+				record[i] = item.WithdrawlID.Hex()
+			case models.PayoutFieldAmount:
+				record[i] = item.Amount.String()
+			case models.PayoutFieldINRAmount:
+				record[i] = item.INRAmount.StringFixed(2)
+			case models.PayoutFieldHolderName:
+				record[i] = cellText(item.HolderName)
+			case models.PayoutFieldAccountNumber:
+				record[i] = item.AccountNumber
+			case models.PayoutFieldIFSCCode:
+				record[i] = item.IFSCCode
+			case models.PayoutFieldBankName:
+				record[i] = cellText(item.BankName)
+			case models.PayoutFieldBatchID:
+				record[i] = batch.ID.Hex()
+			case models.PayoutFieldDate:
+				record[i] = now.Format(dateFormat)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cellText drops leading characters spreadsheets treat as a formula; user
+// supplied names end up in files admins open before uploading
+func cellText(s string) string {
+	return strings.TrimLeft(strings.TrimSpace(s), "=+-@\t\r")
+}
+
+// parseResponse reads the bank's response or statement file. Statements
+// often start with a preamble, so the header is the first row that holds
+// the reference column.
+func parseResponse(r io.Reader, t models.PayoutTemplate) ([]responseRow, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter(t)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	col := map[string]int{}
+	line := 0
+	for col[normalizeHeader(t.Response.ReferenceColumn)] == 0 {
+		record, err := reader.Read()
+		if err == io.EOF || line >= headerScanRows {
+			return nil, fmt.Errorf("%w: no %q column", ErrInvalidFile, t.Response.ReferenceColumn)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		line++
+
+		// Column positions are stored 1-based so a missing column reads as 0
+		col = map[string]int{}
+		for i, name := range record {
+			col[normalizeHeader(name)] = i + 1
+		}
+	}
+
+	resp := t.Response
+	for _, name := range []string{resp.UTRColumn, resp.StatusColumn, resp.ReasonColumn} {
+		if name != "" && col[normalizeHeader(name)] == 0 {
+			return nil, fmt.Errorf("%w: no %q column", ErrInvalidFile, name)
+		}
+	}
+
+	cell := func(record []string, name string) string {
+		i := col[normalizeHeader(name)]
+		if name == "" || i == 0 || i > len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i-1])
+	}
+
+	var rows []responseRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+
+		row := responseRow{
+			Line:      line,
+			Reference: cell(record, resp.ReferenceColumn),
+			UTR:       strings.ToUpper(cell(record, resp.UTRColumn)),
+			Status:    strings.ToLower(cell(record, resp.StatusColumn)),
+			Reason:    cell(record, resp.ReasonColumn),
+		}
+		// Blank lines and statement footers carry no reference
+		if row.Reference == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalizeHeader compares column names without case, padding or a UTF-8 BOM
+func normalizeHeader(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+// rowOutcome decides what a response row means for its withdrawal
+func rowOutcome(row responseRow, resp models.PayoutResponse) string {
+	if resp.StatusColumn == "" {
+		if row.UTR != "" {
+			return models.PayoutRowApproved
+		}
+		return models.PayoutRowPending
+	}
+	for _, v := range resp.SuccessValues {
+		if strings.EqualFold(row.Status, strings.TrimSpace(v)) {
+			return models.PayoutRowApproved
+		}
+	}
+	for _, v := range resp.FailureValues {
+		if strings.EqualFold(row.Status, strings.TrimSpace(v)) {
+			return models.PayoutRowFailed
+		}
+	}
+	return models.PayoutRowPending
+}
@@ -0,0 +1,393 @@
+package payout
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"p2p/models"
+	"p2p/repo/admin"
+	"p2p/repo/payout"
+	"p2p/repo/withdrawl"
+	adminService "p2p/services/admin"
+	"p2p/services/twofactor"
+	"p2p/utils/money"
+	"p2p/utils/statemachine"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PayoutServiceInterface covers bank payout batches: build, export, reconcile
+type PayoutServiceInterface interface {
+	CreateBatch(req models.CreatePayoutBatchRequest, actor models.Actor) (*models.PayoutBatchRes, error)
+	ListBatches() ([]models.PayoutBatch, error)
+	GetBatch(batchID string) (*models.PayoutBatch, error)
+	Export(batchID, templateCode string) ([]byte, string, error)
+	Import(batchID, fileName string, file io.Reader, reasonCode string, actor models.Actor) (*models.PayoutImportRes, error)
+	Templates() ([]models.PayoutTemplate, error)
+	UpsertTemplates(templates []models.PayoutTemplate) error
+}
+
+type PayoutService struct{}
+
+// MaxBatchSize caps one bank file; most portals reject larger uploads
+const MaxBatchSize = 500
+
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrBatchNotFound  = payout.ErrBatchNotFound
+	ErrNothingToBatch = errors.New("no withdrawal could be added to the batch")
+	ErrBatchTooLarge  = fmt.Errorf("a payout batch holds at most %d withdrawals", MaxBatchSize)
+	ErrBatchNotReady  = errors.New("payout batch is still being built")
+	ErrBatchClosed    = errors.New("payout batch is already completed")
+
+	// Reasons a withdrawal is skipped when building a batch
+	ErrWithdrawlNotFound      = withdrawl.ErrWithdrawlNotFound
+	ErrWithdrawlNotPending    = withdrawl.ErrWithdrawlNotPending
+	ErrAlreadyInBatch         = withdrawl.ErrAlreadyInBatch
+	ErrNotBatchable           = withdrawl.ErrNotBatchable
+	ErrFirstApprovalRequired  = withdrawl.ErrFirstApprovalRequired
+	ErrSecondApprovalRequired = withdrawl.ErrSecondApprovalRequired
+	ErrSameApprover           = withdrawl.ErrSameApprover
+)
+
+// CreateBatch claims the listed withdrawals, or the oldest UnderReview ones,
+// into a new batch and moves them to Processing. Withdrawals that can't be
+// claimed are skipped and reported; the rest still form the batch.
+func (s *PayoutService) CreateBatch(req models.CreatePayoutBatchRequest, actor models.Actor) (*models.PayoutBatchRes, error) {
+	tmpl, err := s.template(req.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	wdRepo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
+
+	// 1️⃣ Work out which withdrawals to claim
+	ids := make([]string, 0, len(req.IDs))
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		limit := req.Limit
+		if limit <= 0 || limit > MaxBatchSize {
+			limit = MaxBatchSize
+		}
+		// Processing is one step from UnderReview, so reviewed withdrawals are
+		// the ones ready to pay
+		reviewed, err := wdRepo.GetAllByStatus(models.StatusUnderReview)
+		if err != nil {
+			return nil, err
+		}
+		for _, wd := range reviewed {
+			if len(ids) == limit {
+				break
+			}
+			ids = append(ids, wd.ID.Hex())
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ErrNothingToBatch
+	}
+	if len(ids) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	adminRepo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := adminRepo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	dualThreshold := money.Zero()
+	if cnf.DualApprovalThreshold != nil {
+		dualThreshold = *cnf.DualApprovalThreshold
+	}
+
+	// 2️⃣ Batching approves the payout; one TOTP code covers the largest item
+	largest := money.Zero()
+	for _, id := range ids {
+		if wd, err := wdRepo.GetByID(id); err == nil && wd != nil {
+			largest = money.Max(largest, wd.Amount)
+		}
+	}
+	tf := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+	if err := tf.CheckApproval(actor.ID.Hex(), largest, req.TOTPCode); err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ Create the batch first so every claimed withdrawal points at a real document
+	payoutRepo := payout.PayoutRepository(&payout.PayoutRepo{})
+	batch := models.PayoutBatch{
+		ID:        primitive.NewObjectID(),
+		Template:  tmpl.Code,
+		CreatedBy: actor.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := payoutRepo.Create(batch); err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Claim each withdrawal in its own transaction
+	res := &models.PayoutBatchRes{Skipped: []models.BulkItemResult{}}
+	total, totalINR := money.Zero(), money.Zero()
+	for _, id := range ids {
+		wd, err := wdRepo.ClaimForPayout(id, batch.ID, actor, dualThreshold)
+		if err != nil {
+			res.Skipped = append(res.Skipped, models.BulkItemResult{ID: id, Err: err})
+			continue
+		}
+
+		// Pay the INR amount locked at creation; withdrawals from before rates
+		// were locked fall back to their stored rate, then today's
+		inrAmount := wd.INRAmount
+		if !inrAmount.IsPositive() {
+			rate := wd.INRRate
+			if !rate.IsPositive() {
+				rate = cnf.USDTRate
+			}
+			inrAmount = money.ToINR(wd.Amount, rate)
+		}
+		item := models.PayoutItem{
+			WithdrawlID:   wd.ID,
+			Reference:     wd.ID.Hex(),
+			Amount:        wd.Amount,
+			INRAmount:     inrAmount,
+			BankName:      wd.BankName,
+			HolderName:    wd.HolderName,
+			AccountNumber: wd.AccountNumber,
+			IFSCCode:      wd.IFSCCode,
+			Status:        models.StatusProcessing,
+		}
+		batch.Items = append(batch.Items, item)
+		total = total.Add(item.Amount)
+		totalINR = totalINR.Add(item.INRAmount)
+	}
+
+	if len(batch.Items) == 0 {
+		if err := payoutRepo.Delete(batch.ID); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrNothingToBatch, res.Skipped[0].Err)
+	}
+
+	// 5️⃣ Open the batch for export
+	if err := payoutRepo.Finish(batch.ID, batch.Items, total, totalINR); err != nil {
+		return nil, err
+	}
+	res.Batch, err = payoutRepo.GetByID(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *PayoutService) ListBatches() ([]models.PayoutBatch, error) {
+	repo := payout.PayoutRepository(&payout.PayoutRepo{})
+	return repo.List()
+}
+
+func (s *PayoutService) GetBatch(batchID string) (*models.PayoutBatch, error) {
+	oid, err := primitive.ObjectIDFromHex(batchID)
+	if err != nil {
+		return nil, ErrBatchNotFound
+	}
+	repo := payout.PayoutRepository(&payout.PayoutRepo{})
+	return repo.GetByID(oid)
+}
+
+// Export renders the batch with its template, or with templateCode when
+// given, and returns the file with a suggested file name
+func (s *PayoutService) Export(batchID, templateCode string) ([]byte, string, error) {
+	batch, err := s.GetBatch(batchID)
+	if err != nil {
+		return nil, "", err
+	}
+	if batch.Status == models.PayoutBatchBuilding {
+		return nil, "", ErrBatchNotReady
+	}
+
+	if strings.TrimSpace(templateCode) == "" {
+		templateCode = batch.Template
+	}
+	tmpl, err := s.template(templateCode)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	data, err := writeBatch(batch, tmpl, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	repo := payout.PayoutRepository(&payout.PayoutRepo{})
+	if err := repo.MarkExported(batch.ID); err != nil {
+		return nil, "", err
+	}
+
+	name := fmt.Sprintf("payout_%s_%s_%s.csv", tmpl.Code, batch.ID.Hex(), now.Format("20060102"))
+	return data, name, nil
+}
+
+// Import reconciles the bank's response file against the batch. Paid rows
+// approve their withdrawal with the bank's UTR, failed rows fail it under
+// reasonCode with the bank's remark as note; anything else stays Processing
+// for a later file. Re-importing a file is harmless.
+func (s *PayoutService) Import(batchID, fileName string, file io.Reader, reasonCode string, actor models.Actor) (*models.PayoutImportRes, error) {
+	batch, err := s.GetBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+	switch batch.Status {
+	case models.PayoutBatchBuilding:
+		return nil, ErrBatchNotReady
+	case models.PayoutBatchCompleted:
+		return nil, ErrBatchClosed
+	}
+
+	tmpl, err := s.template(batch.Template)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parseResponse(file, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(reasonCode) == "" {
+		reasonCode = models.ReasonBankPayoutFailed
+	}
+
+	items := make(map[string]*models.PayoutItem, len(batch.Items))
+	for i := range batch.Items {
+		items[strings.ToLower(batch.Items[i].Reference)] = &batch.Items[i]
+	}
+
+	reason := "bank payout " + batch.ID.Hex()
+
+	summary := models.PayoutImport{
+		FileName:   fileName,
+		ImportedBy: actor.ID,
+		ImportedAt: time.Now(),
+	}
+	results := make([]models.PayoutRowResult, 0, len(rows))
+
+	for _, row := range rows {
+		result := models.PayoutRowResult{Row: row.Line, Reference: row.Reference, UTR: row.UTR}
+
+		item, ok := items[strings.ToLower(row.Reference)]
+		if !ok {
+			result.Outcome = models.PayoutRowUnmatched
+			summary.Unmatched++
+			results = append(results, result)
+			continue
+		}
+		result.WithdrawlID = item.WithdrawlID.Hex()
+
+		outcome := rowOutcome(row, tmpl.Response)
+		err := s.settleRow(batch.ID, item, outcome, row, reasonCode, reason, actor)
+		switch {
+		case errors.Is(err, errAlreadySettled):
+			result.Outcome = models.PayoutRowSettled
+		case err != nil:
+			result.Outcome = models.PayoutRowError
+			result.Error = err.Error()
+			summary.Errors++
+		default:
+			result.Outcome = outcome
+			switch outcome {
+			case models.PayoutRowApproved:
+				summary.Approved++
+			case models.PayoutRowFailed:
+				summary.Failed++
+			default:
+				summary.Pending++
+			}
+		}
+		results = append(results, result)
+	}
+
+	// Log the import and complete the batch once nothing is in flight
+	payoutRepo := payout.PayoutRepository(&payout.PayoutRepo{})
+	if err := payoutRepo.RecordImport(batch.ID, summary); err != nil {
+		return nil, err
+	}
+	updated, err := payoutRepo.GetByID(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PayoutImportRes{Summary: summary, Rows: results, Batch: updated}, nil
+}
+
+var (
+	errAlreadySettled = errors.New("payout item already settled")
+	errMissingUTR     = errors.New("row marked paid without a UTR")
+	// errPaidNotApproved flags money the bank sent for a withdrawal that was
+	// settled otherwise, e.g. refunded, and needs a human to reconcile
+	errPaidNotApproved = errors.New("bank reports paid but the withdrawal was not approved, reconcile manually")
+)
+
+// settleRow applies one response row to its withdrawal and batch item
+func (s *PayoutService) settleRow(batchID primitive.ObjectID, item *models.PayoutItem, outcome string, row responseRow, reasonCode, reason string, actor models.Actor) error {
+	if item.Status != models.StatusProcessing {
+		if outcome == models.PayoutRowApproved && item.Status != models.StatusApproved {
+			return fmt.Errorf("%w: item is %s", errPaidNotApproved, item.Status)
+		}
+		return errAlreadySettled
+	}
+
+	wdRepo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
+	payoutRepo := payout.PayoutRepository(&payout.PayoutRepo{})
+
+	var status, failureReason string
+	var rejection *models.Rejection
+	switch outcome {
+	case models.PayoutRowApproved:
+		if row.UTR == "" {
+			return errMissingUTR
+		}
+		status = models.StatusApproved
+	case models.PayoutRowFailed:
+		status = models.StatusFailed
+		failureReason = row.Reason
+		adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+		r, err := adminSvc.ResolveRejection(status, reasonCode, row.Reason, actor)
+		if err != nil {
+			return err
+		}
+		rejection = r
+	default:
+		return nil
+	}
+
+	err := wdRepo.SettlePayout(item.WithdrawlID, batchID, status, row.UTR, actor, reason, rejection)
+	if errors.Is(err, withdrawl.ErrWithdrawlNotPending) {
+		// Settled outside the batch (or the item update below failed last
+		// time); bring the item in line with the withdrawal
+		wd, getErr := wdRepo.GetByID(item.WithdrawlID.Hex())
+		if getErr != nil || wd == nil || !statemachine.IsFinal(wd.Status) {
+			return err
+		}
+		err = errAlreadySettled
+		if status == models.StatusApproved && wd.Status != models.StatusApproved {
+			err = fmt.Errorf("%w: withdrawal is %s", errPaidNotApproved, wd.Status)
+		}
+		status, row.UTR, failureReason = wd.Status, wd.UTR, ""
+	} else if err != nil {
+		return err
+	}
+
+	if status != models.StatusApproved {
+		row.UTR = ""
+	}
+	if itemErr := payoutRepo.SettleItem(batchID, item.WithdrawlID, status, row.UTR, failureReason); itemErr != nil {
+		return itemErr
+	}
+	item.Status = status
+	return err
+}
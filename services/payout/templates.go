@@ -0,0 +1,108 @@
+package payout
+
+import (
+	"errors"
+	"p2p/models"
+	"p2p/repo/admin"
+	adminService "p2p/services/admin"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownTemplate  = errors.New("unknown payout template")
+	ErrInvalidTemplates = errors.New("payout_templates must be non-empty with unique codes, valid columns and response column names")
+	templateCodePattern = regexp.MustCompile(`^[a-z0-9_]{2,40}$`)
+)
+
+var exportFields = map[string]bool{
+	models.PayoutFieldReference:     true,
+	models.PayoutFieldWithdrawlID:   true,
+	models.PayoutFieldAmount:        true,
+	models.PayoutFieldINRAmount:     true,
+	models.PayoutFieldHolderName:    true,
+	models.PayoutFieldAccountNumber: true,
+	models.PayoutFieldIFSCCode:      true,
+	models.PayoutFieldBankName:      true,
+	models.PayoutFieldBatchID:       true,
+	models.PayoutFieldDate:          true,
+}
+
+// Templates returns the configured bank templates or the generic default
+func (s *PayoutService) Templates() ([]models.PayoutTemplate, error) {
+	repo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := repo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	if len(cnf.PayoutTemplates) == 0 {
+		return []models.PayoutTemplate{models.DefaultPayoutTemplate}, nil
+	}
+	return cnf.PayoutTemplates, nil
+}
+
+// UpsertTemplates replaces the template list. Batches keep the code they
+// were created with, so removing a template only affects later exports.
+func (s *PayoutService) UpsertTemplates(templates []models.PayoutTemplate) error {
+	if len(templates) == 0 {
+		return ErrInvalidTemplates
+	}
+
+	seen := make(map[string]bool, len(templates))
+	cleaned := make([]models.PayoutTemplate, 0, len(templates))
+	for _, t := range templates {
+		t.Code = strings.ToLower(strings.TrimSpace(t.Code))
+		t.Name = strings.TrimSpace(t.Name)
+		if !templateCodePattern.MatchString(t.Code) || t.Name == "" || seen[t.Code] || !validTemplate(t) {
+			return ErrInvalidTemplates
+		}
+		seen[t.Code] = true
+		cleaned = append(cleaned, t)
+	}
+
+	adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+	_, err := adminSvc.UpsertAdminConfig(models.AdminConfigData{PayoutTemplates: cleaned})
+	return err
+}
+
+func validTemplate(t models.PayoutTemplate) bool {
+	if t.Delimiter != "" {
+		r, size := utf8.DecodeRuneInString(t.Delimiter)
+		if size != len(t.Delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+			return false
+		}
+	}
+	if len(t.Columns) == 0 {
+		return false
+	}
+	for _, col := range t.Columns {
+		// Exactly one of a known field or a literal value
+		if strings.TrimSpace(col.Header) == "" || (col.Field == "") == (col.Value == "") {
+			return false
+		}
+		if col.Field != "" && !exportFields[col.Field] {
+			return false
+		}
+	}
+	resp := t.Response
+	return strings.TrimSpace(resp.ReferenceColumn) != "" && strings.TrimSpace(resp.UTRColumn) != ""
+}
+
+// template finds a template by code; an empty code picks the first one
+func (s *PayoutService) template(code string) (models.PayoutTemplate, error) {
+	templates, err := s.Templates()
+	if err != nil {
+		return models.PayoutTemplate{}, err
+	}
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		return templates[0], nil
+	}
+	for _, t := range templates {
+		if t.Code == code {
+			return t, nil
+		}
+	}
+	return models.PayoutTemplate{}, ErrUnknownTemplate
+}
@@ -0,0 +1,102 @@
+package rates
+
+import (
+	"errors"
+	"p2p/config"
+	"p2p/models"
+	"p2p/repo/admin"
+	"p2p/repo/rates"
+	"p2p/utils/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RateServiceInterface interface {
+	Quote(userID string, kind string) (*models.RateQuote, error)
+	Lock(userID primitive.ObjectID, kind string, quoteID primitive.ObjectID) (money.Amount, error)
+	Release(quoteID primitive.ObjectID)
+}
+
+type RateService struct{}
+
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrRateUnavailable = errors.New("INR rate is not configured")
+	ErrUnknownKind     = errors.New("kind must be deposit or withdrawl")
+	ErrQuoteNotFound   = rates.ErrQuoteNotFound
+	ErrQuoteExpired    = rates.ErrQuoteExpired
+	ErrQuoteUsed       = rates.ErrQuoteUsed
+)
+
+// IsQuoteError reports whether err is about the rate_quote_id the client sent
+func IsQuoteError(err error) bool {
+	return errors.Is(err, ErrQuoteNotFound) || errors.Is(err, ErrQuoteExpired) || errors.Is(err, ErrQuoteUsed)
+}
+
+// currentRate is the admin's usdt_rate
+func currentRate() (money.Amount, error) {
+	repo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := repo.Fetch()
+	if err != nil {
+		return money.Zero(), err
+	}
+	if !cnf.USDTRate.IsPositive() {
+		return money.Zero(), ErrRateUnavailable
+	}
+	return cnf.USDTRate, nil
+}
+
+// Quote snapshots the current rate for the user for RateQuoteTTLSeconds
+func (s *RateService) Quote(userID string, kind string) (*models.RateQuote, error) {
+	if kind != models.LimitDeposit && kind != models.LimitWithdrawl {
+		return nil, ErrUnknownKind
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := currentRate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	q := models.RateQuote{
+		ID:        primitive.NewObjectID(),
+		UserId:    uid,
+		Kind:      kind,
+		Rate:      rate,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(config.Cfg.RateQuoteTTLSeconds) * time.Second),
+	}
+	repo := rates.RateQuoteRepository(&rates.RateQuoteRepo{})
+	if err := repo.Create(q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// Lock returns the rate a new request is created at: the quoted rate when
+// quoteID is set, which redeems it, or the current rate otherwise
+func (s *RateService) Lock(userID primitive.ObjectID, kind string, quoteID primitive.ObjectID) (money.Amount, error) {
+	if quoteID.IsZero() {
+		return currentRate()
+	}
+	repo := rates.RateQuoteRepository(&rates.RateQuoteRepo{})
+	q, err := repo.Redeem(quoteID, userID, kind)
+	if err != nil {
+		return money.Zero(), err
+	}
+	return q.Rate, nil
+}
+
+// Release gives back a quote whose request failed to be created. It is best
+// effort; at worst the user has to ask for a new quote.
+func (s *RateService) Release(quoteID primitive.ObjectID) {
+	if quoteID.IsZero() {
+		return
+	}
+	repo := rates.RateQuoteRepository(&rates.RateQuoteRepo{})
+	_ = repo.Release(quoteID)
+}
@@ -0,0 +1,186 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"p2p/models"
+	"p2p/repo/sessions"
+	"p2p/repo/users"
+	"p2p/repo/wstickets"
+	midleware "p2p/utils/midleWare"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionServiceInterface interface {
+	IssueTokens(user models.User, userAgent, ip string) (*models.AuthTokens, error)
+	Refresh(refreshToken, userAgent, ip string) (*models.AuthTokens, error)
+	Logout(sessionID string) error
+	LogoutAll(userID primitive.ObjectID) (int64, error)
+	IssueWebSocketTicket(claims midleware.Claims) (*models.WebSocketTicketRes, error)
+}
+
+type SessionService struct{}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrUserBlocked         = errors.New("User Blocked Contact Admin")
+	ErrSessionNotFound     = sessions.ErrSessionNotFound
+)
+
+// IssueTokens opens a new session for a signed in user and returns its tokens
+func (s *SessionService) IssueTokens(user models.User, userAgent, ip string) (*models.AuthTokens, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	repo := sessions.SessionRepository(&sessions.SessionRepo{})
+	sessionID, err := repo.Create(models.Session{
+		UserId:      user.ID,
+		Role:        user.Role,
+		RefreshHash: refreshHash,
+		UserAgent:   userAgent,
+		IP:          ip,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(midleware.RefreshTokenTTL),
+	})
+	if err != nil {
+		log.Println("Error creating session:", err)
+		return nil, err
+	}
+
+	return buildTokens(user, sessionID, refreshToken, now.Add(midleware.RefreshTokenTTL))
+}
+
+// Refresh rotates the refresh token and issues a new access token. Presenting
+// an already rotated token revokes the whole session, since it means the
+// token leaked.
+func (s *SessionService) Refresh(refreshToken, userAgent, ip string) (*models.AuthTokens, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	repo := sessions.SessionRepository(&sessions.SessionRepo{})
+	oldHash := hashToken(refreshToken)
+
+	// 1️⃣ Find the session owning this token
+	session, reused, err := repo.FindByRefreshHash(oldHash)
+	if err != nil {
+		if errors.Is(err, sessions.ErrSessionNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if reused {
+		if err := repo.Revoke(session.ID, "refresh token reuse"); err != nil {
+			log.Println("Error revoking session after reuse:", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 2️⃣ Re-check the user, a block must stop refreshes too
+	user, err := users.UserRepository(&users.UserRepo{}).GetUserByID(session.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBlocked {
+		return nil, ErrUserBlocked
+	}
+
+	// 3️⃣ Rotate; losing the race to a concurrent refresh counts as invalid
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(midleware.RefreshTokenTTL)
+	if err := repo.Rotate(session.ID, oldHash, newHash, expiresAt); err != nil {
+		if errors.Is(err, sessions.ErrSessionRotated) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	return buildTokens(user, session.ID, newToken, expiresAt)
+}
+
+func (s *SessionService) Logout(sessionID string) error {
+	id, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	repo := sessions.SessionRepository(&sessions.SessionRepo{})
+	return repo.Revoke(id, "logout")
+}
+
+func (s *SessionService) LogoutAll(userID primitive.ObjectID) (int64, error) {
+	repo := sessions.SessionRepository(&sessions.SessionRepo{})
+	return repo.RevokeAllForUser(userID, "logout all devices")
+}
+
+// IssueWebSocketTicket hands out a single-use ticket for opening the chat
+// socket in the caller's session. It stands in for the access token in the
+// URL, where proxies and access logs would keep it.
+func (s *SessionService) IssueWebSocketTicket(claims midleware.Claims) (*models.WebSocketTicketRes, error) {
+	ticket, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	repo := wstickets.TicketRepository(&wstickets.TicketRepo{})
+	if err := repo.Create(models.WebSocketTicket{
+		ID:        hash,
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		Role:      claims.Role,
+		Email:     claims.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(midleware.WebSocketTicketTTL),
+	}); err != nil {
+		return nil, err
+	}
+	return &models.WebSocketTicketRes{Ticket: ticket, ExpiresIn: int64(midleware.WebSocketTicketTTL.Seconds())}, nil
+}
+
+func buildTokens(user models.User, sessionID primitive.ObjectID, refreshToken string, refreshExpiresAt time.Time) (*models.AuthTokens, error) {
+	accessToken, err := midleware.GenerateJWT(user.Email, user.ID.Hex(), user.Role, sessionID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthTokens{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(midleware.AccessTokenTTL.Seconds()),
+		RefreshExpiresAt: refreshExpiresAt,
+		SessionID:        sessionID.Hex(),
+	}, nil
+}
+
+// newRefreshToken returns an opaque random token and the hash that is stored
+func newRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,490 @@
+package tickets
+
+import (
+	"errors"
+	"log"
+	"p2p/models"
+	"p2p/repo/deposit"
+	"p2p/repo/tickets"
+	"p2p/repo/users"
+	"p2p/repo/withdrawl"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/realtime"
+	"p2p/utils/statemachine"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TicketServiceInterface interface {
+	Open(actor models.Actor, req models.TicketRequest, files []string) (*models.TicketRes, error)
+	Get(id string) (*models.TicketRes, error)
+	ListForUser(userID string) ([]models.Ticket, error)
+	Queue(status, priority, assignee string, actor models.Actor) ([]models.TicketQueueItem, error)
+	Reply(id string, actor models.Actor, message string, files []string) (*models.TicketMessage, error)
+	Update(id string, actor models.Actor, req models.TicketUpdateRequest) (*models.Ticket, error)
+	Close(id string, actor models.Actor) (*models.Ticket, error)
+}
+
+type TicketService struct{}
+
+const (
+	maxSubjectLength = 120
+	maxMessageLength = 4000
+	// MaxAttachments caps the files on one ticket message
+	MaxAttachments = 5
+)
+
+// SLA is how long support has to first answer and to resolve a ticket
+type SLA struct {
+	FirstResponse time.Duration
+	Resolve       time.Duration
+}
+
+// SLAs by priority, counted from when the ticket was opened
+var SLAs = map[string]SLA{
+	models.TicketPriorityUrgent: {FirstResponse: time.Hour, Resolve: 8 * time.Hour},
+	models.TicketPriorityHigh:   {FirstResponse: 4 * time.Hour, Resolve: 24 * time.Hour},
+	models.TicketPriorityNormal: {FirstResponse: 8 * time.Hour, Resolve: 72 * time.Hour},
+	models.TicketPriorityLow:    {FirstResponse: 24 * time.Hour, Resolve: 120 * time.Hour},
+}
+
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrTicketNotFound    = tickets.ErrTicketNotFound
+	ErrTicketExists      = tickets.ErrTicketExists
+	ErrTicketChanged     = tickets.ErrTicketChanged
+	ErrIllegalTransition = statemachine.ErrIllegalTransition
+	ErrTicketClosed      = errors.New("ticket is closed")
+	ErrRefNotFound       = errors.New("referenced deposit or withdrawl not found")
+	ErrInvalidRefKind    = errors.New("ref_kind must be deposit or withdrawl")
+	ErrInvalidPriority   = errors.New("priority must be low, normal, high or urgent")
+	ErrInvalidSubject    = errors.New("subject must be 1 to 120 characters")
+	ErrInvalidMessage    = errors.New("message can't be empty or longer than 4000 characters")
+	ErrInvalidAssignee   = errors.New("tickets can only be assigned to admins")
+	ErrNothingToUpdate   = errors.New("nothing to update")
+)
+
+// IsValidationError reports whether err comes from bad ticket input
+func IsValidationError(err error) bool {
+	for _, target := range []error{ErrInvalidRefKind, ErrInvalidPriority, ErrInvalidSubject, ErrInvalidMessage, ErrInvalidAssignee, ErrNothingToUpdate} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseID(id string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, ErrTicketNotFound
+	}
+	return oid, nil
+}
+
+func validMessage(message string, files []string) (string, error) {
+	message = strings.TrimSpace(message)
+	if (message == "" && len(files) == 0) || len([]rune(message)) > maxMessageLength {
+		return "", ErrInvalidMessage
+	}
+	return message, nil
+}
+
+// refOwner returns who owns the referenced deposit or withdrawal
+func refOwner(kind string, refID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	switch kind {
+	case models.TicketRefDeposit:
+		repo := deposit.DepositRepository(&deposit.DepositRepo{})
+		dep, err := repo.GetByID(refID)
+		if err != nil || dep == nil {
+			return primitive.NilObjectID, primitive.NilObjectID, ErrRefNotFound
+		}
+		return dep.ID, dep.UserId, nil
+	case models.TicketRefWithdrawl:
+		repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
+		wd, err := repo.GetByID(refID)
+		if err != nil || wd == nil {
+			return primitive.NilObjectID, primitive.NilObjectID, ErrRefNotFound
+		}
+		return wd.ID, wd.UserId, nil
+	}
+	return primitive.NilObjectID, primitive.NilObjectID, ErrInvalidRefKind
+}
+
+// Open starts a ticket about one of the user's own deposits or withdrawals
+func (s *TicketService) Open(actor models.Actor, req models.TicketRequest, files []string) (*models.TicketRes, error) {
+	// 1️⃣ Validate the request
+	kind := strings.ToLower(strings.TrimSpace(req.RefKind))
+	subject := strings.Join(strings.Fields(req.Subject), " ")
+	if n := len([]rune(subject)); n == 0 || n > maxSubjectLength {
+		return nil, ErrInvalidSubject
+	}
+	message, err := validMessage(req.Message, files)
+	if err != nil {
+		return nil, err
+	}
+	priority := strings.ToLower(strings.TrimSpace(req.Priority))
+	if priority == "" {
+		priority = models.TicketPriorityNormal
+	}
+	sla, ok := SLAs[priority]
+	if !ok {
+		return nil, ErrInvalidPriority
+	}
+
+	// 2️⃣ Users can only raise tickets about their own requests
+	refID, ownerID, err := refOwner(kind, req.RefID)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != actor.ID {
+		return nil, ErrRefNotFound
+	}
+
+	// 3️⃣ Store it with its SLA deadlines
+	now := time.Now()
+	t := models.Ticket{
+		UserId:           actor.ID,
+		RefKind:          kind,
+		RefID:            refID,
+		Subject:          subject,
+		Status:           models.TicketOpen,
+		Priority:         priority,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		FirstResponseDue: now.Add(sla.FirstResponse),
+		ResolveDue:       now.Add(sla.Resolve),
+	}
+	first := models.TicketMessage{
+		AuthorID:   actor.ID,
+		AuthorRole: actor.Role,
+		Message:    message,
+		FilesUrl:   files,
+	}
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	created, err := repo.Create(t, first)
+	if err != nil {
+		return nil, err
+	}
+	first.TicketID, first.CreatedAt = created.ID, created.CreatedAt
+	return &models.TicketRes{Ticket: *created, Messages: []models.TicketMessage{first}}, nil
+}
+
+// Get returns a ticket with its whole thread
+func (s *TicketService) Get(id string) (*models.TicketRes, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	t, err := repo.GetByID(oid)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := repo.Messages(oid)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TicketRes{Ticket: *t, Messages: messages}, nil
+}
+
+func (s *TicketService) ListForUser(userID string) ([]models.Ticket, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	return repo.ListByUser(uid)
+}
+
+// Queue lists active tickets for admins, the most urgent deadline first.
+// assignee is "me", "none" or an admin's ID; status defaults to Open and
+// Pending.
+func (s *TicketService) Queue(status, priority, assignee string, actor models.Actor) ([]models.TicketQueueItem, error) {
+	filter := tickets.QueueFilter{Statuses: models.TicketActiveStatuses}
+	if status != "" {
+		st, err := normalizeStatus(status)
+		if err != nil {
+			return nil, err
+		}
+		filter.Statuses = []string{st}
+	}
+	if priority != "" {
+		filter.Priority = strings.ToLower(priority)
+		if _, ok := SLAs[filter.Priority]; !ok {
+			return nil, ErrInvalidPriority
+		}
+	}
+	switch assignee {
+	case "":
+	case "me":
+		filter.AssigneeID = &actor.ID
+	case "none":
+		filter.Unassigned = true
+	default:
+		oid, err := primitive.ObjectIDFromHex(assignee)
+		if err != nil {
+			return nil, ErrInvalidAssignee
+		}
+		filter.AssigneeID = &oid
+	}
+
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	list, err := repo.Queue(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	items := make([]models.TicketQueueItem, len(list))
+	for i, t := range list {
+		items[i] = queueItem(t, now)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].NextDue.Before(items[j].NextDue) })
+	return items, nil
+}
+
+// queueItem works out which SLA clock is running and how long is left
+func queueItem(t models.Ticket, now time.Time) models.TicketQueueItem {
+	item := models.TicketQueueItem{Ticket: t, NextDue: t.ResolveDue, WaitingOn: "support"}
+	if t.FirstResponseAt == nil {
+		item.NextDue = t.FirstResponseDue
+	}
+	if t.Status == models.TicketPending {
+		item.WaitingOn = "user"
+	}
+	item.DueIn = int64(item.NextDue.Sub(now).Seconds())
+	item.Breached = t.Status == models.TicketOpen || t.Status == models.TicketPending
+	item.Breached = item.Breached && now.After(item.NextDue)
+	return item
+}
+
+func normalizeStatus(status string) (string, error) {
+	for _, st := range []string{models.TicketOpen, models.TicketPending, models.TicketResolved, models.TicketClosed} {
+		if strings.EqualFold(strings.TrimSpace(status), st) {
+			return st, nil
+		}
+	}
+	return "", statemachine.ErrUnknownStatus
+}
+
+// Reply adds a message to the thread. Support answering hands the ticket
+// to the user (Pending); the user answering hands it back (Open), which also
+// reopens a Resolved ticket.
+func (s *TicketService) Reply(id string, actor models.Actor, message string, files []string) (*models.TicketMessage, error) {
+	message, err := validMessage(message, files)
+	if err != nil {
+		return nil, err
+	}
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	t, err := repo.GetByID(oid)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == models.TicketClosed {
+		return nil, ErrTicketClosed
+	}
+
+	// 1️⃣ Work out the status hand-off before writing anything
+	now := time.Now()
+	patch := tickets.Patch{}
+	next := models.TicketOpen
+	if actor.Role == midleware.RoleAdmin {
+		next = models.TicketPending
+		if t.FirstResponseAt == nil {
+			patch.FirstResponseAt = &now
+		}
+	}
+	if t.Status != next {
+		change, err := statemachine.Ticket.Step(t.Status, next, actor, "reply", now)
+		if err != nil {
+			return nil, err
+		}
+		patch.Status, patch.History = &next, []models.StatusChange{change}
+		patch.ClearResolvedAt = t.Status == models.TicketResolved
+	}
+	updated, err := repo.Update(oid, t.Status, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2️⃣ Then the message itself
+	msg, err := repo.AddMessage(models.TicketMessage{
+		TicketID:   oid,
+		AuthorID:   actor.ID,
+		AuthorRole: actor.Role,
+		Message:    message,
+		FilesUrl:   files,
+	})
+	if err != nil {
+		return nil, err
+	}
+	notify(*updated, models.TicketEventMessage, msg)
+	return msg, nil
+}
+
+// Update lets admins move, reprioritise or assign a ticket. A new priority
+// recomputes the SLA deadlines from when the ticket was opened.
+func (s *TicketService) Update(id string, actor models.Actor, req models.TicketUpdateRequest) (*models.Ticket, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	t, err := repo.GetByID(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	patch := tickets.Patch{}
+	changed := false
+
+	// 1️⃣ Status through the ticket state machine
+	if req.Status != "" {
+		to, err := normalizeStatus(req.Status)
+		if err != nil {
+			return nil, err
+		}
+		if to != t.Status {
+			change, err := statemachine.Ticket.Step(t.Status, to, actor, strings.TrimSpace(req.Reason), now)
+			if err != nil {
+				return nil, err
+			}
+			patch.Status, patch.History = &to, []models.StatusChange{change}
+			switch {
+			case to == models.TicketResolved:
+				patch.ResolvedAt = &now
+			case t.Status == models.TicketResolved && to != models.TicketClosed:
+				patch.ClearResolvedAt = true
+			}
+			changed = true
+		}
+	}
+
+	// 2️⃣ Priority and its deadlines
+	if req.Priority != "" {
+		priority := strings.ToLower(strings.TrimSpace(req.Priority))
+		sla, ok := SLAs[priority]
+		if !ok {
+			return nil, ErrInvalidPriority
+		}
+		if priority != t.Priority {
+			firstDue, resolveDue := t.CreatedAt.Add(sla.FirstResponse), t.CreatedAt.Add(sla.Resolve)
+			patch.Priority, patch.FirstResponseDue, patch.ResolveDue = &priority, &firstDue, &resolveDue
+			changed = true
+		}
+	}
+
+	// 3️⃣ Assignee, who must be an admin
+	if req.Unassign {
+		patch.Unassign = t.AssigneeID != nil
+		changed = changed || patch.Unassign
+	} else if req.AssigneeID != "" {
+		assignee, err := primitive.ObjectIDFromHex(req.AssigneeID)
+		if err != nil {
+			return nil, ErrInvalidAssignee
+		}
+		userRepo := users.UserRepository(&users.UserRepo{})
+		user, err := userRepo.GetUserByID(assignee)
+		if err != nil || user.Role != midleware.RoleAdmin {
+			return nil, ErrInvalidAssignee
+		}
+		if t.AssigneeID == nil || *t.AssigneeID != assignee {
+			patch.AssigneeID = &assignee
+			changed = true
+		}
+	}
+
+	if !changed {
+		if req.Status == "" && req.Priority == "" && req.AssigneeID == "" && !req.Unassign {
+			return nil, ErrNothingToUpdate
+		}
+		return t, nil
+	}
+	updated, err := repo.Update(oid, t.Status, patch)
+	if err != nil {
+		return nil, err
+	}
+	notify(*updated, models.TicketEventUpdated, updated)
+	return updated, nil
+}
+
+// Close is the owner giving up on or settling their own ticket
+func (s *TicketService) Close(id string, actor models.Actor) (*models.Ticket, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	t, err := repo.GetByID(oid)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserId != actor.ID {
+		return nil, ErrTicketNotFound
+	}
+
+	closed := models.TicketClosed
+	change, err := statemachine.Ticket.Step(t.Status, closed, actor, "closed by user", time.Now())
+	if err != nil {
+		return nil, err
+	}
+	updated, err := repo.Update(oid, t.Status, tickets.Patch{Status: &closed, History: []models.StatusChange{change}})
+	if err != nil {
+		return nil, err
+	}
+	notify(*updated, models.TicketEventUpdated, updated)
+	return updated, nil
+}
+
+// notify pushes a ticket event to the owner and the assignee over /chat/ws
+func notify(t models.Ticket, eventType string, data any) {
+	ids := []string{t.UserId.Hex()}
+	if t.AssigneeID != nil {
+		ids = append(ids, t.AssigneeID.Hex())
+	}
+	realtime.Default().Publish(eventType, data, ids...)
+}
+
+// AttachDeposits fills OpenTickets on deposits. Tickets are extra context,
+// so a failed lookup is logged and the deposits are returned as they are.
+func AttachDeposits(list []models.DepositRes) {
+	ids := make([]primitive.ObjectID, len(list))
+	for i, d := range list {
+		ids[i] = d.ID
+	}
+	byRef := activeForRefs(models.TicketRefDeposit, ids)
+	for i := range list {
+		list[i].OpenTickets = byRef[list[i].ID]
+	}
+}
+
+// AttachWithdrawls fills OpenTickets on withdrawals, like AttachDeposits
+func AttachWithdrawls(list []models.WithdrawlRes) {
+	ids := make([]primitive.ObjectID, len(list))
+	for i, w := range list {
+		ids[i] = w.ID
+	}
+	byRef := activeForRefs(models.TicketRefWithdrawl, ids)
+	for i := range list {
+		list[i].OpenTickets = byRef[list[i].ID]
+	}
+}
+
+func activeForRefs(kind string, ids []primitive.ObjectID) map[primitive.ObjectID][]models.TicketSummary {
+	repo := tickets.TicketRepository(&tickets.TicketRepo{})
+	byRef, err := repo.ActiveForRefs(kind, ids)
+	if err != nil {
+		log.Printf("Failed to load open tickets for %s requests: %v", kind, err)
+		return nil
+	}
+	return byRef
+}
@@ -0,0 +1,288 @@
+package twofactor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"p2p/models"
+	"p2p/repo/admin"
+	"p2p/repo/preauth"
+	"p2p/repo/users"
+	midleware "p2p/utils/midleWare"
+	"p2p/utils/money"
+	"p2p/utils/totp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	issuer            = "P2P"
+	recoveryCodeCount = 10
+	// loginMaxAttempts is how many codes one pre-auth token may try
+	loginMaxAttempts = 5
+)
+
+type TwoFactorServiceInterface interface {
+	Enroll(userID string) (*models.TOTPEnrollRes, error)
+	ConfirmEnrollment(userID, code string) ([]string, error)
+	Disable(userID string, req models.TOTPCodeRequest) error
+	RegenerateRecoveryCodes(userID, code string) ([]string, error)
+	CompleteLogin(req models.TwoFactorLoginRequest, role string) (models.User, error)
+	CheckApproval(adminID string, amount money.Amount, code string) error
+}
+
+type TwoFactorService struct{}
+
+var (
+	ErrInvalidCode         = errors.New("invalid two-factor code")
+	ErrAlreadyEnabled      = errors.New("two-factor authentication already enabled")
+	ErrNotEnabled          = errors.New("two-factor authentication not enabled")
+	ErrNoPendingEnrollment = errors.New("start enrollment first")
+	ErrInvalidPreAuth      = errors.New("invalid or expired pre-auth token")
+	ErrCodeRequired        = errors.New("totp_code is required to approve this amount")
+	ErrEnrollmentRequired  = errors.New("enable two-factor authentication to approve this amount")
+	ErrUserBlocked         = errors.New("User Blocked Contact Admin")
+	ErrTooManyAttempts     = errors.New("too many attempts, sign in again")
+)
+
+// Enroll generates a new secret and keeps it pending until a code proves
+// the authenticator app has it
+func (s *TwoFactorService) Enroll(userID string) (*models.TOTPEnrollRes, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	repo := users.UserRepository(&users.UserRepo{})
+	if err := repo.SetPendingTOTP(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollRes{
+		Secret:     secret,
+		OtpauthURI: totp.URI(issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables 2FA and returns the plain recovery codes, which
+// are shown this one time only
+func (s *TwoFactorService) ConfirmEnrollment(userID, code string) ([]string, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+	if user.TOTPPendingSecret == "" {
+		return nil, ErrNoPendingEnrollment
+	}
+
+	step, ok := totp.Validate(user.TOTPPendingSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := users.UserRepository(&users.UserRepo{})
+	if err := repo.EnableTOTP(user.ID, user.TOTPPendingSecret, hashes); err != nil {
+		return nil, err
+	}
+	if err := repo.ClaimTOTPStep(user.ID, step); err != nil {
+		log.Println("Error recording totp step:", err)
+	}
+	return codes, nil
+}
+
+func (s *TwoFactorService) Disable(userID string, req models.TOTPCodeRequest) error {
+	user, err := getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrNotEnabled
+	}
+	if err := verifySecondFactor(user, req); err != nil {
+		return err
+	}
+
+	repo := users.UserRepository(&users.UserRepo{})
+	return repo.DisableTOTP(user.ID)
+}
+
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrNotEnabled
+	}
+	if err := verifySecondFactor(user, models.TOTPCodeRequest{Code: code}); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	repo := users.UserRepository(&users.UserRepo{})
+	if err := repo.SetRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CompleteLogin checks the pre-auth token from the password step and the
+// second factor, returning the user to open a session for
+func (s *TwoFactorService) CompleteLogin(req models.TwoFactorLoginRequest, role string) (models.User, error) {
+	claims, err := midleware.ValidatePreAuthToken(req.PreAuthToken)
+	if err != nil || claims.Role != role {
+		return models.User{}, ErrInvalidPreAuth
+	}
+
+	user, err := getUser(claims.UserID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.IsBlocked {
+		return models.User{}, ErrUserBlocked
+	}
+	if !user.TOTPEnabled {
+		return models.User{}, ErrNotEnabled
+	}
+
+	// Every try counts against the token, and it dies after the last one
+	preAuthRepo := preauth.PreAuthRepository(&preauth.PreAuthRepo{})
+	attempt, err := preAuthRepo.RecordAttempt(claims.ID, user.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return models.User{}, err
+	}
+	if attempt.CompletedAt != nil {
+		return models.User{}, ErrInvalidPreAuth
+	}
+	if attempt.Attempts > loginMaxAttempts {
+		return models.User{}, ErrTooManyAttempts
+	}
+	if err := verifySecondFactor(user, req.TOTPCodeRequest); err != nil {
+		if errors.Is(err, ErrInvalidCode) && attempt.Attempts == loginMaxAttempts {
+			return models.User{}, ErrTooManyAttempts
+		}
+		return models.User{}, err
+	}
+	if err := preAuthRepo.Complete(claims.ID); err != nil {
+		if errors.Is(err, preauth.ErrPreAuthUsed) {
+			return models.User{}, ErrInvalidPreAuth
+		}
+		return models.User{}, err
+	}
+
+	adminRepo := admin.AdminRepository(&admin.AdminRepo{})
+	if cnf, err := adminRepo.Fetch(); err == nil {
+		user.INRBalance = money.ToINR(user.Balance, cnf.USDTRate)
+	}
+	return user, nil
+}
+
+// CheckApproval enforces the configured TOTP threshold on admin approvals.
+// Below the threshold, or with no threshold set, it always passes.
+func (s *TwoFactorService) CheckApproval(adminID string, amount money.Amount, code string) error {
+	adminRepo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := adminRepo.Fetch()
+	if err != nil {
+		return err
+	}
+	if !NeedsApprovalCode(cnf, amount) {
+		return nil
+	}
+
+	user, err := getUser(adminID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrEnrollmentRequired
+	}
+	if strings.TrimSpace(code) == "" {
+		return ErrCodeRequired
+	}
+	// Recovery codes are for login only, approvals need the live app
+	return verifySecondFactor(user, models.TOTPCodeRequest{Code: code})
+}
+
+// NeedsApprovalCode reports whether approving amount is at or above the
+// configured TOTP threshold. Such approvals always need an admin.
+func NeedsApprovalCode(cnf *models.AdminConfigData, amount money.Amount) bool {
+	return cnf.TOTPApprovalThreshold != nil && cnf.TOTPApprovalThreshold.IsPositive() && !amount.LessThan(*cnf.TOTPApprovalThreshold)
+}
+
+// verifySecondFactor accepts a TOTP code, burning its step, or a recovery code
+func verifySecondFactor(user models.User, req models.TOTPCodeRequest) error {
+	repo := users.UserRepository(&users.UserRepo{})
+
+	if rc := strings.TrimSpace(req.RecoveryCode); rc != "" && req.Code == "" {
+		if err := repo.ConsumeRecoveryCode(user.ID, hashRecoveryCode(rc)); err != nil {
+			if errors.Is(err, users.ErrRecoveryCodeInvalid) {
+				return ErrInvalidCode
+			}
+			return err
+		}
+		return nil
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return ErrInvalidCode
+	}
+	if err := repo.ClaimTOTPStep(user.ID, step); err != nil {
+		if errors.Is(err, users.ErrTOTPReplay) {
+			return ErrInvalidCode
+		}
+		return err
+	}
+	return nil
+}
+
+func getUser(userID string) (models.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return models.User{}, errors.New("invalid user ID")
+	}
+	repo := users.UserRepository(&users.UserRepo{})
+	return repo.GetUserByID(id)
+}
+
+// newRecoveryCodes returns plain codes for the user and SHA-256 hashes to store.
+// The codes carry 50 random bits so a fast hash is enough and lets Mongo
+// match and pull them atomically.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashRecoveryCode(c)
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
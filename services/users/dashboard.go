@@ -5,7 +5,7 @@ import (
 	"p2p/models"
 	"p2p/repo/admin"
 	"p2p/repo/users"
-	"strconv"
+	"p2p/utils/money"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -31,18 +31,9 @@ func (s *DashboardService) GetUserDashboard(userId primitive.ObjectID) (*models.
 		cnf = &models.AdminConfigData{} // empty defaults
 	}
 
-	// Convert USD rate safely
-	usdRate := 0.00 // default if cnf.USDTRate is empty or invalid
-	if cnf.USDTRate != "" {
-		if f, err := strconv.ParseFloat(cnf.USDTRate, 64); err == nil {
-			usdRate = f
-		} else {
-			log.Println("Error converting USDTRate to float64:", err)
-		}
-	}
-
+	// INR value rounds to paise; a missing rate yields zero
 	res.SellPrice = cnf.USDTRate
-	res.INRBalance = res.Balance * usdRate
+	res.INRBalance = money.ToINR(res.Balance, cnf.USDTRate)
 	res.WalletAddress = cnf.SecureWalletAddress
 
 	return res, nil
@@ -0,0 +1,185 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"p2p/models"
+	"p2p/repo/passwordreset"
+	"p2p/repo/sessions"
+	"p2p/repo/users"
+	"p2p/utils"
+	"p2p/utils/notify"
+	"strings"
+	"time"
+)
+
+const (
+	resetCodeTTL      = 10 * time.Minute
+	resetMaxAttempts  = 5
+	resetResendWindow = time.Minute
+	// Guesses allowed per email across all codes issued within the window
+	resetEmailMaxAttempts = 10
+	resetEmailWindow      = 24 * time.Hour
+	minPasswordLength     = 8
+)
+
+var (
+	ErrInvalidResetCode = errors.New("invalid or expired reset code")
+	ErrTooManyAttempts  = errors.New("too many attempts, request a new code")
+	ErrWeakPassword     = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+)
+
+// RequestPasswordReset emails a one-time code to the account owner. It
+// returns nil for unknown emails so the endpoint can't be used to probe
+// which accounts exist.
+func (s *UserService) RequestPasswordReset(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("email can't be empty")
+	}
+
+	repo := users.UserRepository(&users.UserRepo{})
+	user, err := repo.GetUserByEmail(email)
+	if err != nil {
+		log.Println("Password reset requested for unknown email:", email)
+		return nil
+	}
+
+	resetRepo := passwordreset.PasswordResetRepository(&passwordreset.PasswordResetRepo{})
+
+	// 1️⃣ Throttle: don't issue a fresh code while a recent one is still live,
+	// or once the email has used up its guesses for the window
+	if attempts, err := resetRepo.AttemptsSince(user.Email, time.Now().Add(-resetEmailWindow)); err != nil {
+		return err
+	} else if attempts >= resetEmailMaxAttempts {
+		log.Println("Password reset withheld, too many attempts for:", user.Email)
+		return nil
+	}
+	if active, err := resetRepo.GetActive(user.Email); err == nil {
+		if time.Since(active.CreatedAt) < resetResendWindow {
+			return nil
+		}
+	} else if !errors.Is(err, passwordreset.ErrResetNotFound) {
+		return err
+	}
+
+	// 2️⃣ Store only the bcrypt hash of the code
+	code, err := newResetCode()
+	if err != nil {
+		return err
+	}
+	codeHash, err := utils.HashPassword(code)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := resetRepo.Create(models.PasswordReset{
+		UserId:    user.ID,
+		Email:     user.Email,
+		CodeHash:  codeHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(resetCodeTTL),
+	}); err != nil {
+		log.Println("Error storing password reset:", err)
+		return err
+	}
+
+	// 3️⃣ Deliver
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return notify.FromConfig().Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Your password reset code",
+		Body: fmt.Sprintf("Your password reset code is %s. It expires in %d minutes.\n\nIf you did not request this, ignore this email.",
+			code, int(resetCodeTTL.Minutes())),
+	})
+}
+
+// ConfirmPasswordReset sets a new password when the code matches, then
+// revokes every session the user had open
+func (s *UserService) ConfirmPasswordReset(req models.ResetPasswordRequest) error {
+	email := strings.TrimSpace(req.Email)
+	code := strings.TrimSpace(req.Code)
+	if email == "" || code == "" {
+		return ErrInvalidResetCode
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		return ErrWeakPassword
+	}
+
+	resetRepo := passwordreset.PasswordResetRepository(&passwordreset.PasswordResetRepo{})
+
+	// 1️⃣ Latest live reset for this email
+	reset, err := resetRepo.GetActive(email)
+	if err != nil {
+		if errors.Is(err, passwordreset.ErrResetNotFound) {
+			return ErrInvalidResetCode
+		}
+		return err
+	}
+
+	// 2️⃣ Count the guess before checking it, against this code and against
+	// every code the email was sent recently
+	attempts, err := resetRepo.RecordAttempt(reset.ID)
+	if err != nil {
+		if errors.Is(err, passwordreset.ErrResetNotFound) {
+			return ErrInvalidResetCode
+		}
+		return err
+	}
+	if attempts > resetMaxAttempts {
+		return ErrTooManyAttempts
+	}
+	total, err := resetRepo.AttemptsSince(reset.Email, time.Now().Add(-resetEmailWindow))
+	if err != nil {
+		return err
+	}
+	if total > resetEmailMaxAttempts {
+		return ErrTooManyAttempts
+	}
+
+	if err := utils.CheckPasswordHash(code, reset.CodeHash); err != nil {
+		return ErrInvalidResetCode
+	}
+
+	// 3️⃣ Consume the code before changing anything
+	if err := resetRepo.MarkUsed(reset.ID); err != nil {
+		if errors.Is(err, passwordreset.ErrResetAlreadyUsed) {
+			return ErrInvalidResetCode
+		}
+		return err
+	}
+
+	hashedPwd, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		log.Println("Error hashing password:", err)
+		return err
+	}
+	repo := users.UserRepository(&users.UserRepo{})
+	if err := repo.UpdatePassword(reset.Email, hashedPwd); err != nil {
+		return err
+	}
+
+	// 4️⃣ Anyone holding an old token is logged out
+	sessionRepo := sessions.SessionRepository(&sessions.SessionRepo{})
+	if _, err := sessionRepo.RevokeAllForUser(reset.UserId, "password reset"); err != nil {
+		log.Println("Error revoking sessions after password reset:", err)
+		return err
+	}
+	return nil
+}
+
+// newResetCode returns a uniformly random 6-digit code
+func newResetCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
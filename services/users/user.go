@@ -5,28 +5,26 @@ import (
 	"log"
 	"p2p/models"
 	"p2p/repo/admin"
+	"p2p/repo/sessions"
 	"p2p/repo/users"
 	"p2p/utils"
-	"strconv"
-	"time"
+	"p2p/utils/money"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserServiceInterface interface {
-	RegisterUser(user models.User) (primitive.ObjectID, error)
+	RegisterUser(req models.RegisterRequest) (primitive.ObjectID, error)
 	SignInUser(user models.Login) (models.User, error)
 	BlockUser(userID primitive.ObjectID, block bool) error
 	GetAllUsers() ([]models.User, error)
-	UpdatePassword(email, passwrd string) error
+	RequestPasswordReset(email string) error
+	ConfirmPasswordReset(req models.ResetPasswordRequest) error
 }
 type UserService struct{}
 
-func (s *UserService) RegisterUser(user models.User) (primitive.ObjectID, error) {
-	user.Role = "user"
-	user.IsBlocked = false
-	user.Balance = 0.00
-	user.CreatedAt = time.Now()
+func (s *UserService) RegisterUser(req models.RegisterRequest) (primitive.ObjectID, error) {
+	user := req.NewUser("user")
 
 	if user.Email == "" || user.Password == "" {
 		return primitive.NilObjectID, errors.New("email or password can't be empty")
@@ -98,13 +96,7 @@ func (s *UserService) SignInUser(user models.Login) (models.User, error) {
 		log.Println("Error fetching admin config:", err)
 		return models.User{}, err
 	}
-	usdRate, err := strconv.ParseFloat(cnf.USDTRate, 64)
-	if err != nil {
-		log.Println("Error converting string to float64:", err)
-		return userData, nil // //cant efffect login
-	}
-
-	userData.INRBalance = usdRate * userData.Balance
+	userData.INRBalance = money.ToINR(userData.Balance, cnf.USDTRate)
 
 	return userData, nil
 }
@@ -114,7 +106,19 @@ func (s *UserService) BlockUser(userID primitive.ObjectID, block bool) error {
 	// Repo instance
 	repo := users.UserRepository(&users.UserRepo{})
 
-	return repo.BlockUser(userID, block)
+	if err := repo.BlockUser(userID, block); err != nil {
+		return err
+	}
+
+	// A blocked user must lose every live session, not just new logins
+	if block {
+		sessionRepo := sessions.SessionRepository(&sessions.SessionRepo{})
+		if _, err := sessionRepo.RevokeAllForUser(userID, "user blocked"); err != nil {
+			log.Println("Error revoking sessions for blocked user:", err)
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *UserService) GetAllUsers() ([]models.User, error) {
@@ -122,14 +126,3 @@ func (s *UserService) GetAllUsers() ([]models.User, error) {
 
 	return repo.GetAllUsers()
 }
-
-func (s *UserService) UpdatePassword(email, passwrd string) error {
-	repo := users.UserRepository(&users.UserRepo{})
-	// Hashing password
-	hashedPwd, err := utils.HashPassword(passwrd)
-	if err != nil {
-		log.Println("Error hashing password:", err)
-		return err
-	}
-	return repo.UpdatePassword(email, hashedPwd)
-}
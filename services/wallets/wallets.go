@@ -0,0 +1,186 @@
+package wallets
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"p2p/models"
+	"p2p/repo/admin"
+	"p2p/repo/wallets"
+	"p2p/utils/chain"
+	"p2p/utils/money"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WalletServiceInterface interface {
+	Register(userID string, req models.DepositWalletRequest) (*models.DepositWallet, error)
+	ListForUser(userID string) ([]models.DepositWallet, error)
+	Delete(id, userID string) error
+	VerifyOwnership(id, userID string, req models.WalletProofRequest) (*models.DepositWallet, error)
+	AddressesFor(userID primitive.ObjectID, network string) ([]string, error)
+}
+
+type WalletService struct{}
+
+const maxLabelLength = 50
+
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrWalletNotFound       = wallets.ErrWalletNotFound
+	ErrWalletTaken          = wallets.ErrWalletTaken
+	ErrInvalidAddress       = chain.ErrInvalidAddress
+	ErrVerificationDisabled = chain.ErrVerificationDisabled
+	ErrInvalidLabel         = errors.New("label can't exceed 50 characters")
+	ErrNoProofAddress       = errors.New("no platform wallet is configured to receive proof transfers")
+	// ErrProofMismatch is returned when the transaction doesn't prove the wallet, see the reasons
+	ErrProofMismatch = errors.New("transaction does not prove ownership of this wallet")
+)
+
+// Register adds a sending address for the configured network, stored in its
+// canonical form so every spelling of it collides on the unique index. The
+// wallet stays unverified until the user sends its proof amount from it.
+func (s *WalletService) Register(userID string, req models.DepositWalletRequest) (*models.DepositWallet, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	label := strings.TrimSpace(req.Label)
+	if len([]rune(label)) > maxLabelLength {
+		return nil, ErrInvalidLabel
+	}
+
+	verifier, err := chain.FromConfig()
+	if err != nil {
+		return nil, err
+	}
+	address, err := verifier.CanonicalAddress(req.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	adminRepo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := adminRepo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	if cnf.SecureWalletAddress == "" {
+		return nil, ErrNoProofAddress
+	}
+	proofAmount, err := newProofAmount()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := wallets.WalletRepository(&wallets.WalletRepo{})
+	return repo.Create(models.DepositWallet{
+		UserId:       uid,
+		Network:      verifier.Network(),
+		Address:      address,
+		Label:        label,
+		ProofAddress: cnf.SecureWalletAddress,
+		ProofAmount:  proofAmount,
+	})
+}
+
+// VerifyOwnership checks the user's test transfer: it must come from the
+// wallet, pay exactly the proof amount to the proof address and be confirmed
+func (s *WalletService) VerifyOwnership(id, userID string, req models.WalletProofRequest) (*models.DepositWallet, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrWalletNotFound
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	repo := wallets.WalletRepository(&wallets.WalletRepo{})
+	w, err := repo.GetByID(oid, uid)
+	if err != nil {
+		return nil, err
+	}
+	if w.VerifiedAt != nil {
+		return w, nil
+	}
+
+	verifier, err := chain.FromConfig()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	txHash := strings.TrimSpace(req.TxHash)
+	result, err := verifier.Verify(ctx, chain.VerifyRequest{
+		TxHash:        txHash,
+		WalletAddress: w.ProofAddress,
+		Amount:        w.ProofAmount,
+		SenderWallets: []string{w.Address},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.SenderRegistered {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("sent from %s, not %s", result.FromAddress, w.Address))
+	}
+	if result.Status != models.VerificationVerified || !result.SenderRegistered {
+		return nil, fmt.Errorf("%w: %s", ErrProofMismatch, strings.Join(append([]string{result.Status}, result.Reasons...), "; "))
+	}
+
+	return repo.MarkVerified(oid, uid, txHash)
+}
+
+func (s *WalletService) ListForUser(userID string) ([]models.DepositWallet, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	repo := wallets.WalletRepository(&wallets.WalletRepo{})
+	return repo.ListByUser(uid, "")
+}
+
+func (s *WalletService) Delete(id, userID string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrWalletNotFound
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	repo := wallets.WalletRepository(&wallets.WalletRepo{})
+	return repo.Delete(oid, uid)
+}
+
+// AddressesFor returns the user's verified addresses on one network, for
+// matching a deposit's sender
+func (s *WalletService) AddressesFor(userID primitive.ObjectID, network string) ([]string, error) {
+	repo := wallets.WalletRepository(&wallets.WalletRepo{})
+	list, err := repo.ListByUser(userID, network)
+	if err != nil {
+		return nil, err
+	}
+	addrs := []string{}
+	for _, w := range list {
+		if w.VerifiedAt != nil {
+			addrs = append(addrs, w.Address)
+		}
+	}
+	return addrs, nil
+}
+
+// newProofAmount picks a random amount between 0.010001 and 0.019999 USDT,
+// so one transfer can't prove two wallets registered at the same time
+func newProofAmount() (money.Amount, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(9999))
+	if err != nil {
+		return money.Zero(), err
+	}
+	micro := money.New(n.Int64() + 1).Div(money.New(1000000))
+	return money.MustParse("0.01").Add(micro), nil
+}
@@ -0,0 +1,105 @@
+package withdrawl
+
+import (
+	"errors"
+	"fmt"
+	"p2p/models"
+	"p2p/repo/admin"
+	"p2p/repo/withdrawl"
+	adminService "p2p/services/admin"
+	"p2p/services/twofactor"
+	"p2p/utils/money"
+	"p2p/utils/statemachine"
+	"strings"
+)
+
+// MaxBulkItems caps one bulk request so it fits in a single HTTP round trip
+const MaxBulkItems = 100
+
+var (
+	ErrBulkEmpty     = errors.New("at least one withdrawl id is required")
+	ErrBulkTooLarge  = errors.New("too many withdrawls in one bulk request")
+	ErrDuplicateItem = errors.New("withdrawl listed more than once in this request")
+)
+
+// BulkUpdateWithdrawStatus moves every listed withdrawal towards the same
+// status, each with its own UTR. Items run in separate transactions, so one
+// failure never rolls back the others; request-wide problems (bad status,
+// missing reason, TOTP) fail the whole batch before anything is touched.
+func (s *WithdrawlService) BulkUpdateWithdrawStatus(req models.BulkStatusRequest, actor models.Actor) (*models.BulkStatusRes, error) {
+	status, err := statemachine.Normalize(req.Status)
+	if err != nil {
+		return nil, err
+	}
+	if status == models.WithdrawlAwaitingSecondApproval {
+		return nil, fmt.Errorf("%w: request Approved to start a dual approval", ErrIllegalTransition)
+	}
+
+	targets := req.Targets()
+	if len(targets) == 0 {
+		return nil, ErrBulkEmpty
+	}
+	if len(targets) > MaxBulkItems {
+		return nil, ErrBulkTooLarge
+	}
+
+	adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+	rejection, err := adminSvc.ResolveRejection(status, req.ReasonCode, req.Note, actor)
+	if err != nil {
+		return nil, err
+	}
+	reason := historyReason(req.Reason, rejection)
+
+	adminRepo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := adminRepo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	dualThreshold := money.Zero()
+	if cnf.DualApprovalThreshold != nil {
+		dualThreshold = *cnf.DualApprovalThreshold
+	}
+
+	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
+
+	// A TOTP code can only be used once, so it covers the whole batch and is
+	// checked against the largest withdrawal in it
+	if status == models.StatusApproved {
+		largest := money.Zero()
+		for _, t := range targets {
+			wd, err := repo.GetByID(strings.TrimSpace(t.ID))
+			if err == nil && wd != nil {
+				largest = money.Max(largest, wd.Amount)
+			}
+		}
+		tf := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+		if err := tf.CheckApproval(actor.ID.Hex(), largest, req.TOTPCode); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &models.BulkStatusRes{Results: make([]models.BulkItemResult, 0, len(targets))}
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		id := strings.TrimSpace(t.ID)
+		item := models.BulkItemResult{ID: id}
+
+		if seen[id] {
+			item.Err = ErrDuplicateItem
+		} else {
+			seen[id] = true
+			utr := strings.ToUpper(strings.TrimSpace(t.UTR))
+			item.Status, item.Err = repo.UpdateWithdrawStatus(id, status, utr, actor, reason, rejection, dualThreshold)
+		}
+
+		if item.Err == nil {
+			item.Success = true
+			res.Succeeded++
+		} else {
+			item.Status = ""
+			res.Failed++
+		}
+		res.Results = append(res.Results, item)
+	}
+	return res, nil
+}
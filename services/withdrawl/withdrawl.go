@@ -1,13 +1,31 @@
 package withdrawl
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"p2p/models"
+	"p2p/repo/admin"
 	"p2p/repo/withdrawl"
+	adminService "p2p/services/admin"
+	"p2p/services/beneficiary"
+	"p2p/services/fees"
+	"p2p/services/limits"
+	"p2p/services/rates"
+	"p2p/services/tickets"
+	"p2p/services/twofactor"
+	"p2p/utils/money"
+	"p2p/utils/statemachine"
+	"strings"
 )
 
 type WithdrawlServiceInterface interface {
 	CreateWithdrawl(req models.WithdrawlRequest) error
-	UpdateWithdrawStatus(withdrawID, utr string, approve bool) error
+	UpdateWithdrawStatus(req models.UpdateStatusRequest, actor models.Actor) (string, error)
+	BulkUpdateWithdrawStatus(req models.BulkStatusRequest, actor models.Actor) (*models.BulkStatusRes, error)
+	ListAwaitingSecondApproval() ([]models.WithdrawlRes, error)
+	CancelWithdrawl(withdrawID string, actor models.Actor, reason string) error
+	AddAdminNote(withdrawID string, actor models.Actor, note string) error
 	ListWithdrawls() ([]models.WithdrawlRes, error)
 	GetWithdrawlByID(id string) (*models.WithdrawlRes, error)
 	SearchWithdrawlsByUsername(username string) ([]models.WithdrawlRes, error)
@@ -16,37 +34,211 @@ type WithdrawlServiceInterface interface {
 type WithdrawlService struct {
 }
 
-// Create new withdrawl request
+// Errors surfaced to handlers so they can pick a status code
+var (
+	ErrInsufficientBalance   = withdrawl.ErrInsufficientBalance
+	ErrInvalidAmount         = withdrawl.ErrInvalidAmount
+	ErrWithdrawlNotPending   = withdrawl.ErrWithdrawlNotPending
+	ErrDuplicateUTR          = withdrawl.ErrDuplicateUTR
+	ErrSameApprover          = withdrawl.ErrSameApprover
+	ErrFirstApprovalRequired = withdrawl.ErrFirstApprovalRequired
+	ErrAlreadyInBatch        = withdrawl.ErrAlreadyInBatch
+	ErrWithdrawlNotFound     = withdrawl.ErrWithdrawlNotFound
+	ErrNotCancellable        = withdrawl.ErrWithdrawlNotCancellable
+	ErrIllegalTransition     = statemachine.ErrIllegalTransition
+	ErrUnknownStatus         = statemachine.ErrUnknownStatus
+	ErrBeneficiaryNotFound   = beneficiary.ErrBeneficiaryNotFound
+	ErrBeneficiaryRequired   = errors.New("beneficiary_id is required")
+)
+
+// Create new withdrawl request, paid to one of the user's saved beneficiaries
 func (s *WithdrawlService) CreateWithdrawl(req models.WithdrawlRequest) error {
+	// USDT amounts are kept to 6 decimal places
+	req.Amount = req.Amount.RoundUSDT()
+
+	// Bank details always come from the beneficiary, never from the request
+	if req.BeneficiaryID.IsZero() {
+		return ErrBeneficiaryRequired
+	}
+	benSvc := beneficiary.BeneficiaryServiceInterface(&beneficiary.BeneficiaryService{})
+	ben, err := benSvc.ForWithdrawal(req.BeneficiaryID, req.UserId)
+	if err != nil {
+		return err
+	}
+
+	if !req.Amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+	// The fee is fixed now so later schedule changes never reprice it
+	feeSvc := fees.FeeServiceInterface(&fees.FeeService{})
+	quote, err := feeSvc.Quote(req.UserId, req.Amount)
+	if err != nil {
+		return err
+	}
+
+	// So is the INR value the beneficiary will be paid
+	rateSvc := rates.RateServiceInterface(&rates.RateService{})
+	rate, err := rateSvc.Lock(req.UserId, models.LimitWithdrawl, req.RateQuoteID)
+	if err != nil {
+		return err
+	}
+
+	// Only client inputs are carried over; approvals, UTR and history start empty
+	wd := models.WithdrawlRequest{
+		UserId:              req.UserId,
+		Amount:              req.Amount,
+		INRRate:             rate,
+		INRAmount:           money.ToINR(req.Amount, rate),
+		RateQuoteID:         req.RateQuoteID,
+		Status:              models.StatusPending,
+		BeneficiaryID:       ben.ID,
+		BankName:            ben.BankName,
+		HolderName:          ben.HolderName,
+		AccountNumber:       ben.AccountNumber,
+		IFSCCode:            ben.IFSCCode,
+		BeneficiaryVerified: ben.Verified,
+		Fee:                 quote.Fee,
+	}
+
+	// Limits are checked in the insert's transaction so parallel requests can't all pass
+	limitSvc := limits.LimitsServiceInterface(&limits.LimitsService{})
+	checkLimits := func(ctx context.Context) error {
+		return limitSvc.Check(ctx, models.LimitWithdrawl, wd.UserId, wd.Amount)
+	}
+
+	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
+	if err := repo.WithdrawlRequest(wd, checkLimits); err != nil {
+		rateSvc.Release(req.RateQuoteID)
+		return err
+	}
+	return nil
+}
+
+// UpdateWithdrawStatus returns the resulting status, which is
+// AwaitingSecondApproval when a large withdrawal got its first approval
+func (s *WithdrawlService) UpdateWithdrawStatus(req models.UpdateStatusRequest, actor models.Actor) (string, error) {
+	status, err := statemachine.Normalize(req.Status)
+	if err != nil {
+		return "", err
+	}
+	// Only an approval may park a withdrawal for the checker, so the maker is recorded
+	if status == models.WithdrawlAwaitingSecondApproval {
+		return "", fmt.Errorf("%w: request Approved to start a dual approval", ErrIllegalTransition)
+	}
+
+	// UTRs are bank references; compare them without case or padding
+	utr := strings.ToUpper(strings.TrimSpace(req.UTR))
+
+	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
+
+	// Large payouts need a fresh code from the approving admin
+	if status == models.StatusApproved {
+		wd, err := repo.GetByID(req.ID)
+		if err != nil || wd == nil {
+			return "", ErrWithdrawlNotFound
+		}
+		tf := twofactor.TwoFactorServiceInterface(&twofactor.TwoFactorService{})
+		if err := tf.CheckApproval(actor.ID.Hex(), wd.Amount, req.TOTPCode); err != nil {
+			return "", err
+		}
+	}
+
+	adminRepo := admin.AdminRepository(&admin.AdminRepo{})
+	cnf, err := adminRepo.Fetch()
+	if err != nil {
+		return "", err
+	}
+	dualThreshold := money.Zero()
+	if cnf.DualApprovalThreshold != nil {
+		dualThreshold = *cnf.DualApprovalThreshold
+	}
+
+	adminSvc := adminService.AdminServiceInterface(&adminService.AdminService{})
+	rejection, err := adminSvc.ResolveRejection(status, req.ReasonCode, req.Note, actor)
+	if err != nil {
+		return "", err
+	}
+
+	return repo.UpdateWithdrawStatus(req.ID, status, utr, actor, historyReason(req.Reason, rejection), rejection, dualThreshold)
+}
+
+// AddAdminNote appends an internal note that the owner never sees
+func (s *WithdrawlService) AddAdminNote(withdrawID string, actor models.Actor, note string) error {
+	n, err := adminService.NewAdminNote(note, actor)
+	if err != nil {
+		return err
+	}
+	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
+	return repo.AddAdminNote(withdrawID, n)
+}
+
+// historyReason prefers the explicit reason and falls back to the rejection code
+func historyReason(reason string, rejection *models.Rejection) string {
+	reason = strings.TrimSpace(reason)
+	if reason == "" && rejection != nil {
+		return rejection.Code
+	}
+	return reason
+}
+
+// CancelWithdrawl cancels the caller's own Pending withdrawal and refunds it
+func (s *WithdrawlService) CancelWithdrawl(withdrawID string, actor models.Actor, reason string) error {
 	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
-	return repo.WithdrawlRequest(req)
+	return repo.CancelWithdrawl(withdrawID, actor, strings.TrimSpace(reason))
 }
 
-func (s *WithdrawlService) UpdateWithdrawStatus(withdrawID, utr string, approve bool) error {
+// ListAwaitingSecondApproval is the checker queue, oldest first
+func (s *WithdrawlService) ListAwaitingSecondApproval() ([]models.WithdrawlRes, error) {
 	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
-	return repo.UpdateWithdrawStatus(withdrawID, approve, utr)
+	list, err := repo.GetAllByStatus(models.WithdrawlAwaitingSecondApproval)
+	if err != nil {
+		return nil, err
+	}
+	tickets.AttachWithdrawls(list)
+	return list, nil
 }
 
 // GetWithdrawlsByUserID - paginated withdrawls for a given user
 func (s *WithdrawlService) GetWithdrawlsByUserID(userID string) ([]models.WithdrawlRes, error) {
 	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
-	return repo.GetAllByUserID(userID)
+	list, err := repo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	tickets.AttachWithdrawls(list)
+	return list, nil
 }
 
 // List withdrawls with pagination
 func (s *WithdrawlService) ListWithdrawls() ([]models.WithdrawlRes, error) {
 	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
-	return repo.GetAll()
+	list, err := repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	tickets.AttachWithdrawls(list)
+	return list, nil
 }
 
 // Get withdrawl by ID
 func (s *WithdrawlService) GetWithdrawlByID(id string) (*models.WithdrawlRes, error) {
 	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
-	return repo.GetByID(id)
+	wd, err := repo.GetByID(id)
+	if err != nil || wd == nil {
+		return wd, err
+	}
+	list := []models.WithdrawlRes{*wd}
+	tickets.AttachWithdrawls(list)
+	return &list[0], nil
 }
 
 // Search withdrawls by username
 func (s *WithdrawlService) SearchWithdrawlsByUsername(username string) ([]models.WithdrawlRes, error) {
 	repo := withdrawl.WithdrawlRepository(&withdrawl.WithdrawlRepo{})
-	return repo.SearchByUsername(username)
+	list, err := repo.SearchByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	tickets.AttachWithdrawls(list)
+	return list, nil
 }
@@ -0,0 +1,125 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// topic pads a 20-byte hex address into an indexed log topic
+func topic(addrHex string) string {
+	return "0x" + strings.Repeat("0", 24) + addrHex
+}
+
+func TestERC20ScansPastTransfersToOthers(t *testing.T) {
+	const (
+		sender = "1111111111111111111111111111111111111111"
+		other  = "2222222222222222222222222222222222222222"
+		ours   = "3333333333333333333333333333333333333333"
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var result interface{}
+		switch req.Method {
+		case "eth_getTransactionReceipt":
+			result = rpcReceipt{Status: "0x1", BlockNumber: "0x10", Logs: []rpcLog{
+				{Address: defaultERC20Contract, Topics: []string{transferTopic, topic(sender), topic(other)}, Data: "0x0f4240"}, // 1 USDT
+				{Address: "0x9999999999999999999999999999999999999999", Topics: []string{transferTopic, topic(sender), topic(ours)}, Data: "0x5f5e100"},
+				{Address: defaultERC20Contract, Topics: []string{transferTopic, topic(sender), topic(ours)}, Data: "0x5f5e100"}, // 100 USDT
+			}}
+		case "eth_blockNumber":
+			result = "0x30"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	client := NewERC20Client(srv.URL, "")
+	transfers, err := client.GetUSDTTransfers(context.Background(), "0xabc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 2 {
+		t.Fatalf("got %d USDT transfers, want 2 (other tokens skipped)", len(transfers))
+	}
+	if transfers[1].To != "0x"+ours || transfers[1].Amount.String() != "100" || transfers[1].Confirmations != 33 {
+		t.Errorf("second transfer = %+v", transfers[1])
+	}
+
+	res, err := NewVerifier(client, 20).Verify(context.Background(), VerifyRequest{
+		TxHash:        "0xabc",
+		WalletAddress: "0x" + strings.ToUpper(ours),
+		Amount:        transfers[1].Amount,
+	})
+	if err != nil || res.Status != "verified" {
+		t.Errorf("verify = %s %v %v", res.Status, res.Reasons, err)
+	}
+}
+
+func TestTRC20ScansPastTransfersToOthers(t *testing.T) {
+	contract, err := tronHex(defaultTRC20Contract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		sender = "1111111111111111111111111111111111111111"
+		other  = "2222222222222222222222222222222222222222"
+		ours   = "3333333333333333333333333333333333333333"
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wallet/gettransactioninfobyid":
+			json.NewEncoder(w).Encode(tronTxInfo{ID: "abc", BlockNumber: 100, Log: []tronLog{
+				{Address: contract, Topics: []string{tronTransferTopicHash, topic(sender)[2:], topic(other)[2:]}, Data: "0f4240"},
+				{Address: contract, Topics: []string{tronTransferTopicHash, topic(sender)[2:], topic(ours)[2:]}, Data: "05f5e100"},
+			}})
+		case "/wallet/getnowblock":
+			w.Write([]byte(`{"block_header":{"raw_data":{"number":119}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewTRC20Client(srv.URL, "", "")
+	res, err := NewVerifier(client, 20).Verify(context.Background(), VerifyRequest{
+		TxHash:        "abc",
+		WalletAddress: "41" + ours,
+		Amount:        tokenAmount(bigHex(t, "05f5e100")),
+	})
+	if err != nil || res.Status != "verified" {
+		t.Fatalf("verify = %s %v %v", res.Status, res.Reasons, err)
+	}
+	if !client.SameAddress(res.ToAddress, "41"+ours) || res.Confirmations != 20 {
+		t.Errorf("picked %s with %d confirmations", res.ToAddress, res.Confirmations)
+	}
+}
+
+func TestTRC20NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	res, err := NewVerifier(NewTRC20Client(srv.URL, "", ""), 20).Verify(context.Background(), VerifyRequest{TxHash: "abc", WalletAddress: "T"})
+	if err != nil || res.Status != "not_found" {
+		t.Errorf("verify = %s %v", res.Status, err)
+	}
+}
+
+func bigHex(t *testing.T, h string) *big.Int {
+	t.Helper()
+	n, ok := new(big.Int).SetString(h, 16)
+	if !ok {
+		t.Fatalf("bad hex %q", h)
+	}
+	return n
+}
@@ -0,0 +1,176 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"p2p/utils/money"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Tether USD on Ethereum mainnet
+const defaultERC20Contract = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+// keccak256("Transfer(address,address,uint256)")
+const transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// ERC20Client reads USDT transfers through an Ethereum JSON-RPC endpoint
+type ERC20Client struct {
+	rpcURL   string
+	contract string
+	http     *http.Client
+}
+
+func NewERC20Client(rpcURL, contract string) *ERC20Client {
+	if contract == "" {
+		contract = defaultERC20Contract
+	}
+	return &ERC20Client{
+		rpcURL:   rpcURL,
+		contract: contract,
+		http:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *ERC20Client) Network() string {
+	return "erc20"
+}
+
+func (c *ERC20Client) SameAddress(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// Canonical lowercases a 0x address, dropping any checksum casing
+func (c *ERC20Client) Canonical(addr string) (string, error) {
+	a := strings.ToLower(strings.TrimSpace(addr))
+	if !erc20Address.MatchString(a) {
+		return "", ErrInvalidAddress
+	}
+	return a, nil
+}
+
+var erc20Address = regexp.MustCompile(`^0x[0-9a-f]{40}$`)
+
+type rpcLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+type rpcReceipt struct {
+	Status      string   `json:"status"`
+	BlockNumber string   `json:"blockNumber"`
+	Logs        []rpcLog `json:"logs"`
+}
+
+func (c *ERC20Client) GetUSDTTransfers(ctx context.Context, txHash string) ([]Transfer, error) {
+	var receipt *rpcReceipt
+	if err := c.call(ctx, "eth_getTransactionReceipt", []interface{}{txHash}, &receipt); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ErrTxNotFound
+	}
+
+	var head string
+	if err := c.call(ctx, "eth_blockNumber", []interface{}{}, &head); err != nil {
+		return nil, err
+	}
+
+	success := receipt.Status == "0x1"
+	var confirmations int64
+	headNum, ok1 := new(big.Int).SetString(strings.TrimPrefix(head, "0x"), 16)
+	blockNum, ok2 := new(big.Int).SetString(strings.TrimPrefix(receipt.BlockNumber, "0x"), 16)
+	if ok1 && ok2 {
+		confirmations = new(big.Int).Sub(headNum, blockNum).Int64() + 1
+	}
+
+	var transfers []Transfer
+	for _, l := range receipt.Logs {
+		if !strings.EqualFold(l.Address, c.contract) || len(l.Topics) < 3 || !strings.EqualFold(l.Topics[0], transferTopic) {
+			continue
+		}
+		raw, ok := new(big.Int).SetString(strings.TrimPrefix(l.Data, "0x"), 16)
+		if !ok {
+			return nil, fmt.Errorf("malformed transfer amount %q", l.Data)
+		}
+		transfers = append(transfers, Transfer{
+			TxHash:        txHash,
+			From:          topicAddress(l.Topics[1]),
+			To:            topicAddress(l.Topics[2]),
+			Amount:        tokenAmount(raw),
+			Confirmations: confirmations,
+			Success:       success,
+		})
+	}
+
+	if len(transfers) == 0 {
+		return nil, ErrNoTransfer
+	}
+	return transfers, nil
+}
+
+// topicAddress turns a 32-byte indexed topic into a 0x address
+func topicAddress(topic string) string {
+	t := strings.TrimPrefix(topic, "0x")
+	if len(t) < 40 {
+		return "0x" + t
+	}
+	return "0x" + t[len(t)-40:]
+}
+
+// tokenAmount converts raw token units to a USDT amount
+func tokenAmount(raw *big.Int) money.Amount {
+	a, err := money.Parse(new(big.Rat).SetFrac(raw, new(big.Int).Exp(big.NewInt(10), big.NewInt(usdtDecimals), nil)).FloatString(usdtDecimals))
+	if err != nil {
+		return money.Zero()
+	}
+	return a
+}
+
+func (c *ERC20Client) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: rpc returned %s", method, resp.Status)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if envelope.Error != nil {
+		return fmt.Errorf("%s: %s", method, envelope.Error.Message)
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
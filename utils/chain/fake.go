@@ -0,0 +1,58 @@
+package chain
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// FakeClient is an in-process chain for tests and local runs. Register
+// transfers with Add and wrap it with NewVerifier, then install it with Use.
+type FakeClient struct {
+	mu        sync.RWMutex
+	network   string
+	transfers map[string][]Transfer
+}
+
+func NewFakeClient(network string) *FakeClient {
+	return &FakeClient{
+		network:   network,
+		transfers: make(map[string][]Transfer),
+	}
+}
+
+// Add registers a transfer that GetUSDTTransfers will return for its hash,
+// after any added before it with the same hash
+func (c *FakeClient) Add(t Transfer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := strings.ToLower(t.TxHash)
+	c.transfers[key] = append(c.transfers[key], t)
+}
+
+func (c *FakeClient) Network() string {
+	return c.network
+}
+
+func (c *FakeClient) SameAddress(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+func (c *FakeClient) Canonical(addr string) (string, error) {
+	a := strings.ToLower(strings.TrimSpace(addr))
+	if a == "" {
+		return "", ErrInvalidAddress
+	}
+	return a, nil
+}
+
+func (c *FakeClient) GetUSDTTransfers(_ context.Context, txHash string) ([]Transfer, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list, ok := c.transfers[strings.ToLower(txHash)]
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+	return append([]Transfer(nil), list...), nil
+}
@@ -0,0 +1,266 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTronGridURL    = "https://api.trongrid.io"
+	defaultTRC20Contract  = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t" // Tether USD on Tron
+	tronTransferTopicHash = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+)
+
+// TRC20Client reads USDT transfers through the TronGrid HTTP API
+type TRC20Client struct {
+	baseURL  string
+	apiKey   string
+	contract string
+	http     *http.Client
+}
+
+func NewTRC20Client(baseURL, apiKey, contract string) *TRC20Client {
+	if baseURL == "" {
+		baseURL = defaultTronGridURL
+	}
+	if contract == "" {
+		contract = defaultTRC20Contract
+	}
+	return &TRC20Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		apiKey:   apiKey,
+		contract: contract,
+		http:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *TRC20Client) Network() string {
+	return "trc20"
+}
+
+// SameAddress compares base58 (T...) or hex (41.../0x...) Tron addresses
+func (c *TRC20Client) SameAddress(a, b string) bool {
+	ha, errA := tronHex(a)
+	hb, errB := tronHex(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return ha == hb
+}
+
+// Canonical renders any Tron address form as base58 T...
+func (c *TRC20Client) Canonical(addr string) (string, error) {
+	h, err := tronHex(addr)
+	if err != nil {
+		return "", ErrInvalidAddress
+	}
+	raw, _ := hex.DecodeString("41" + h)
+	return base58CheckEncode(raw), nil
+}
+
+type tronLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+type tronTxInfo struct {
+	ID          string    `json:"id"`
+	BlockNumber int64     `json:"blockNumber"`
+	Result      string    `json:"result"`
+	Log         []tronLog `json:"log"`
+	Receipt     struct {
+		Result string `json:"result"`
+	} `json:"receipt"`
+}
+
+func (c *TRC20Client) GetUSDTTransfers(ctx context.Context, txHash string) ([]Transfer, error) {
+	var info tronTxInfo
+	if err := c.post(ctx, "/wallet/gettransactioninfobyid", map[string]string{"value": txHash}, &info); err != nil {
+		return nil, err
+	}
+	if info.ID == "" {
+		return nil, ErrTxNotFound
+	}
+
+	var head struct {
+		BlockHeader struct {
+			RawData struct {
+				Number int64 `json:"number"`
+			} `json:"raw_data"`
+		} `json:"block_header"`
+	}
+	if err := c.post(ctx, "/wallet/getnowblock", map[string]string{}, &head); err != nil {
+		return nil, err
+	}
+
+	success := info.Result != "FAILED" && (info.Receipt.Result == "" || info.Receipt.Result == "SUCCESS")
+	confirmations := head.BlockHeader.RawData.Number - info.BlockNumber + 1
+
+	contractHex, err := tronHex(c.contract)
+	if err != nil {
+		return nil, fmt.Errorf("invalid USDT contract address: %w", err)
+	}
+
+	var transfers []Transfer
+	for _, l := range info.Log {
+		if len(l.Topics) < 3 || !strings.EqualFold(l.Topics[0], tronTransferTopicHash) {
+			continue
+		}
+		if addr, err := tronHex(l.Address); err != nil || addr != contractHex {
+			continue
+		}
+		raw, ok := new(big.Int).SetString(l.Data, 16)
+		if !ok {
+			return nil, fmt.Errorf("malformed transfer amount %q", l.Data)
+		}
+		transfers = append(transfers, Transfer{
+			TxHash:        txHash,
+			From:          tronBase58FromTopic(l.Topics[1]),
+			To:            tronBase58FromTopic(l.Topics[2]),
+			Amount:        tokenAmount(raw),
+			Confirmations: confirmations,
+			Success:       success,
+		})
+	}
+
+	if len(transfers) == 0 {
+		return nil, ErrNoTransfer
+	}
+	return transfers, nil
+}
+
+func (c *TRC20Client) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("TRON-PRO-API-KEY", c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: trongrid returned %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// -------------------- ADDRESS HELPERS --------------------
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// tronHex normalizes a Tron address to the 20-byte hex form without prefix
+func tronHex(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if strings.HasPrefix(addr, "T") {
+		raw, err := base58CheckDecode(addr)
+		if err != nil {
+			return "", err
+		}
+		if len(raw) != 21 || raw[0] != 0x41 {
+			return "", errors.New("not a Tron address")
+		}
+		return hex.EncodeToString(raw[1:]), nil
+	}
+
+	h := strings.ToLower(strings.TrimPrefix(addr, "0x"))
+	if len(h) == 42 && strings.HasPrefix(h, "41") {
+		h = h[2:]
+	}
+	if len(h) != 40 {
+		return "", errors.New("not a Tron address")
+	}
+	if _, err := hex.DecodeString(h); err != nil {
+		return "", err
+	}
+	return h, nil
+}
+
+// tronBase58FromTopic renders an indexed address topic as a T... address
+func tronBase58FromTopic(topic string) string {
+	t := strings.TrimPrefix(topic, "0x")
+	if len(t) < 40 {
+		return t
+	}
+	raw, err := hex.DecodeString("41" + t[len(t)-40:])
+	if err != nil {
+		return t
+	}
+	return base58CheckEncode(raw)
+}
+
+func base58CheckDecode(s string) ([]byte, error) {
+	n := new(big.Int)
+	for _, r := range s {
+		idx := strings.IndexRune(base58Alphabet, r)
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", r)
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+
+	decoded := n.Bytes()
+	for _, r := range s {
+		if r != '1' {
+			break
+		}
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) < 5 {
+		return nil, errors.New("base58 payload too short")
+	}
+
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, errors.New("base58 checksum mismatch")
+	}
+	return payload, nil
+}
+
+func base58CheckEncode(payload []byte) string {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	full := append(append([]byte{}, payload...), second[:4]...)
+
+	n := new(big.Int).SetBytes(full)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, big.NewInt(58), mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range full {
+		if b != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
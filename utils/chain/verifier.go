@@ -0,0 +1,210 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"p2p/config"
+	"p2p/models"
+	"p2p/utils/money"
+	"strings"
+	"sync"
+	"time"
+)
+
+// USDT uses 6 decimals on both Tron and Ethereum
+const usdtDecimals = 6
+
+var (
+	// ErrTxNotFound is returned by clients when the hash is unknown to the chain
+	ErrTxNotFound = errors.New("transaction not found on chain")
+	// ErrNoTransfer is returned when the transaction has no USDT transfer log
+	ErrNoTransfer = errors.New("transaction has no USDT transfer")
+	// ErrVerificationDisabled is returned when no chain network is configured
+	ErrVerificationDisabled = errors.New("chain verification is not configured")
+	// ErrInvalidAddress is returned for an address that is not valid on the network
+	ErrInvalidAddress = errors.New("not a valid address for this network")
+)
+
+// Transfer is a USDT token transfer as seen on chain
+type Transfer struct {
+	TxHash        string
+	From          string
+	To            string
+	Amount        money.Amount
+	Confirmations int64
+	Success       bool
+}
+
+// Client fetches USDT transfers from one chain
+type Client interface {
+	Network() string
+	// GetUSDTTransfers returns every USDT transfer in the transaction, in log order
+	GetUSDTTransfers(ctx context.Context, txHash string) ([]Transfer, error)
+	// SameAddress compares two addresses in the chain's canonical form
+	SameAddress(a, b string) bool
+	// Canonical returns the one form of an address that is stored and compared
+	Canonical(addr string) (string, error)
+}
+
+// VerifyRequest is what the user claims about a deposit
+type VerifyRequest struct {
+	TxHash        string
+	WalletAddress string
+	Amount        money.Amount
+	// SenderWallets are the addresses the sender may be, e.g. the depositor's verified wallets
+	SenderWallets []string
+}
+
+// ChainVerifier checks a claimed deposit against the chain
+type ChainVerifier interface {
+	Network() string
+	Verify(ctx context.Context, req VerifyRequest) (models.ChainVerification, error)
+	CanonicalAddress(addr string) (string, error)
+}
+
+type verifier struct {
+	client           Client
+	minConfirmations int64
+}
+
+// NewVerifier builds a ChainVerifier on top of a chain client
+func NewVerifier(client Client, minConfirmations int64) ChainVerifier {
+	return &verifier{client: client, minConfirmations: minConfirmations}
+}
+
+func (v *verifier) Network() string {
+	return v.client.Network()
+}
+
+func (v *verifier) CanonicalAddress(addr string) (string, error) {
+	return v.client.Canonical(addr)
+}
+
+// Verify checks that the hash exists, succeeded, paid the wallet the claimed
+// amount and has enough confirmations. A mismatch is reported in the result,
+// only transport failures are returned as errors.
+func (v *verifier) Verify(ctx context.Context, req VerifyRequest) (models.ChainVerification, error) {
+	res := models.ChainVerification{
+		Network:   v.client.Network(),
+		CheckedAt: time.Now(),
+	}
+
+	transfers, err := v.client.GetUSDTTransfers(ctx, strings.TrimSpace(req.TxHash))
+	if err == nil && len(transfers) == 0 {
+		err = ErrNoTransfer
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTxNotFound):
+			res.Status = models.VerificationNotFound
+			res.Reasons = append(res.Reasons, err.Error())
+			return res, nil
+		case errors.Is(err, ErrNoTransfer):
+			res.Status = models.VerificationMismatch
+			res.Reasons = append(res.Reasons, err.Error())
+			return res, nil
+		}
+		res.Status = models.VerificationError
+		res.Reasons = append(res.Reasons, err.Error())
+		return res, err
+	}
+	transfer := v.pick(transfers, req)
+
+	res.FromAddress = transfer.From
+	res.ToAddress = transfer.To
+	res.Amount = transfer.Amount
+	res.Confirmations = transfer.Confirmations
+	for _, w := range req.SenderWallets {
+		if v.client.SameAddress(transfer.From, w) {
+			res.SenderRegistered = true
+			break
+		}
+	}
+
+	if !transfer.Success {
+		res.Status = models.VerificationFailedOnChain
+		res.Reasons = append(res.Reasons, "transaction reverted on chain")
+		return res, nil
+	}
+
+	if req.WalletAddress == "" || !v.client.SameAddress(transfer.To, req.WalletAddress) {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("paid %s, expected %s", transfer.To, req.WalletAddress))
+	}
+	if transfer.Amount.RoundUSDT().Cmp(req.Amount.RoundUSDT()) != 0 {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("amount %s, claimed %s", transfer.Amount, req.Amount))
+	}
+	if len(res.Reasons) > 0 {
+		res.Status = models.VerificationMismatch
+		return res, nil
+	}
+
+	if transfer.Confirmations < v.minConfirmations {
+		res.Status = models.VerificationUnconfirmed
+		res.Reasons = append(res.Reasons, fmt.Sprintf("%d of %d confirmations", transfer.Confirmations, v.minConfirmations))
+		return res, nil
+	}
+
+	res.Status = models.VerificationVerified
+	return res, nil
+}
+
+// pick finds the transfer the claim is about: one paying the wallet the
+// claimed amount, else any paying the wallet, else the first so the mismatch
+// names where the money went
+func (v *verifier) pick(transfers []Transfer, req VerifyRequest) Transfer {
+	toWallet := -1
+	for i, t := range transfers {
+		if req.WalletAddress == "" || !v.client.SameAddress(t.To, req.WalletAddress) {
+			continue
+		}
+		if t.Amount.RoundUSDT().Cmp(req.Amount.RoundUSDT()) == 0 {
+			return t
+		}
+		if toWallet < 0 {
+			toWallet = i
+		}
+	}
+	if toWallet >= 0 {
+		return transfers[toWallet]
+	}
+	return transfers[0]
+}
+
+var (
+	overrideMu sync.RWMutex
+	override   ChainVerifier
+)
+
+// Use replaces the configured verifier, e.g. with a FakeClient-backed one in
+// tests. Passing nil restores the configuration-driven verifier.
+func Use(v ChainVerifier) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	override = v
+}
+
+// FromConfig returns the verifier for the configured network
+func FromConfig() (ChainVerifier, error) {
+	overrideMu.RLock()
+	v := override
+	overrideMu.RUnlock()
+	if v != nil {
+		return v, nil
+	}
+
+	cfg := config.Cfg
+	switch strings.ToLower(cfg.ChainNetwork) {
+	case "trc20":
+		return NewVerifier(NewTRC20Client(cfg.ChainRPCURL, cfg.ChainAPIKey, cfg.USDTContract), cfg.ChainMinConfirmations), nil
+	case "erc20":
+		if cfg.ChainRPCURL == "" {
+			return nil, errors.New("ChainRPCURL is required for erc20 verification")
+		}
+		return NewVerifier(NewERC20Client(cfg.ChainRPCURL, cfg.USDTContract), cfg.ChainMinConfirmations), nil
+	case "":
+		return nil, ErrVerificationDisabled
+	default:
+		return nil, fmt.Errorf("unsupported chain network %q", cfg.ChainNetwork)
+	}
+}
@@ -0,0 +1,102 @@
+package chain
+
+import (
+	"context"
+	"p2p/models"
+	"p2p/utils/money"
+	"testing"
+)
+
+const (
+	ourWallet   = "TOurWallet"
+	otherWallet = "TSomeoneElse"
+)
+
+func TestVerify(t *testing.T) {
+	fake := NewFakeClient("trc20")
+	fake.Add(Transfer{TxHash: "0xgood", From: "TSender", To: ourWallet, Amount: money.MustParse("100"), Confirmations: 25, Success: true})
+	fake.Add(Transfer{TxHash: "0xelsewhere", From: "TSender", To: otherWallet, Amount: money.MustParse("100"), Confirmations: 25, Success: true})
+	fake.Add(Transfer{TxHash: "0xfresh", From: "TSender", To: ourWallet, Amount: money.MustParse("100"), Confirmations: 3, Success: true})
+	fake.Add(Transfer{TxHash: "0xreverted", From: "TSender", To: ourWallet, Amount: money.MustParse("100"), Confirmations: 25})
+	// A fee hop to another address comes before the payment to us
+	fake.Add(Transfer{TxHash: "0xsplit", From: "TSender", To: otherWallet, Amount: money.MustParse("1"), Confirmations: 25, Success: true})
+	fake.Add(Transfer{TxHash: "0xsplit", From: "TSender", To: ourWallet, Amount: money.MustParse("100"), Confirmations: 25, Success: true})
+
+	v := NewVerifier(fake, 20)
+	cases := []struct {
+		name   string
+		hash   string
+		amount string
+		want   string
+	}{
+		{"verified", "0xgood", "100", models.VerificationVerified},
+		{"hash case ignored", "0xGOOD", "100.000000", models.VerificationVerified},
+		{"wrong amount", "0xgood", "99.5", models.VerificationMismatch},
+		{"wrong recipient", "0xelsewhere", "100", models.VerificationMismatch},
+		{"not found", "0xmissing", "100", models.VerificationNotFound},
+		{"too few confirmations", "0xfresh", "100", models.VerificationUnconfirmed},
+		{"reverted", "0xreverted", "100", models.VerificationFailedOnChain},
+		{"later log pays us", "0xsplit", "100", models.VerificationVerified},
+	}
+	for _, tc := range cases {
+		res, err := v.Verify(context.Background(), VerifyRequest{
+			TxHash:        tc.hash,
+			WalletAddress: ourWallet,
+			Amount:        money.MustParse(tc.amount),
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if res.Status != tc.want {
+			t.Errorf("%s: status %s (%v), want %s", tc.name, res.Status, res.Reasons, tc.want)
+		}
+	}
+}
+
+func TestVerifyReportsWhereMoneyWent(t *testing.T) {
+	fake := NewFakeClient("trc20")
+	fake.Add(Transfer{TxHash: "0xelsewhere", From: "TSender", To: otherWallet, Amount: money.MustParse("100"), Confirmations: 25, Success: true})
+
+	res, err := NewVerifier(fake, 20).Verify(context.Background(), VerifyRequest{
+		TxHash:        "0xelsewhere",
+		WalletAddress: ourWallet,
+		Amount:        money.MustParse("100"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ToAddress != otherWallet || len(res.Reasons) == 0 {
+		t.Errorf("got to %q reasons %v", res.ToAddress, res.Reasons)
+	}
+}
+
+func TestVerifySenderRegistered(t *testing.T) {
+	fake := NewFakeClient("trc20")
+	fake.Add(Transfer{TxHash: "0xgood", From: "TSender", To: ourWallet, Amount: money.MustParse("100"), Confirmations: 25, Success: true})
+	v := NewVerifier(fake, 20)
+
+	cases := []struct {
+		name    string
+		wallets []string
+		want    bool
+	}{
+		{"no wallets", nil, false},
+		{"other wallet", []string{"TStranger"}, false},
+		{"registered", []string{"TStranger", "tsender"}, true},
+	}
+	for _, tc := range cases {
+		res, err := v.Verify(context.Background(), VerifyRequest{
+			TxHash:        "0xgood",
+			WalletAddress: ourWallet,
+			Amount:        money.MustParse("100"),
+			SenderWallets: tc.wallets,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != models.VerificationVerified || res.SenderRegistered != tc.want {
+			t.Errorf("%s: status %s registered %v, want registered %v", tc.name, res.Status, res.SenderRegistered, tc.want)
+		}
+	}
+}
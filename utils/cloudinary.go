@@ -91,3 +91,17 @@ func UploadFormFileToCloudinary(c *gin.Context, fileHeader *multipart.FileHeader
 	log.Println("Cloudinary upload successful:", result.SecureURL)
 	return result.SecureURL, nil
 }
+
+// UploadFormFilesToCloudinary uploads each attachment in turn and returns
+// their URLs in the same order
+func UploadFormFilesToCloudinary(c *gin.Context, files []*multipart.FileHeader) ([]string, error) {
+	var urls []string
+	for _, fileHeader := range files {
+		url, err := UploadFormFileToCloudinary(c, fileHeader)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
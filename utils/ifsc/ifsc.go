@@ -0,0 +1,188 @@
+package ifsc
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"p2p/config"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrInvalidFormat is returned for codes that are not 4 letters, a 0 and 6 alphanumerics
+	ErrInvalidFormat = errors.New("IFSC must be 4 letters, a zero and 6 letters or digits")
+	// ErrUnknownIFSC is returned when the master file has no branch for the code
+	ErrUnknownIFSC = errors.New("IFSC not found")
+
+	pattern = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+)
+
+// Branch is one row of the IFSC master file
+type Branch struct {
+	IFSC   string `json:"ifsc"`
+	Bank   string `json:"bank"`
+	Branch string `json:"branch"`
+	City   string `json:"city,omitempty"`
+	State  string `json:"state,omitempty"`
+}
+
+// Directory is an in-memory IFSC master file
+type Directory struct {
+	branches map[string]Branch
+}
+
+// Normalize upper-cases and trims a code
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidFormat checks the RBI IFSC layout without looking the code up
+func ValidFormat(code string) bool {
+	return pattern.MatchString(code)
+}
+
+// Load reads a master file CSV with a header row. IFSC and BANK columns are
+// required; BRANCH, CITY (or CENTRE) and STATE are used when present, which
+// matches the published RBI and Razorpay dumps.
+func Load(r io.Reader) (*Directory, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read IFSC header: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i + 1
+	}
+	if col["IFSC"] == 0 || col["BANK"] == 0 {
+		return nil, errors.New("IFSC master file needs IFSC and BANK columns")
+	}
+	if col["CITY"] == 0 {
+		col["CITY"] = col["CENTRE"]
+	}
+
+	cell := func(record []string, name string) string {
+		i := col[name]
+		if i == 0 || i > len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i-1])
+	}
+
+	d := &Directory{branches: map[string]Branch{}}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read IFSC master file: %w", err)
+		}
+		code := Normalize(cell(record, "IFSC"))
+		if !ValidFormat(code) {
+			continue
+		}
+		d.branches[code] = Branch{
+			IFSC:   code,
+			Bank:   cell(record, "BANK"),
+			Branch: cell(record, "BRANCH"),
+			City:   cell(record, "CITY"),
+			State:  cell(record, "STATE"),
+		}
+	}
+	return d, nil
+}
+
+// LoadFile loads a master file from disk
+func LoadFile(path string) (*Directory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Len is the number of branches loaded
+func (d *Directory) Len() int {
+	return len(d.branches)
+}
+
+// Lookup validates the code and returns its branch
+func (d *Directory) Lookup(code string) (Branch, error) {
+	code = Normalize(code)
+	if !ValidFormat(code) {
+		return Branch{}, ErrInvalidFormat
+	}
+	b, ok := d.branches[code]
+	if !ok {
+		return Branch{}, ErrUnknownIFSC
+	}
+	return b, nil
+}
+
+var (
+	overrideMu sync.RWMutex
+	override   *Directory
+
+	loadOnce sync.Once
+	loaded   *Directory
+	loadErr  error
+)
+
+// Use replaces the configured directory. Passing nil restores the
+// configuration-driven one.
+func Use(d *Directory) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	override = d
+}
+
+// FromConfig returns the directory loaded from IFSCMasterFile, reading the
+// file once. It returns nil when no master file is configured, in which case
+// callers only check the format.
+func FromConfig() (*Directory, error) {
+	overrideMu.RLock()
+	d := override
+	overrideMu.RUnlock()
+	if d != nil {
+		return d, nil
+	}
+
+	path := config.Cfg.IFSCMasterFile
+	if path == "" {
+		return nil, nil
+	}
+	loadOnce.Do(func() {
+		loaded, loadErr = LoadFile(path)
+		if loadErr == nil {
+			log.Printf("✅ Loaded %d IFSC branches from %s", loaded.Len(), path)
+		}
+	})
+	return loaded, loadErr
+}
+
+// Resolve checks code against the configured master file, or only its
+// format when none is configured, in which case only Branch.IFSC is set.
+func Resolve(code string) (Branch, error) {
+	code = Normalize(code)
+	if !ValidFormat(code) {
+		return Branch{}, ErrInvalidFormat
+	}
+	d, err := FromConfig()
+	if err != nil {
+		return Branch{}, err
+	}
+	if d == nil {
+		return Branch{IFSC: code}, nil
+	}
+	return d.Lookup(code)
+}
@@ -1,11 +1,19 @@
 package midleware
 
 import (
-	"log"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"p2p/models"
+	"p2p/repo/sessions"
+	"p2p/repo/users"
+	"p2p/repo/wstickets"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // JWTAuthMiddleware checks for valid JWT in Authorization header or cookie
@@ -15,14 +23,11 @@ func AuthMiddleware() gin.HandlerFunc {
 		// secretKey := config.Cfg.JWTSecret
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
-			log.Println("Authorization header or token cookie missing")
 			tokenCookie, err := c.Cookie("token")
 			if err != nil {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header or token cookie missing"})
-				log.Println("Error retrieving token from cookie:", err)
 				return
 			}
-			log.Println("Using token from cookie:", tokenCookie)
 			tokenString = tokenCookie
 		} else {
 			// Always try to remove the Bearer prefix
@@ -34,29 +39,109 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+		if claims.Purpose != "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "two-factor login not completed"})
+			return
+		}
+
+		// Tokens are only good while their session is live and the user unblocked
+		if status, err := SessionCheck(claims); err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
 
 		// Set user info in context
+		c.Set("sessionID", claims.SessionID)
 		c.Set("email", claims.Email)
 		c.Set("userID", claims.UserID)
 		c.Set("role", claims.Role)
 
-		// Local dev: domain is empty so it works on localhost
-		domain := "" // Empty = works for both localhost & production if needed
+		// The HttpOnly token cookie is only ever set by login and refresh; the
+		// token itself is never echoed back or logged
+		c.Next()
+	}
+}
+
+// SessionCheck is run by AuthMiddleware on every token. Tests swap it so the
+// routes can be exercised without a database.
+var SessionCheck = checkSession
+
+// checkSession looks up the token's session and owner, returning the status
+// to abort with when either no longer allows access
+func checkSession(claims Claims) (int, error) {
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return http.StatusUnauthorized, errors.New("session missing re-login")
+	}
+
+	session, err := sessions.SessionRepository(&sessions.SessionRepo{}).GetByID(sessionID)
+	if err != nil {
+		if errors.Is(err, sessions.ErrSessionNotFound) {
+			return http.StatusUnauthorized, errors.New("session not found re-login")
+		}
+		return http.StatusInternalServerError, err
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) || session.UserId.Hex() != claims.UserID {
+		return http.StatusUnauthorized, errors.New("session revoked re-login")
+	}
+
+	user, err := users.UserRepository(&users.UserRepo{}).GetUserByID(session.UserId)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	if user.IsBlocked {
+		return http.StatusForbidden, errors.New("User Blocked Contact Admin")
+	}
+	return 0, nil
+}
+
+// WebSocketTicketTTL is how long a ticket from POST /chat/ws-ticket stays valid
+const WebSocketTicketTTL = 30 * time.Second
 
-		// Set cookies
-		c.SetCookie("token", tokenString, 3600, "/", domain, true, false)
+// RedeemWebSocketTicket consumes a ticket by its hash. Tests swap it so the
+// socket route can be exercised without a database.
+var RedeemWebSocketTicket = func(hash string) (*models.WebSocketTicket, error) {
+	return wstickets.TicketRepository(&wstickets.TicketRepo{}).Redeem(hash)
+}
 
-		userID := claims.UserID
-		role := claims.Role
-		email := claims.Email
+// WebSocketAuth authenticates the chat socket handshake. Browsers cannot set
+// headers on it, so they pass a single-use ?ticket= instead of the access
+// token, which would otherwise end up in access logs. Cookies are refused so
+// other sites cannot open sockets as the user.
+func WebSocketAuth() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			auth(c)
+			return
+		}
 
-		c.SetCookie("userID", userID, 3600, "/", domain, true, false)
-		c.SetCookie("role", role, 3600, "/", domain, true, false)
-		c.SetCookie("email", email, 3600, "/", domain, true, false)
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "ticket query parameter or Authorization header required"})
+			return
+		}
+		sum := sha256.Sum256([]byte(ticket))
+		t, err := RedeemWebSocketTicket(hex.EncodeToString(sum[:]))
+		if err != nil {
+			if errors.Is(err, wstickets.ErrTicketInvalid) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
-		// Also set token in the response header
-		c.Header("Authorization", "Bearer "+tokenString)
+		claims := Claims{Email: t.Email, UserID: t.UserID, Role: t.Role, SessionID: t.SessionID}
+		if status, err := SessionCheck(claims); err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
 
+		c.Set("sessionID", claims.SessionID)
+		c.Set("email", claims.Email)
+		c.Set("userID", claims.UserID)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
@@ -0,0 +1,46 @@
+package midleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"p2p/config"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddlewareLeavesCookiesAlone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Cfg.JWTSecret = "auth-test-secret"
+	prev := SessionCheck
+	SessionCheck = func(Claims) (int, error) { return 0, nil }
+	t.Cleanup(func() { SessionCheck = prev })
+
+	token, err := GenerateJWT("user@example.com", "user-1", RoleUser, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for name, set := range map[string]func(*http.Request){
+		"header": func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) },
+		"cookie": func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "token", Value: token}) },
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		set(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: got %d, want 200", name, w.Code)
+		}
+		if cookies := w.Result().Cookies(); len(cookies) > 0 {
+			t.Errorf("%s: middleware set cookies %v", name, cookies)
+		}
+		if w.Header().Get("Authorization") != "" {
+			t.Errorf("%s: token echoed in the response", name)
+		}
+	}
+}
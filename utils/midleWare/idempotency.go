@@ -0,0 +1,134 @@
+package midleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"p2p/models"
+	"p2p/repo/idempotency"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	idempotencyTTL    = 24 * time.Hour
+	idempotencyLease  = 60 * time.Second // after this a crashed request's key can be retried
+	maxIdempotencyKey = 255
+)
+
+// bodyCaptureWriter keeps a copy of everything written to the response
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key. Keys are scoped per route and per user, so it must
+// run after AuthMiddleware. Requests without the header pass straight through.
+func Idempotency(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.FullPath()+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+
+		userID := c.GetString("userID")
+		now := time.Now()
+		rec := models.IdempotencyRecord{
+			ID:          scope + "|" + userID + "|" + key,
+			Scope:       scope,
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			Status:      models.IdempotencyInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyTTL),
+			LeaseID:     primitive.NewObjectID().Hex(),
+			LeaseUntil:  now.Add(idempotencyLease),
+		}
+
+		repo := idempotency.IdempotencyRepository(&idempotency.IdempotencyRepo{})
+		existing, err := repo.Reserve(rec)
+		if err != nil {
+			log.Println("Idempotency reserve failed:", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Idempotency-Key reused with a different request"})
+			case existing.Status != models.IdempotencyCompleted || existing.Response == nil:
+				if wait := time.Until(existing.LeaseUntil); wait > 0 {
+					c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+				}
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "request with this Idempotency-Key is still in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.AbortWithStatusJSON(existing.ResponseCode, existing.Response)
+			}
+			return
+		}
+
+		capture := bodyCaptureWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = capture
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			// Server-side failures are not final; free the key for a retry
+			if err := repo.Release(rec.ID, rec.LeaseID); err != nil {
+				log.Println("Idempotency release failed:", err)
+			}
+			return
+		}
+
+		var resp models.GeneralResponse
+		if err := json.Unmarshal(capture.body.Bytes(), &resp); err != nil {
+			log.Println("Idempotency response not a GeneralResponse:", err)
+			if err := repo.Release(rec.ID, rec.LeaseID); err != nil {
+				log.Println("Idempotency release failed:", err)
+			}
+			return
+		}
+
+		if err := repo.Complete(rec.ID, rec.LeaseID, status, resp); err != nil {
+			log.Println("Idempotency complete failed:", err)
+		}
+	}
+}
@@ -6,22 +6,39 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Claims struct {
 	Email  string
 	UserID string
 	Role   string
+	// SessionID ties the access token to a row in the sessions collection so
+	// it can be revoked server-side
+	SessionID string
+	// Purpose is empty for access tokens and PurposePreAuth for the token
+	// handed out between the password and TOTP login steps
+	Purpose string
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(email, userID, role string) (string, error) {
-	expireTime := time.Now().Add(60 * time.Minute)
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	PreAuthTokenTTL = 5 * time.Minute
+
+	PurposePreAuth = "2fa"
+)
+
+func GenerateJWT(email, userID, role, sessionID string) (string, error) {
+	expireTime := time.Now().Add(AccessTokenTTL)
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
 		Email:  email,
 		UserID: userID,
 		Role:   role,
+
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 		},
@@ -50,3 +67,31 @@ func ValidateToken(tokenString string) (Claims, error) {
 	}
 	return claims, nil
 }
+
+// GeneratePreAuthToken issues a short-lived token that only proves the
+// password step passed; AuthMiddleware rejects it. Its jti keys the count of
+// second-factor attempts made with it.
+func GeneratePreAuthToken(email, userID, role string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		Email:   email,
+		UserID:  userID,
+		Role:    role,
+		Purpose: PurposePreAuth,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(PreAuthTokenTTL)),
+		},
+	})
+	return token.SignedString([]byte(config.Cfg.JWTSecret))
+}
+
+func ValidatePreAuthToken(tokenString string) (Claims, error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return claims, err
+	}
+	if claims.Purpose != PurposePreAuth || claims.ID == "" {
+		return claims, errors.New("not a pre-auth token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,105 @@
+package midleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"p2p/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles carried in the JWT "role" claim
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// OwnerResolver returns the user ID that owns the resource addressed by the request
+type OwnerResolver func(c *gin.Context) (string, error)
+
+// RequireRole allows the request through only when the role set by AuthMiddleware
+// is one of the given roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "role not found in context"})
+			return
+		}
+
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+
+		log.Printf("Role %q denied for %s %s", role, c.Request.Method, c.FullPath())
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
+
+// AdminOnly allows only admin tokens
+func AdminOnly() gin.HandlerFunc {
+	return RequireRole(RoleAdmin)
+}
+
+// UserOnly allows only end-user tokens
+func UserOnly() gin.HandlerFunc {
+	return RequireRole(RoleUser)
+}
+
+// OwnerOrAdmin lets admins through unconditionally and end users only when
+// they own the resource returned by resolve.
+func OwnerOrAdmin(resolve OwnerResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == RoleAdmin {
+			c.Next()
+			return
+		}
+		if role != RoleUser {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+			return
+		}
+
+		ownerID, err := resolve(c)
+		if err != nil {
+			if errors.Is(err, ErrResourceNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		if ownerID == "" || ownerID != c.GetString("userID") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not the owner of this resource"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// OwnerFromParam resolves the owner straight from a path parameter holding a user ID
+func OwnerFromParam(param string) OwnerResolver {
+	return func(c *gin.Context) (string, error) {
+		return c.Param(param), nil
+	}
+}
+
+// ErrResourceNotFound is returned by resolvers when the addressed resource does not exist
+var ErrResourceNotFound = errors.New("resource not found")
+
+// CurrentActor returns the authenticated caller for status history entries.
+// It must run after AuthMiddleware.
+func CurrentActor(c *gin.Context) (models.Actor, error) {
+	id, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		return models.Actor{}, errors.New("user ID not found in context")
+	}
+	return models.Actor{ID: id, Role: c.GetString("role")}, nil
+}
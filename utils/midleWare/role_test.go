@@ -0,0 +1,75 @@
+package midleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serve runs one request through mw with the given role and user already in
+// the context, as AuthMiddleware would leave them
+func serve(t *testing.T, mw gin.HandlerFunc, role, userID string) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/:id", func(c *gin.Context) {
+		c.Set("role", role)
+		c.Set("userID", userID)
+	}, mw, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/owner-1", nil))
+	return w.Code
+}
+
+func TestRequireRole(t *testing.T) {
+	cases := []struct {
+		name string
+		mw   gin.HandlerFunc
+		role string
+		want int
+	}{
+		{"admin on admin-only", AdminOnly(), RoleAdmin, http.StatusOK},
+		{"user on admin-only", AdminOnly(), RoleUser, http.StatusForbidden},
+		{"user on user-only", UserOnly(), RoleUser, http.StatusOK},
+		{"admin on user-only", UserOnly(), RoleAdmin, http.StatusForbidden},
+		{"unknown role", RequireRole(RoleAdmin, RoleUser), "guest", http.StatusForbidden},
+		{"no role", AdminOnly(), "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if got := serve(t, tc.mw, tc.role, "user-1"); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOwnerOrAdmin(t *testing.T) {
+	owner := OwnerFromParam("id")
+	notFound := func(*gin.Context) (string, error) { return "", ErrResourceNotFound }
+	failing := func(*gin.Context) (string, error) { return "", errors.New("lookup failed") }
+
+	cases := []struct {
+		name    string
+		resolve OwnerResolver
+		role    string
+		userID  string
+		want    int
+	}{
+		{"owner", owner, RoleUser, "owner-1", http.StatusOK},
+		{"other user", owner, RoleUser, "user-2", http.StatusForbidden},
+		{"admin", owner, RoleAdmin, "admin-1", http.StatusOK},
+		{"unknown role", owner, "guest", "owner-1", http.StatusForbidden},
+		{"missing resource", notFound, RoleUser, "owner-1", http.StatusNotFound},
+		{"failed lookup", failing, RoleUser, "owner-1", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if got := serve(t, OwnerOrAdmin(tc.resolve), tc.role, tc.userID); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package midleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"p2p/models"
+	"p2p/repo/wstickets"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWebSocketAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prevCheck, prevRedeem := SessionCheck, RedeemWebSocketTicket
+	t.Cleanup(func() { SessionCheck, RedeemWebSocketTicket = prevCheck, prevRedeem })
+	SessionCheck = func(Claims) (int, error) { return 0, nil }
+
+	// One live ticket; redeeming consumes it like the repo does
+	sum := sha256.Sum256([]byte("good-ticket"))
+	tickets := map[string]*models.WebSocketTicket{
+		hex.EncodeToString(sum[:]): {UserID: "user-1", Role: RoleUser, SessionID: "session-1"},
+	}
+	RedeemWebSocketTicket = func(hash string) (*models.WebSocketTicket, error) {
+		t, ok := tickets[hash]
+		if !ok {
+			return nil, wstickets.ErrTicketInvalid
+		}
+		delete(tickets, hash)
+		return t, nil
+	}
+
+	r := gin.New()
+	r.GET("/ws", WebSocketAuth(), func(c *gin.Context) { c.String(http.StatusOK, c.GetString("userID")) })
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws"+query, nil))
+		return w
+	}
+
+	if w := get("?ticket=good-ticket"); w.Code != http.StatusOK || w.Body.String() != "user-1" {
+		t.Errorf("valid ticket: got %d %q", w.Code, w.Body.String())
+	}
+	cases := map[string]string{
+		"reused ticket":  "?ticket=good-ticket",
+		"unknown ticket": "?ticket=nope",
+		"no ticket":      "",
+		"token in query": "?token=some.jwt.value",
+	}
+	for name, query := range cases {
+		if w := get(query); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: got %d, want 401", name, w.Code)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/x/bsonx/bsoncore"
+)
+
+// Rounding scales for the two currencies the platform handles
+const (
+	USDTScale = 6
+	INRScale  = 2
+)
+
+// scale is the number of fractional digits every Amount carries internally
+const scale = 18
+
+var unit = new(big.Int).Exp(big.NewInt(10), big.NewInt(scale), nil)
+
+// Amount is an exact decimal money value. It is stored in MongoDB as
+// Decimal128 and serialized to JSON as a string. The zero value is 0.
+type Amount struct {
+	v *big.Int // value * 10^scale
+}
+
+var ErrInvalidAmount = errors.New("invalid decimal amount")
+
+// maxDigits caps the digits Parse accepts, well past anything the platform
+// handles, so client input cannot make it do unbounded work
+const maxDigits = 40
+
+// storedDigits is the most significant digits a Decimal128 coefficient holds
+const storedDigits = 34
+
+// plainDecimal is an optional sign, digits and an optional fraction; no
+// exponents or fractions like "1/3"
+var plainDecimal = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// Zero returns an Amount of 0
+func Zero() Amount {
+	return Amount{}
+}
+
+// New returns an Amount holding the integer i
+func New(i int64) Amount {
+	return Amount{v: new(big.Int).Mul(big.NewInt(i), unit)}
+}
+
+// Parse reads a plain decimal string such as "12", "-0.5" or "88.25" of at
+// most maxDigits digits. Digits beyond the internal scale are rounded half
+// away from zero, and the result must still fit in a Decimal128.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Amount{}, fmt.Errorf("%w: empty string", ErrInvalidAmount)
+	}
+	if !plainDecimal.MatchString(s) || countDigits(s) > maxDigits {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	a := fromRat(r)
+	if err := a.storable(); err != nil {
+		return Amount{}, err
+	}
+	return a, nil
+}
+
+// MustParse is Parse for constants; it panics on bad input
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// FromFloat converts a float64 using its shortest decimal representation.
+// It exists for legacy documents written before amounts were decimal.
+func FromFloat(f float64) Amount {
+	a, err := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		// Too many digits for Parse; take the binary value exactly instead
+		r := new(big.Rat).SetFloat64(f)
+		if r == nil {
+			return Amount{}
+		}
+		return fromRat(r)
+	}
+	return a
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// storable fails when a has more significant digits than Decimal128 keeps
+func (a Amount) storable() error {
+	s := strings.TrimPrefix(a.String(), "-")
+	if !strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+	}
+	s = strings.TrimLeft(strings.Replace(s, ".", "", 1), "0")
+	if len(s) > storedDigits {
+		return fmt.Errorf("%w: %s has more than %d significant digits", ErrInvalidAmount, a, storedDigits)
+	}
+	return nil
+}
+
+// fromDecimal128 converts a stored Decimal128, whose String form may use an
+// exponent that Parse refuses
+func fromDecimal128(d bson.Decimal128) (Amount, error) {
+	coef, exp, err := d.BigInt()
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil)
+	if exp >= 0 {
+		return Amount{v: new(big.Int).Mul(new(big.Int).Mul(coef, pow), unit)}, nil
+	}
+	return Amount{v: divRound(new(big.Int).Mul(coef, unit), pow)}, nil
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func fromRat(r *big.Rat) Amount {
+	n := new(big.Int).Mul(r.Num(), unit)
+	return Amount{v: divRound(n, r.Denom())}
+}
+
+// divRound divides n by d rounding half away from zero
+func divRound(n, d *big.Int) *big.Int {
+	q, m := new(big.Int).QuoRem(n, d, new(big.Int))
+	if m.Sign() == 0 {
+		return q
+	}
+	twice := new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2))
+	if twice.Cmp(new(big.Int).Abs(d)) >= 0 {
+		if n.Sign()*d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func (a Amount) raw() *big.Int {
+	if a.v == nil {
+		return new(big.Int)
+	}
+	return a.v
+}
+
+// -------------------- ARITHMETIC --------------------
+
+func (a Amount) Add(b Amount) Amount {
+	return Amount{v: new(big.Int).Add(a.raw(), b.raw())}
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{v: new(big.Int).Sub(a.raw(), b.raw())}
+}
+
+func (a Amount) Neg() Amount {
+	return Amount{v: new(big.Int).Neg(a.raw())}
+}
+
+// Mul multiplies two amounts, rounding the product to the internal scale
+func (a Amount) Mul(b Amount) Amount {
+	p := new(big.Int).Mul(a.raw(), b.raw())
+	return Amount{v: divRound(p, unit)}
+}
+
+// Div divides a by b, rounding to the internal scale. Dividing by zero returns zero.
+func (a Amount) Div(b Amount) Amount {
+	if b.Sign() == 0 {
+		return Amount{}
+	}
+	n := new(big.Int).Mul(a.raw(), unit)
+	return Amount{v: divRound(n, b.raw())}
+}
+
+// Round rounds to dp fractional digits, half away from zero
+func (a Amount) Round(dp int) Amount {
+	if dp >= scale {
+		return a
+	}
+	step := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-dp)), nil)
+	q := divRound(a.raw(), step)
+	return Amount{v: q.Mul(q, step)}
+}
+
+// RoundUSDT rounds to USDT precision (6 dp)
+func (a Amount) RoundUSDT() Amount {
+	return a.Round(USDTScale)
+}
+
+// RoundINR rounds to INR precision (2 dp)
+func (a Amount) RoundINR() Amount {
+	return a.Round(INRScale)
+}
+
+// ToINR converts a USDT amount at the given INR rate, rounded to INR precision
+func ToINR(usdt, rate Amount) Amount {
+	return usdt.Mul(rate).RoundINR()
+}
+
+// Percent returns p percent of a, rounded to the internal scale
+func (a Amount) Percent(p Amount) Amount {
+	return a.Mul(p).Div(New(100))
+}
+
+// -------------------- COMPARISON --------------------
+
+func (a Amount) Cmp(b Amount) int {
+	return a.raw().Cmp(b.raw())
+}
+
+func (a Amount) Sign() int {
+	return a.raw().Sign()
+}
+
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+func (a Amount) IsPositive() bool {
+	return a.Sign() > 0
+}
+
+func (a Amount) IsNegative() bool {
+	return a.Sign() < 0
+}
+
+func (a Amount) LessThan(b Amount) bool {
+	return a.Cmp(b) < 0
+}
+
+func (a Amount) GreaterThan(b Amount) bool {
+	return a.Cmp(b) > 0
+}
+
+// Min returns the smaller of a and b
+func Min(a, b Amount) Amount {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of a and b
+func Max(a, b Amount) Amount {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// -------------------- FORMATTING --------------------
+
+// String formats the amount with trailing fractional zeros removed
+func (a Amount) String() string {
+	s := a.StringFixed(scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// StringFixed formats the amount rounded to exactly dp fractional digits
+func (a Amount) StringFixed(dp int) string {
+	r := a.Round(dp).raw()
+	neg := r.Sign() < 0
+	digits := new(big.Int).Abs(r).String()
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	intPart := digits[:len(digits)-scale]
+	fracPart := digits[len(digits)-scale:]
+	if dp < scale {
+		fracPart = fracPart[:dp]
+	}
+
+	s := intPart
+	if dp > 0 {
+		s += "." + fracPart
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// Float64 is a lossy conversion for logging and display only
+func (a Amount) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(a.raw(), unit).Float64()
+	return f
+}
+
+// -------------------- JSON --------------------
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts a plain decimal string, a JSON number without an
+// exponent or null
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" || s == `""` {
+		*a = Amount{}
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// -------------------- BSON --------------------
+
+// MarshalBSONValue stores the amount as Decimal128 with trailing zeros
+// trimmed. Results of arithmetic too precise to store are refused rather
+// than rounded silently.
+func (a Amount) MarshalBSONValue() (byte, []byte, error) {
+	if err := a.storable(); err != nil {
+		return 0, nil, err
+	}
+	d, err := bson.ParseDecimal128(a.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	high, low := d.GetBytes()
+	return byte(bson.TypeDecimal128), bsoncore.AppendDecimal128(nil, high, low), nil
+}
+
+// UnmarshalBSONValue reads Decimal128 as well as the double, integer and
+// string values left behind by documents written before this type existed.
+func (a *Amount) UnmarshalBSONValue(t byte, data []byte) error {
+	v := bsoncore.Value{Type: bsoncore.Type(t), Data: data}
+
+	switch bson.Type(t) {
+	case bson.TypeDecimal128:
+		high, low, ok := v.Decimal128OK()
+		if !ok {
+			return fmt.Errorf("%w: malformed decimal128", ErrInvalidAmount)
+		}
+		parsed, err := fromDecimal128(bson.NewDecimal128(high, low))
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case bson.TypeDouble:
+		f, ok := v.DoubleOK()
+		if !ok {
+			return fmt.Errorf("%w: malformed double", ErrInvalidAmount)
+		}
+		*a = FromFloat(f)
+	case bson.TypeInt32:
+		i, ok := v.Int32OK()
+		if !ok {
+			return fmt.Errorf("%w: malformed int32", ErrInvalidAmount)
+		}
+		*a = New(int64(i))
+	case bson.TypeInt64:
+		i, ok := v.Int64OK()
+		if !ok {
+			return fmt.Errorf("%w: malformed int64", ErrInvalidAmount)
+		}
+		*a = New(i)
+	case bson.TypeString:
+		s, ok := v.StringValueOK()
+		if !ok {
+			return fmt.Errorf("%w: malformed string", ErrInvalidAmount)
+		}
+		if strings.TrimSpace(s) == "" {
+			*a = Amount{}
+			return nil
+		}
+		parsed, err := Parse(s)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case bson.TypeNull, bson.TypeUndefined:
+		*a = Amount{}
+	default:
+		return fmt.Errorf("%w: cannot decode BSON type %v", ErrInvalidAmount, bson.Type(t))
+	}
+	return nil
+}
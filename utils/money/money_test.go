@@ -0,0 +1,97 @@
+package money
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParse(t *testing.T) {
+	valid := map[string]string{
+		"12":                    "12",
+		"-0.5":                  "-0.5",
+		"+88.250":               "88.25",
+		" 1.000001":             "1.000001",
+		"0.1234567890123456789": "0.123456789012345679",
+	}
+	for in, want := range valid {
+		a, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", in, err)
+			continue
+		}
+		if a.String() != want {
+			t.Errorf("Parse(%q) = %s, want %s", in, a, want)
+		}
+	}
+
+	invalid := []string{"", "1/3", "1e5", "1e1000000", "1E-3", ".5", "5.", "0x10", "1,000", "NaN", "Inf",
+		"12345678901234567890123456789012345678901"}
+	for _, in := range invalid {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalidAmount", in, err)
+		}
+	}
+}
+
+func TestUnmarshalJSONRejectsExponents(t *testing.T) {
+	var a Amount
+	for _, in := range []string{`1e1000000`, `"1/3"`} {
+		if err := a.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) accepted", in)
+		}
+	}
+	if err := a.UnmarshalJSON([]byte(`10.5`)); err != nil || a.String() != "10.5" {
+		t.Errorf("UnmarshalJSON(10.5) = %s, %v", a, err)
+	}
+}
+
+func TestDecimal128WithExponent(t *testing.T) {
+	for in, want := range map[string]string{"1E-7": "0.0000001", "1.5E+3": "1500", "-2.25": "-2.25"} {
+		d, err := bson.ParseDecimal128(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := fromDecimal128(d)
+		if err != nil || a.String() != want {
+			t.Errorf("fromDecimal128(%s) = %s, %v, want %s", in, a, err, want)
+		}
+	}
+}
+
+func TestFromFloatOutsideParseRange(t *testing.T) {
+	if got := FromFloat(1e50).Float64(); got != 1e50 {
+		t.Errorf("FromFloat(1e50) = %v", got)
+	}
+	if got := FromFloat(0.25).String(); got != "0.25" {
+		t.Errorf("FromFloat(0.25) = %s", got)
+	}
+}
+
+func TestDecimal128Boundary(t *testing.T) {
+	// 34 significant digits is the most a Decimal128 holds
+	fits := []string{"1234567890123456.789012345678901234", "1234567890123456789012345678901234", "100000000000000000000000000000000000000"}
+	for _, in := range fits {
+		a, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", in, err)
+			continue
+		}
+		if _, _, err := a.MarshalBSONValue(); err != nil {
+			t.Errorf("marshal %s: %v", in, err)
+		}
+	}
+
+	for _, in := range []string{"12345678901234567.890123456789012345", "-12345678901234567890123456789012345"} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalidAmount", in, err)
+		}
+	}
+
+	// Arithmetic can outgrow what Parse admits; storing it must fail clearly
+	wide := MustParse("12345678901234567").Add(MustParse("0.000000000000000001"))
+	if _, _, err := wide.MarshalBSONValue(); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("marshal %s = %v, want ErrInvalidAmount", wide, err)
+	}
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"p2p/config"
+	"strings"
+	"sync"
+)
+
+// Message is a plain text notification to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users, e.g. password reset codes
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ErrNoDelivery means there is no SMTP server and DevMode is off, so codes
+// could never reach their users
+var ErrNoDelivery = errors.New("SMTPHost is required unless DevMode is set")
+
+// LogNotifier writes messages to the server log. It is the local stand-in
+// used in DevMode when no SMTP server is configured. Bodies carry live
+// codes, so they are only printed when ShowBody is set.
+type LogNotifier struct {
+	ShowBody bool
+}
+
+func (n *LogNotifier) Send(_ context.Context, msg Message) error {
+	body := "[body redacted]"
+	if n.ShowBody {
+		body = msg.Body
+	}
+	log.Printf("📧 [notify] to=%s subject=%q\n%s", msg.To, msg.Subject, body)
+	return nil
+}
+
+// CheckConfig refuses a setup that would drop messages outside DevMode
+func CheckConfig() error {
+	if config.Cfg.SMTPHost == "" && !config.Cfg.DevMode {
+		return ErrNoDelivery
+	}
+	return nil
+}
+
+// SMTPNotifier sends messages as plain text email
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (n *SMTPNotifier) Send(_ context.Context, msg Message) error {
+	addr := fmt.Sprintf("%s:%d", n.Host, n.Port)
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	body := strings.Join([]string{
+		"From: " + n.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	return smtp.SendMail(addr, auth, n.From, []string{msg.To}, []byte(body))
+}
+
+var (
+	overrideMu sync.RWMutex
+	override   Notifier
+)
+
+// Use replaces the configured notifier. Passing nil restores the
+// configuration-driven one.
+func Use(n Notifier) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	override = n
+}
+
+// FromConfig returns SMTP delivery when SMTPHost is set, otherwise the log.
+// Message bodies reach the log only in DevMode.
+func FromConfig() Notifier {
+	overrideMu.RLock()
+	n := override
+	overrideMu.RUnlock()
+	if n != nil {
+		return n
+	}
+
+	cfg := config.Cfg
+	if cfg.SMTPHost == "" {
+		return &LogNotifier{ShowBody: cfg.DevMode}
+	}
+	from := cfg.SMTPFrom
+	if from == "" {
+		from = cfg.SMTPUsername
+	}
+	return &SMTPNotifier{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     from,
+	}
+}
@@ -0,0 +1,124 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is one push to a connected user
+type Event struct {
+	Type string    `json:"type"`
+	Data any       `json:"data,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+// Broker fans events out to every server instance and tracks who is
+// connected anywhere. MemoryBroker covers a single instance; a shared broker
+// (e.g. Redis pub/sub) lets several instances serve the same users.
+type Broker interface {
+	// Publish delivers ev to every subscriber, on every instance
+	Publish(ctx context.Context, userID string, ev Event) error
+	// Subscribe calls fn for every published event until cancel is called
+	Subscribe(fn func(userID string, ev Event)) (cancel func())
+	// Connect and Disconnect count a user's connections across instances and
+	// report whether this one was the first or the last
+	Connect(ctx context.Context, userID string) (first bool, err error)
+	Disconnect(ctx context.Context, userID string) (last bool, err error)
+	// Online reports which of userIDs have at least one connection
+	Online(ctx context.Context, userIDs []string) (map[string]bool, error)
+}
+
+// MemoryBroker is an in-process Broker; it is the default and what tests use
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[int]func(userID string, ev Event)
+	nextID      int
+	connections map[string]int
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: map[int]func(string, Event){},
+		connections: map[string]int{},
+	}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, userID string, ev Event) error {
+	b.mu.RLock()
+	subs := make([]func(string, Event), 0, len(b.subscribers))
+	for _, fn := range b.subscribers {
+		subs = append(subs, fn)
+	}
+	b.mu.RUnlock()
+
+	for _, fn := range subs {
+		fn(userID, ev)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(fn func(userID string, ev Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+func (b *MemoryBroker) Connect(_ context.Context, userID string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connections[userID]++
+	return b.connections[userID] == 1, nil
+}
+
+func (b *MemoryBroker) Disconnect(_ context.Context, userID string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.connections[userID] <= 1 {
+		delete(b.connections, userID)
+		return true, nil
+	}
+	b.connections[userID]--
+	return false, nil
+}
+
+func (b *MemoryBroker) Online(_ context.Context, userIDs []string) (map[string]bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	online := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		online[id] = b.connections[id] > 0
+	}
+	return online, nil
+}
+
+var (
+	overrideMu sync.RWMutex
+	override   Broker
+	memory     = NewMemoryBroker()
+)
+
+// Use replaces the configured broker. Passing nil restores the in-memory
+// one. Call it before the hub is first used.
+func Use(b Broker) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	override = b
+}
+
+// FromConfig returns the broker set with Use, or the in-memory one
+func FromConfig() Broker {
+	overrideMu.RLock()
+	defer overrideMu.RUnlock()
+	if override != nil {
+		return override
+	}
+	return memory
+}
@@ -0,0 +1,149 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// sendBuffer is how many events a client may fall behind before it is dropped
+const sendBuffer = 64
+
+// Client is one open connection of a user
+type Client struct {
+	UserID string
+
+	send      chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewClient(userID string) *Client {
+	return &Client{UserID: userID, send: make(chan Event, sendBuffer), done: make(chan struct{})}
+}
+
+// Events yields what should be written to the connection
+func (c *Client) Events() <-chan Event {
+	return c.send
+}
+
+// Done is closed once the client is closed, e.g. because it fell behind
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Push queues ev for this connection only, e.g. a reply to the client. It
+// reports false when the client is too far behind to take it.
+func (c *Client) Push(ev Event) bool {
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	select {
+	case c.send <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+// Hub delivers broker events to the clients connected to this instance
+type Hub struct {
+	broker Broker
+
+	mu      sync.RWMutex
+	clients map[string]map[*Client]struct{}
+}
+
+// NewHub subscribes a hub to broker
+func NewHub(broker Broker) *Hub {
+	h := &Hub{broker: broker, clients: map[string]map[*Client]struct{}{}}
+	broker.Subscribe(h.deliver)
+	return h
+}
+
+var (
+	defaultOnce sync.Once
+	defaultHub  *Hub
+)
+
+// Default is the process-wide hub on the configured broker
+func Default() *Hub {
+	defaultOnce.Do(func() {
+		defaultHub = NewHub(FromConfig())
+	})
+	return defaultHub
+}
+
+// deliver hands ev to the user's local clients, dropping any that cannot keep up
+func (h *Hub) deliver(userID string, ev Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients[userID] {
+		select {
+		case c.send <- ev:
+		default:
+			log.Printf("Realtime client of %s fell behind, closing", userID)
+			c.Close()
+		}
+	}
+}
+
+// Register adds c and reports whether the user just came online
+func (h *Hub) Register(c *Client) (bool, error) {
+	h.mu.Lock()
+	if h.clients[c.UserID] == nil {
+		h.clients[c.UserID] = map[*Client]struct{}{}
+	}
+	h.clients[c.UserID][c] = struct{}{}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return h.broker.Connect(ctx, c.UserID)
+}
+
+// Unregister removes c and reports whether the user went offline
+func (h *Hub) Unregister(c *Client) (bool, error) {
+	c.Close()
+
+	h.mu.Lock()
+	set := h.clients[c.UserID]
+	if _, ok := set[c]; !ok {
+		h.mu.Unlock()
+		return false, nil
+	}
+	delete(set, c)
+	if len(set) == 0 {
+		delete(h.clients, c.UserID)
+	}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return h.broker.Disconnect(ctx, c.UserID)
+}
+
+// Publish sends an event of type to every connection of the users
+func (h *Hub) Publish(eventType string, data any, userIDs ...string) {
+	ev := Event{Type: eventType, Data: data, At: time.Now()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, id := range userIDs {
+		if err := h.broker.Publish(ctx, id, ev); err != nil {
+			log.Printf("Failed to publish %s event to %s: %v", eventType, id, err)
+		}
+	}
+}
+
+// Online reports which of userIDs are connected on any instance
+func (h *Hub) Online(userIDs []string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return h.broker.Online(ctx, userIDs)
+}
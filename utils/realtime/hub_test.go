@@ -0,0 +1,96 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+)
+
+// next waits briefly for the client's next event
+func next(t *testing.T, c *Client) (Event, bool) {
+	t.Helper()
+	select {
+	case ev := <-c.Events():
+		return ev, true
+	case <-time.After(100 * time.Millisecond):
+		return Event{}, false
+	}
+}
+
+func TestHubFanOut(t *testing.T) {
+	// Two hubs on one broker stand in for two server instances
+	broker := NewMemoryBroker()
+	a, b := NewHub(broker), NewHub(broker)
+
+	phone, laptop, other := NewClient("alice"), NewClient("alice"), NewClient("bob")
+	for _, reg := range []struct {
+		hub *Hub
+		c   *Client
+	}{{a, phone}, {b, laptop}, {a, other}} {
+		if _, err := reg.hub.Register(reg.c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.Publish("chat.message", "hi", "alice")
+
+	for name, c := range map[string]*Client{"phone": phone, "laptop": laptop} {
+		ev, ok := next(t, c)
+		if !ok || ev.Type != "chat.message" || ev.Data != "hi" || ev.At.IsZero() {
+			t.Errorf("%s: got %+v, %v", name, ev, ok)
+		}
+	}
+	if ev, ok := next(t, other); ok {
+		t.Errorf("bob got alice's event %+v", ev)
+	}
+}
+
+func TestHubDropsSlowClient(t *testing.T) {
+	hub := NewHub(NewMemoryBroker())
+	c := NewClient("alice")
+	if _, err := hub.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i <= sendBuffer; i++ {
+		hub.Publish("chat.message", i, "alice")
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Error("client that fell behind was not closed")
+	}
+}
+
+func TestHubPresence(t *testing.T) {
+	broker := NewMemoryBroker()
+	a, b := NewHub(broker), NewHub(broker)
+	first, second := NewClient("alice"), NewClient("alice")
+
+	if online, _ := a.Register(first); !online {
+		t.Error("first connection should bring alice online")
+	}
+	if online, _ := b.Register(second); online {
+		t.Error("second connection should not report alice coming online again")
+	}
+
+	status, err := a.Online([]string{"alice", "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status["alice"] || status["bob"] {
+		t.Errorf("online = %v", status)
+	}
+
+	if offline, _ := a.Unregister(first); offline {
+		t.Error("alice still has a connection on the other instance")
+	}
+	if offline, _ := a.Unregister(first); offline {
+		t.Error("unregistering twice must not count as going offline")
+	}
+	if offline, _ := b.Unregister(second); !offline {
+		t.Error("last connection should take alice offline")
+	}
+	if status, _ := b.Online([]string{"alice"}); status["alice"] {
+		t.Error("alice still reported online")
+	}
+}
@@ -0,0 +1,110 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"p2p/models"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrIllegalTransition is returned when the target is not one allowed move away
+	ErrIllegalTransition = errors.New("illegal status transition")
+	// ErrUnknownStatus is returned for status names the machine does not know
+	ErrUnknownStatus = errors.New("unknown status")
+)
+
+// Machine is a set of allowed status transitions for one document type
+type Machine struct {
+	name  string
+	edges map[string][]string
+}
+
+func New(name string, edges map[string][]string) *Machine {
+	return &Machine{name: name, edges: edges}
+}
+
+// Deposit: Pending → UnderReview → Processing → Approved/Failed/Rejected,
+// Pending may also be Rejected, Cancelled or Expired directly
+var Deposit = New("deposit", map[string][]string{
+	models.StatusPending:     {models.StatusUnderReview, models.StatusRejected, models.StatusCancelled, models.StatusExpired},
+	models.StatusUnderReview: {models.StatusProcessing, models.StatusRejected},
+	models.StatusProcessing:  {models.StatusApproved, models.StatusFailed, models.StatusRejected},
+})
+
+// Withdrawl follows the deposit flow with a maker-checker detour through
+// AwaitingSecondApproval for large amounts. The checker either approves
+// directly or puts the withdrawal into a payout batch (Processing).
+var Withdrawl = New("withdrawl", map[string][]string{
+	models.StatusPending:                   {models.StatusUnderReview, models.StatusRejected, models.StatusCancelled, models.StatusExpired},
+	models.StatusUnderReview:               {models.StatusProcessing, models.WithdrawlAwaitingSecondApproval, models.StatusRejected},
+	models.StatusProcessing:                {models.StatusApproved, models.StatusFailed, models.StatusRejected, models.WithdrawlAwaitingSecondApproval},
+	models.WithdrawlAwaitingSecondApproval: {models.StatusApproved, models.StatusProcessing, models.StatusRejected},
+})
+
+// Ticket: Open and Pending swap as either side replies, a reply to a
+// Resolved ticket reopens it on the other side, Closed is final
+var Ticket = New("ticket", map[string][]string{
+	models.TicketOpen:     {models.TicketPending, models.TicketResolved, models.TicketClosed},
+	models.TicketPending:  {models.TicketOpen, models.TicketResolved, models.TicketClosed},
+	models.TicketResolved: {models.TicketOpen, models.TicketPending, models.TicketClosed},
+})
+
+var known = []string{
+	models.StatusPending, models.StatusUnderReview, models.StatusProcessing,
+	models.StatusApproved, models.StatusFailed, models.StatusRejected,
+	models.StatusCancelled, models.StatusExpired, models.WithdrawlAwaitingSecondApproval,
+}
+
+// Normalize maps a client supplied status to its canonical spelling
+func Normalize(status string) (string, error) {
+	s := strings.TrimSpace(status)
+	for _, k := range known {
+		if strings.EqualFold(s, k) {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownStatus, status)
+}
+
+// IsFinal reports whether no transition leaves status
+func IsFinal(status string) bool {
+	switch status {
+	case models.StatusApproved, models.StatusFailed, models.StatusRejected,
+		models.StatusCancelled, models.StatusExpired:
+		return true
+	}
+	return false
+}
+
+// Can reports whether from → to is a single allowed move
+func (m *Machine) Can(from, to string) bool {
+	for _, next := range m.edges[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Step validates a single move from → to and builds its status history
+// entry. Callers ask for the next status, never a distant one; anything that
+// is not one allowed move is an illegal transition.
+func (m *Machine) Step(from, to string, actor models.Actor, reason string, at time.Time) (models.StatusChange, error) {
+	if !m.Can(from, to) {
+		return models.StatusChange{}, m.illegal(from, to)
+	}
+	return models.StatusChange{
+		From:      from,
+		To:        to,
+		ActorID:   actor.ID,
+		ActorRole: actor.Role,
+		Reason:    reason,
+		At:        at,
+	}, nil
+}
+
+func (m *Machine) illegal(from, to string) error {
+	return fmt.Errorf("%w: %s %s → %s", ErrIllegalTransition, m.name, from, to)
+}
@@ -0,0 +1,40 @@
+package statemachine
+
+import (
+	"errors"
+	"p2p/models"
+	"testing"
+	"time"
+)
+
+func TestStep(t *testing.T) {
+	actor := models.Actor{Role: "admin"}
+	cases := []struct {
+		name     string
+		m        *Machine
+		from, to string
+		ok       bool
+	}{
+		{"deposit next step", Deposit, models.StatusPending, models.StatusUnderReview, true},
+		{"deposit skips review", Deposit, models.StatusPending, models.StatusApproved, false},
+		{"deposit skips processing", Deposit, models.StatusUnderReview, models.StatusApproved, false},
+		{"deposit final", Deposit, models.StatusApproved, models.StatusRejected, false},
+		{"same status", Deposit, models.StatusPending, models.StatusPending, false},
+		{"withdrawl parked for checker", Withdrawl, models.StatusProcessing, models.WithdrawlAwaitingSecondApproval, true},
+		{"withdrawl checker approves", Withdrawl, models.WithdrawlAwaitingSecondApproval, models.StatusApproved, true},
+		{"withdrawl pending to checker", Withdrawl, models.StatusPending, models.WithdrawlAwaitingSecondApproval, false},
+		{"ticket reply to resolved", Ticket, models.TicketResolved, models.TicketPending, true},
+	}
+	for _, tc := range cases {
+		change, err := tc.m.Step(tc.from, tc.to, actor, "reason", time.Now())
+		if tc.ok {
+			if err != nil || change.From != tc.from || change.To != tc.to || change.Reason != "reason" {
+				t.Errorf("%s: got %+v, %v", tc.name, change, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("%s: got %v, want ErrIllegalTransition", tc.name, err)
+		}
+	}
+}